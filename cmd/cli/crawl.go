@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"go.uber.org/zap"
+
+	"github.com/erainogo/html-analyzer/internal/app/crawler"
+	"github.com/erainogo/html-analyzer/internal/app/services"
+	"github.com/erainogo/html-analyzer/internal/config"
+	"github.com/erainogo/html-analyzer/internal/handlers"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+const (
+	// crawlCommand the subcommand that crawls a site from a seed url instead of reading an input.
+	crawlCommand = "crawl"
+	// crawlStatusCommand the subcommand that prints the progress of the crawl of a state file.
+	crawlStatusCommand = "crawl-status"
+)
+
+// runCrawl crawls the site of seed, one row per analyzed page, and reports the site summary.
+func runCrawl(
+	ctx context.Context,
+	logger *zap.SugaredLogger,
+	hc *http.Client,
+	seed, outputPath string,
+	policy *entities.Policy,
+	rules []entities.ExtractionRule,
+) {
+	include, err := crawler.ParsePatterns(*config.Config.CrawlInclude)
+	if err != nil {
+		logger.Fatalf("Failed to parse crawl include patterns: %v", err)
+	}
+
+	exclude, err := crawler.ParsePatterns(*config.Config.CrawlExclude)
+	if err != nil {
+		logger.Fatalf("Failed to parse crawl exclude patterns: %v", err)
+	}
+
+	fetcher, err := setUpFetcher(hc)
+	if err != nil {
+		logger.Fatalf("Failed to set up fetcher: %v", err)
+	}
+
+	robotsTxt := setUpRobots(hc)
+
+	service := services.NewAnalyzeService(ctx, hc,
+		services.WithLogger(logger),
+		services.WithReadability(*config.Config.Readability),
+		services.WithPolicy(policy),
+		services.WithUserAgent(*config.Config.UserAgent),
+		services.WithRobots(robotsTxt))
+
+	// formats the rows, the pages are fetched by the crawler
+	cliServer := handlers.NewCliServer(ctx, service, fetcher,
+		handlers.CliWithLogger(logger),
+		handlers.CliWithExtractionRules(rules),
+		handlers.CliWithFetchMetadata(*config.Config.FetchMetadata),
+		handlers.CliWithRobots(*config.Config.Robots))
+
+	opts := []crawler.Option{
+		crawler.WithLogger(logger),
+		crawler.WithMaxDepth(*config.Config.CrawlDepth),
+		crawler.WithMaxPages(*config.Config.CrawlMaxPages),
+		crawler.WithScope(include, exclude),
+		crawler.WithExtractionRules(rules),
+		crawler.WithRobots(robotsTxt),
+	}
+
+	statePath := *config.Config.CrawlState
+	if statePath != "" {
+		opts = append(opts, crawler.WithStateFile(statePath))
+	}
+
+	c := crawler.New(fetcher, service, opts...)
+
+	writer, outputFile := openCrawlOutput(logger, outputPath, statePath != "", policy, rules)
+	defer outputFile.Close()
+	defer writer.Flush()
+
+	logger.Infow("Started crawling", "seed", entities.RedactURL(seed))
+
+	summary, err := c.Crawl(ctx, seed, func(page entities.CrawlPage) {
+		if page.Result == nil {
+			logger.Errorw("Error analyzing URL", "url", entities.RedactURL(page.URL), "error", page.Error)
+
+			return
+		}
+
+		row := append(cliServer.Row(page.URL, page.Result), fmt.Sprint(page.Depth))
+		if err := writer.Write(row); err != nil {
+			logger.Errorf("Failed to write row: %v", err)
+		}
+
+		// rows of a resumable crawl are on disk once the page is in the state file
+		if statePath != "" {
+			writer.Flush()
+		}
+	})
+	// no summary when the crawl couldn't start
+	if summary == nil {
+		logger.Fatalf("Failed to crawl: %v", err)
+	}
+
+	if err != nil {
+		logger.Warnf("Crawl stopped early: %v", err)
+
+		if statePath != "" {
+			fmt.Printf("crawl stopped, run the same command again to resume it from %s\n", statePath)
+		}
+	}
+
+	fmt.Printf("crawled %d pages to depth %d, %d failed, %d skipped, %d disallowed by robots.txt, average score %d\n",
+		summary.Pages, summary.Depth, summary.Failed, summary.Skipped, summary.Disallowed, summary.AverageScore)
+
+	if summary.Truncated {
+		fmt.Printf("stopped at the page limit of %d, raise --crawl-max-pages to crawl further\n",
+			*config.Config.CrawlMaxPages)
+	}
+
+	if summaryPath := *config.Config.CrawlSummary; summaryPath != "" {
+		if err := writeCrawlSummary(summaryPath, summary); err != nil {
+			logger.Errorf("Failed to write crawl summary: %v", err)
+		} else {
+			logger.Infof("Crawl Summary Generated : %s", summaryPath)
+		}
+	}
+
+	logger.Infof("Output File Generated : %s", outputPath)
+}
+
+// openCrawlOutput the csv writer of the crawl's rows. A resumed crawl appends to the rows of the
+// runs before it.
+func openCrawlOutput(
+	logger *zap.SugaredLogger,
+	path string,
+	resumable bool,
+	policy *entities.Policy,
+	rules []entities.ExtractionRule,
+) (*csv.Writer, *os.File) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resumable {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	outputFile, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		logger.Fatalf("Failed to create output file: %v", err)
+	}
+
+	info, err := outputFile.Stat()
+	if err != nil {
+		logger.Fatalf("Failed to create output file: %v", err)
+	}
+
+	writer := csv.NewWriter(outputFile)
+
+	if info.Size() == 0 {
+		if err := writer.Write(append(csvHeader(policy, rules), "Depth")); err != nil {
+			logger.Fatalf("Failed to write header: %v", err)
+		}
+	}
+
+	return writer, outputFile
+}
+
+// printCrawlProgress prints the progress of the crawl of a state file as json.
+func printCrawlProgress(logger *zap.SugaredLogger, statePath string) {
+	progress, err := crawler.ReadProgress(statePath)
+	if err != nil {
+		logger.Fatalf("Failed to read crawl state: %v", err)
+	}
+
+	data, err := json.MarshalIndent(progress, "", "  ")
+	if err != nil {
+		logger.Fatalf("Failed to encode crawl progress: %v", err)
+	}
+
+	fmt.Println(string(data))
+}
+
+// writeCrawlSummary writes the site summary of a crawl as json.
+func writeCrawlSummary(path string, summary *entities.CrawlSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
@@ -4,20 +4,33 @@ import (
 	"context"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	flag "github.com/spf13/pflag"
 	"go.uber.org/zap"
 
+	"github.com/erainogo/html-analyzer/internal/app/fetchers"
+	"github.com/erainogo/html-analyzer/internal/app/httpcache"
+	"github.com/erainogo/html-analyzer/internal/app/robots"
 	"github.com/erainogo/html-analyzer/internal/app/services"
+	"github.com/erainogo/html-analyzer/internal/app/site"
+	"github.com/erainogo/html-analyzer/internal/app/transport"
+	"github.com/erainogo/html-analyzer/internal/app/warc"
 	"github.com/erainogo/html-analyzer/internal/config"
+	"github.com/erainogo/html-analyzer/internal/core/adapters"
 	"github.com/erainogo/html-analyzer/internal/handlers"
 	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
 )
 
 //---------------------------------------- CLI ENTRYPOINT FOR THE APPLICATION --------------------------------------- //
@@ -28,9 +41,10 @@ type urlJob struct {
 }
 
 type urlResult struct {
-	Index int
-	Row   []string
-	Err   error
+	Index       int
+	Row         []string
+	Fingerprint entities.Fingerprint
+	Err         error
 }
 
 // set up logger
@@ -42,6 +56,164 @@ func setUpLogger() *zap.SugaredLogger {
 	return zapLogger.With(zap.String("app", appName)).Sugar()
 }
 
+// load the policy file if one is configured
+func loadPolicy(logger *zap.SugaredLogger) *entities.Policy {
+	if *config.Config.PolicyFile == "" {
+		return nil
+	}
+
+	policy, err := services.LoadPolicy(*config.Config.PolicyFile)
+	if err != nil {
+		logger.Fatalf("Failed to load policy: %v", err)
+	}
+
+	return policy
+}
+
+// load the extraction rules if a rules file is configured
+func loadExtractionRules(logger *zap.SugaredLogger) []entities.ExtractionRule {
+	if *config.Config.ExtractFile == "" {
+		return nil
+	}
+
+	rules, err := services.LoadExtractionRules(*config.Config.ExtractFile)
+	if err != nil {
+		logger.Fatalf("Failed to load extraction rules: %v", err)
+	}
+
+	return rules
+}
+
+// cache the responses of the client on disk if a cache dir is configured
+// argsWanted the number of positional args of the mode the args pick.
+func argsWanted(args []string, archived bool) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case crawlCommand, sitemapCommand:
+			// the subcommand, its url and the output csv
+			return 3
+		case crawlStatusCommand:
+			// the subcommand and the state file
+			return 2
+		}
+	}
+
+	if archived {
+		// a har or warc takes the place of the input, only the output csv is left
+		return 1
+	}
+
+	// the input and the output csv
+	return 2
+}
+
+func setUpCache(logger *zap.SugaredLogger, hc *http.Client) {
+	if *config.Config.CacheDir == "" {
+		if *config.Config.Offline {
+			logger.Fatalf("Offline mode needs a cache dir")
+		}
+
+		return
+	}
+
+	cache, err := httpcache.New(*config.Config.CacheDir, hc.Transport,
+		httpcache.WithMaxAge(time.Duration(*config.Config.CacheMaxAge)*time.Second),
+		httpcache.WithMaxEntrySize(int64(*config.Config.MaxBodySize)<<20),
+		httpcache.WithOffline(*config.Config.Offline))
+	if err != nil {
+		logger.Fatalf("Failed to set up cache: %v", err)
+	}
+
+	hc.Transport = cache
+}
+
+// add the credentials of the requests, and the per domain ones of the credentials file if one is
+// configured, to the requests of the client
+func setUpCredentials(logger *zap.SugaredLogger, hc *http.Client) {
+	store, err := fetchers.LoadCredentials(*config.Config.CredentialsFile)
+	if err != nil {
+		logger.Fatalf("Failed to load credentials: %v", err)
+	}
+
+	hc.Transport = fetchers.NewCredentialTransport(hc.Transport, store)
+}
+
+// answer the requests of the client from the fixture file if replay is configured, the
+// network isn't used at all
+func setUpReplay(logger *zap.SugaredLogger, hc *http.Client) {
+	if *config.Config.ReplayFile == "" {
+		return
+	}
+
+	if *config.Config.RecordFile != "" {
+		logger.Fatalf("--record and --replay can't be used together")
+	}
+
+	fixtures, err := fetchers.NewFixtureFetcher(*config.Config.ReplayFile, int64(*config.Config.MaxBodySize)<<20)
+	if err != nil {
+		logger.Fatalf("Failed to load replay fixtures: %v", err)
+	}
+
+	hc.Transport = fixtures.Transport(nil)
+}
+
+// record the responses of the client to a fixture file if record is configured
+func setUpFixtureRecorder(hc *http.Client) *fetchers.Recorder {
+	if *config.Config.RecordFile == "" {
+		return nil
+	}
+
+	recorder := fetchers.NewRecorder(int64(*config.Config.MaxBodySize) << 20)
+	hc.Transport = recorder.Transport(hc.Transport)
+
+	return recorder
+}
+
+// save the responses recorded by --record
+func saveFixtures(logger *zap.SugaredLogger, recorder *fetchers.Recorder) {
+	if recorder == nil {
+		return
+	}
+
+	if err := recorder.Save(*config.Config.RecordFile); err != nil {
+		logger.Errorf("Failed to save recorded fixtures: %v", err)
+	} else {
+		logger.Infof("Fixtures Recorded : %s", *config.Config.RecordFile)
+	}
+}
+
+// set up the outbound transport, proxy and tls, from the config
+func setUpTransport() (*http.Transport, error) {
+	return transport.New(
+		transport.WithProxy(*config.Config.Proxy),
+		transport.WithNoProxy(*config.Config.NoProxy),
+		transport.WithCABundles(*config.Config.CABundles),
+		transport.WithClientCert(*config.Config.ClientCert, *config.Config.ClientKey))
+}
+
+// set up the robots.txt checks of the links and crawled pages, off unless configured
+func setUpRobots(hc *http.Client) *robots.Checker {
+	return robots.New(hc, *config.Config.UserAgent,
+		robots.WithEnabled(*config.Config.Robots),
+		robots.WithMaxAge(time.Duration(*config.Config.RobotsMaxAge)*time.Second))
+}
+
+// set up the fetcher for the pages from the config
+func setUpFetcher(hc *http.Client) (adapters.Fetcher, error) {
+	headers, err := fetchers.ParseHeaders(*config.Config.FetchHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	maxBodySize := int64(*config.Config.MaxBodySize) << 20
+
+	return fetchers.New(*config.Config.Fetcher, *config.Config.FetchSource, hc, maxBodySize,
+		fetchers.WithTimeout(time.Duration(*config.Config.FetchTimeOut)*time.Second),
+		fetchers.WithMaxRedirects(*config.Config.MaxRedirects),
+		fetchers.WithUserAgent(*config.Config.UserAgent),
+		fetchers.WithHeaders(headers))
+}
+
 func main() {
 	logger := setUpLogger()
 
@@ -51,10 +223,23 @@ func main() {
 	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
 
 	// set up http client
+	tr, err := setUpTransport()
+	if err != nil {
+		logger.Fatalf("Failed to set up transport: %v", err)
+	}
+
 	hc := &http.Client{
-		Timeout: 30 * time.Second,
+		Timeout:   time.Duration(*config.Config.FetchTimeOut) * time.Second,
+		Transport: tr,
 	}
 
+	// credentials are added outside the cache, which doesn't store credentialed responses
+	setUpCache(logger, hc)
+	setUpCredentials(logger, hc)
+	setUpReplay(logger, hc)
+
+	fixtureRecorder := setUpFixtureRecorder(hc)
+
 	// background routine to shut down server if signal received
 	// this will wait for the ch chan to receive the exit signals from the os.
 	// if received cancel the context.
@@ -64,36 +249,102 @@ func main() {
 
 		cancel()
 
-		if tr, ok := hc.Transport.(*http.Transport); ok {
-			tr.CloseIdleConnections()
-		}
+		hc.CloseIdleConnections()
 
 		logger.Info("Server gracefully stopped")
 	}()
 
-	if len(os.Args) < constants.ARGS {
-		fmt.Println("Usage: analyzer <input.csv> <output.csv>")
+	// positional args, flags are already consumed by the config
+	args := flag.Args()
+	archived := *config.Config.HARFile != "" || *config.Config.WARCFile != ""
+
+	crawling := len(args) > 0 && args[0] == crawlCommand
+	sitemapping := len(args) > 0 && args[0] == sitemapCommand
+
+	if len(args) < argsWanted(args, archived) {
+		fmt.Println("Usage: analyzer [flags] <input.csv | page.html | directory | -> <output.csv>")
+		fmt.Println("       analyzer [flags] --har <session.har> <output.csv>")
+		fmt.Println("       analyzer [flags] --warc <crawl.warc.gz> <output.csv>")
+		fmt.Println("       analyzer [flags] --site --base-url <url> <directory> <output.csv>")
+		fmt.Println("       analyzer [flags] --sitemap <site-or-sitemap-url> <output.csv>")
+		fmt.Println("       analyzer [flags] crawl <seed-url> <output.csv>")
+		fmt.Println("       analyzer [flags] sitemap <site-or-sitemap-url> <report.csv>")
+		fmt.Println("       analyzer crawl-status <state-file>")
 
 		os.Exit(1)
 	}
 
-	inputPath := os.Args[1]
-	outputPath := os.Args[2]
+	if args[0] == crawlStatusCommand {
+		printCrawlProgress(logger, args[1])
 
-	logger.Info("Started generating report")
+		return
+	}
 
-	inputFile, err := os.Open(inputPath)
-	if err != nil {
-		logger.Fatalf("Failed to open input file: %v", err)
+	policy := loadPolicy(logger)
+	rules := loadExtractionRules(logger)
+
+	if recorder := setUpRecorder(logger, hc); recorder != nil {
+		defer recorder.Close()
 	}
-	defer inputFile.Close()
 
-	reader := csv.NewReader(inputFile)
-	records, err := reader.ReadAll()
-	if err != nil {
-		logger.Fatalf("Failed to read input CSV: %v", err)
+	if sitemapping {
+		runSitemap(ctx, logger, hc, args[1], args[2])
+		saveFixtures(logger, fixtureRecorder)
+
+		return
+	}
+
+	if crawling {
+		runCrawl(ctx, logger, hc, args[1], args[2], policy, rules)
+		saveFixtures(logger, fixtureRecorder)
+
+		return
+	}
+
+	var (
+		fetcher    adapters.Fetcher
+		records    [][]string
+		outputPath string
+	)
+
+	if archived {
+		outputPath = args[0]
+
+		archive := loadArchive(logger)
+
+		// link checks use the archived responses before the network
+		hc.Transport = archive.Transport(hc.Transport)
+		fetcher = archive
+
+		for _, u := range archive.Documents() {
+			records = append(records, []string{u})
+		}
+	} else if *config.Config.Sitemap {
+		outputPath = args[1]
+		fetcher, records = setUpSitemapInput(ctx, logger, hc, args[0])
+	} else {
+		outputPath = args[1]
+		fetcher, records = setUpInput(logger, hc, args[0])
+	}
+
+	var (
+		checker     *site.Site
+		serviceOpts []services.AnalyzeServiceOption
+	)
+
+	if *config.Config.SiteCheck {
+		if archived {
+			logger.Fatalf("--site checks an input directory, not a har or warc")
+		}
+
+		checker = setUpSite(logger, hc, args[0])
+
+		// links off the site would need the network
+		serviceOpts = append(serviceOpts, services.WithExternalLinkChecks(false))
 	}
 
+	logger.Info("Started generating report")
+
 	// limit the records to process
 	if len(records) > 10000 {
 		_, err = fmt.Fprintln(os.Stdout, "too much records to process")
@@ -122,15 +373,312 @@ func main() {
 	defer writer.Flush()
 
 	// Write header
-	err = writer.Write(constants.CsvHeader)
+	err = writer.Write(csvHeader(policy, rules))
 	if err != nil {
 		logger.Fatalf("Failed to write header: %v", err)
 	}
 
-	generateCsv(ctx, logger, records, writer, hc)
+	pages := generateCsv(ctx, logger, records, writer, hc, fetcher, policy, rules, serviceOpts...)
+
+	if reportPath := *config.Config.DuplicatesReport; reportPath != "" {
+		clusters := services.ClusterNearDuplicates(pages, *config.Config.DuplicateDistance)
+
+		if err := writeDuplicatesReport(reportPath, clusters); err != nil {
+			logger.Errorf("Failed to write duplicates report: %v", err)
+		} else {
+			logger.Infof("Duplicates Report Generated : %s", reportPath)
+		}
+	}
+
+	saveFixtures(logger, fixtureRecorder)
 
 	logger.Infof("Finished analyzing. Exiting.")
 	logger.Infof("Output File Generated : %s", outputPath)
+
+	if checker != nil {
+		writer.Flush()
+
+		checkSite(logger, checker, records)
+	}
+}
+
+// csvHeader the columns of the rows of the cli server, for the configured options.
+func csvHeader(policy *entities.Policy, rules []entities.ExtractionRule) []string {
+	header := append([]string{}, constants.CsvHeader...)
+	if *config.Config.Readability {
+		header = append(header, constants.ReadabilityCsvHeader...)
+	}
+
+	if policy != nil {
+		header = append(header, constants.PolicyCsvHeader...)
+	}
+
+	if *config.Config.FetchMetadata {
+		header = append(header, constants.FetchCsvHeader...)
+	}
+
+	if *config.Config.Robots {
+		header = append(header, constants.RobotsCsvHeader...)
+	}
+
+	for _, r := range rules {
+		header = append(header, r.Name)
+	}
+
+	return header
+}
+
+// loadArchive loads the har or warc given in place of the input csv.
+func loadArchive(logger *zap.SugaredLogger) *fetchers.FixtureFetcher {
+	maxBodySize := int64(*config.Config.MaxBodySize) << 20
+
+	var (
+		archive *fetchers.FixtureFetcher
+		err     error
+	)
+
+	if *config.Config.HARFile != "" {
+		archive, err = fetchers.NewHARFetcher(*config.Config.HARFile, maxBodySize)
+	} else {
+		archive, err = fetchers.NewWARCFetcher(*config.Config.WARCFile, maxBodySize)
+	}
+
+	if err != nil {
+		logger.Fatalf("Failed to load archive: %v", err)
+	}
+
+	return archive
+}
+
+// record the requests of the client to a warc file if one is configured, the returned
+// file has to be closed once the run is over.
+func setUpRecorder(logger *zap.SugaredLogger, hc *http.Client) io.Closer {
+	path := *config.Config.WARCOut
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		logger.Fatalf("Failed to create WARC file: %v", err)
+	}
+
+	writer := warc.NewWriter(file, strings.HasSuffix(path, ".gz"))
+
+	err = writer.WriteInfo(map[string]string{
+		"software": "html-analyzer",
+		"format":   "WARC File Format 1.1",
+	})
+	if err != nil {
+		logger.Fatalf("Failed to write WARC file: %v", err)
+	}
+
+	hc.Transport = warc.NewRecorder(writer, hc.Transport, int64(*config.Config.MaxBodySize)<<20)
+
+	return file
+}
+
+// set up the fetcher and the records of the input: "-" for html on stdin, a directory or
+// an html file on disk, or a csv of urls fetched with the configured fetcher.
+func setUpInput(logger *zap.SugaredLogger, hc *http.Client, input string) (adapters.Fetcher, [][]string) {
+	maxBodySize := int64(*config.Config.MaxBodySize) << 20
+	base := loadBaseURL(logger)
+
+	// local pages have nothing to resolve their relative links against without a base url
+	warnNoBase := func() {
+		if base == nil {
+			logger.Warn("No --base-url, the relative links of local pages are reported as unchecked")
+		}
+	}
+
+	if input == "-" {
+		warnNoBase()
+
+		pageURL := input
+		if base != nil {
+			pageURL = base.String()
+		}
+
+		fetcher, err := fetchers.NewReaderFetcher(os.Stdin, pageURL, maxBodySize)
+		if err != nil {
+			logger.Fatalf("Failed to read stdin: %v", err)
+		}
+
+		return fetcher, [][]string{{pageURL}}
+	}
+
+	info, err := os.Stat(input)
+	if err != nil {
+		logger.Fatalf("Failed to open input: %v", err)
+	}
+
+	switch {
+	case info.IsDir():
+		warnNoBase()
+
+		files, err := fetchers.ListFiles(input, *config.Config.Include, *config.Config.Exclude)
+		if err != nil {
+			logger.Fatalf("Failed to list input directory: %v", err)
+		}
+
+		records := make([][]string, 0, len(files))
+		for _, f := range files {
+			records = append(records, []string{f})
+		}
+
+		return fetchers.NewFileFetcher(input, maxBodySize, fetchers.FileWithBaseURL(base)), records
+	case isHTMLFile(input):
+		warnNoBase()
+
+		fetcher := fetchers.NewFileFetcher(filepath.Dir(input), maxBodySize, fetchers.FileWithBaseURL(base))
+
+		return fetcher, [][]string{{filepath.Base(input)}}
+	}
+
+	fetcher, err := setUpFetcher(hc)
+	if err != nil {
+		logger.Fatalf("Failed to set up fetcher: %v", err)
+	}
+
+	return fetcher, readRecords(logger, input)
+}
+
+// loadBaseURL the url local files are served at, nil when not configured.
+func loadBaseURL(logger *zap.SugaredLogger) *url.URL {
+	if *config.Config.BaseURL == "" {
+		return nil
+	}
+
+	base, err := url.Parse(*config.Config.BaseURL)
+	if err != nil || base.Host == "" {
+		logger.Fatalf("Invalid base url %q", *config.Config.BaseURL)
+	}
+
+	return base
+}
+
+// setUpSite serves the input directory as the site for the link checks, instead of the network.
+func setUpSite(logger *zap.SugaredLogger, hc *http.Client, input string) *site.Site {
+	info, err := os.Stat(input)
+	if err != nil || !info.IsDir() {
+		logger.Fatalf("--site needs an input directory, got %q", input)
+	}
+
+	base := loadBaseURL(logger)
+	if base == nil {
+		logger.Fatalf("--site needs the --base-url the site is served at")
+	}
+
+	s := site.New(input, base)
+	hc.Transport = s.Transport()
+
+	return s
+}
+
+// checkSite checks the links between the analyzed pages, the exit code is non-zero on issues.
+func checkSite(logger *zap.SugaredLogger, s *site.Site, records [][]string) {
+	pages := make([]string, 0, len(records))
+	for _, r := range records {
+		pages = append(pages, r[0])
+	}
+
+	report, err := s.Check(pages)
+	if err != nil {
+		logger.Fatalf("Failed to check site: %v", err)
+	}
+
+	for _, issue := range report.Issues {
+		fmt.Printf("%s\t%s\t%s\n", issue.Kind, issue.Page, issue.Target)
+	}
+
+	fmt.Printf("checked %d pages, found %d issues\n", report.Pages, len(report.Issues))
+
+	if reportPath := *config.Config.SiteReport; reportPath != "" {
+		if err := writeSiteReport(reportPath, report); err != nil {
+			logger.Errorf("Failed to write site report: %v", err)
+		} else {
+			logger.Infof("Site Report Generated : %s", reportPath)
+		}
+	}
+
+	if len(report.Issues) > 0 {
+		os.Exit(1)
+	}
+}
+
+func isHTMLFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	return ext == ".html" || ext == ".htm" || ext == ".xhtml"
+}
+
+// readRecords reads the urls of the input csv.
+func readRecords(logger *zap.SugaredLogger, inputPath string) [][]string {
+	inputFile, err := os.Open(inputPath)
+	if err != nil {
+		logger.Fatalf("Failed to open input file: %v", err)
+	}
+	defer inputFile.Close()
+
+	reader := csv.NewReader(inputFile)
+	records, err := reader.ReadAll()
+	if err != nil {
+		logger.Fatalf("Failed to read input CSV: %v", err)
+	}
+
+	return records
+}
+
+// writeDuplicatesReport writes one row per url, rows of the same cluster share the cluster number.
+func writeDuplicatesReport(path string, clusters []entities.DuplicateCluster) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	if err := writer.Write(constants.DuplicatesCsvHeader); err != nil {
+		return err
+	}
+
+	for i, cluster := range clusters {
+		for _, u := range cluster.URLs {
+			if err := writer.Write([]string{cluster.Kind, fmt.Sprint(i + 1), u}); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// writeSiteReport writes one row per issue found by the site check.
+func writeSiteReport(path string, report *entities.SiteReport) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	if err := writer.Write(constants.SiteCsvHeader); err != nil {
+		return err
+	}
+
+	for _, issue := range report.Issues {
+		if err := writer.Write([]string{issue.Kind, issue.Page, issue.Target}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
 }
 
 func generateCsv(
@@ -139,18 +687,32 @@ func generateCsv(
 	records [][]string,
 	writer *csv.Writer,
 	hc *http.Client,
-) {
+	fetcher adapters.Fetcher,
+	policy *entities.Policy,
+	rules []entities.ExtractionRule,
+	opts ...services.AnalyzeServiceOption,
+) []entities.PageFingerprint {
 	select {
 	case <-ctx.Done():
 		logger.Info("Server stopped")
 
-		return
+		return nil
 	default:
-		service := services.NewAnalyzeService(
-			ctx, hc, services.WithLogger(logger))
+		opts = append([]services.AnalyzeServiceOption{
+			services.WithLogger(logger),
+			services.WithReadability(*config.Config.Readability),
+			services.WithPolicy(policy),
+			services.WithUserAgent(*config.Config.UserAgent),
+			services.WithRobots(setUpRobots(hc)),
+		}, opts...)
+
+		service := services.NewAnalyzeService(ctx, hc, opts...)
 
 		cliServer := handlers.NewCliServer(
-			ctx, service, handlers.CliWithLogger(logger))
+			ctx, service, fetcher, handlers.CliWithLogger(logger),
+			handlers.CliWithExtractionRules(rules),
+			handlers.CliWithFetchMetadata(*config.Config.FetchMetadata),
+			handlers.CliWithRobots(*config.Config.Robots))
 
 		// make buffered channels for the count of the records.
 		jobs := make(chan urlJob, len(records))
@@ -179,15 +741,21 @@ func generateCsv(
 							return
 						}
 
-						row, err := cliServer.Handler(ctx, job.URL)
+						row, result, err := cliServer.Handler(ctx, job.URL)
 
-						logger.Infof("processed row %v", row)
+						logger.Infow("processed url", "url", entities.RedactURL(job.URL))
 
-						results <- urlResult{
+						res := urlResult{
 							Index: job.Index,
 							Row:   row,
 							Err:   err,
 						}
+
+						if result != nil {
+							res.Fingerprint = result.Fingerprint
+						}
+
+						results <- res
 					}
 				}
 			}()
@@ -217,7 +785,7 @@ func generateCsv(
 		for res := range results {
 			if res.Err != nil {
 				logger.Errorw("Error analyzing URL", "url",
-					records[res.Index][0], "error", res.Err)
+					entities.RedactURL(records[res.Index][0]), "error", res.Err)
 
 				continue
 			}
@@ -231,11 +799,20 @@ func generateCsv(
 			return cr[i].Index < cr[j].Index
 		})
 
+		pages := make([]entities.PageFingerprint, 0, len(cr))
+
 		for _, res := range cr {
 			err := writer.Write(res.Row)
 			if err != nil {
-				return
+				return pages
 			}
+
+			pages = append(pages, entities.PageFingerprint{
+				URL:         records[res.Index][0],
+				Fingerprint: res.Fingerprint,
+			})
 		}
+
+		return pages
 	}
 }
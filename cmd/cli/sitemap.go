@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"go.uber.org/zap"
+
+	"github.com/erainogo/html-analyzer/internal/app/sitemap"
+	"github.com/erainogo/html-analyzer/internal/config"
+	"github.com/erainogo/html-analyzer/internal/core/adapters"
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// sitemapCommand the subcommand that validates the sitemaps of a site and checks the urls they list.
+const sitemapCommand = "sitemap"
+
+func setUpSitemapReader(logger *zap.SugaredLogger, hc *http.Client) *sitemap.Reader {
+	return sitemap.New(hc, *config.Config.UserAgent,
+		sitemap.WithLogger(logger),
+		sitemap.WithRobots(setUpRobots(hc)))
+}
+
+// setUpSitemapInput the fetcher and the records of the pages the sitemaps of a site or sitemap url list.
+func setUpSitemapInput(
+	ctx context.Context, logger *zap.SugaredLogger, hc *http.Client, input string,
+) (adapters.Fetcher, [][]string) {
+	report, err := setUpSitemapReader(logger, hc).Read(ctx, input)
+	if err != nil {
+		logger.Fatalf("Failed to read sitemaps: %v", err)
+	}
+
+	for _, issue := range report.Issues {
+		logger.Warnw("sitemap issue", "kind", issue.Kind, "sitemap", issue.Sitemap, "url", issue.URL,
+			"detail", issue.Detail)
+	}
+
+	records := make([][]string, 0, len(report.URLs))
+	for _, u := range report.URLs {
+		records = append(records, []string{u.Loc})
+	}
+
+	fetcher, err := setUpFetcher(hc)
+	if err != nil {
+		logger.Fatalf("Failed to set up fetcher: %v", err)
+	}
+
+	return fetcher, records
+}
+
+// runSitemap reads the sitemaps of a site or sitemap url, checks the urls they list and writes one
+// row per issue found.
+func runSitemap(ctx context.Context, logger *zap.SugaredLogger, hc *http.Client, input, outputPath string) {
+	reader := setUpSitemapReader(logger, hc)
+
+	logger.Infow("Started reading sitemaps", "url", entities.RedactURL(input))
+
+	report, err := reader.Read(ctx, input)
+	// the report says why no sitemap could be read
+	if err != nil && !errors.Is(err, entities.ErrNoSitemap) {
+		logger.Fatalf("Failed to read sitemaps: %v", err)
+	}
+
+	if err == nil {
+		reader.Check(ctx, report)
+	}
+
+	if err := writeSitemapReport(outputPath, report); err != nil {
+		logger.Fatalf("Failed to write sitemap report: %v", err)
+	}
+
+	fmt.Printf("read %d sitemaps listing %d urls, %d issues\n",
+		len(report.Sitemaps), len(report.URLs), len(report.Issues))
+
+	logger.Infof("Sitemap Report Generated : %s", outputPath)
+}
+
+// writeSitemapReport writes one row per issue of the sitemaps or the urls they list.
+func writeSitemapReport(path string, report *entities.SitemapReport) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	if err := writer.Write(constants.SitemapCsvHeader); err != nil {
+		return err
+	}
+
+	for _, issue := range report.Issues {
+		if err := writer.Write([]string{issue.Kind, issue.Sitemap, issue.URL, issue.Detail}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
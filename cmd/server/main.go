@@ -13,9 +13,16 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/erainogo/html-analyzer/internal/app/crawler"
+	"github.com/erainogo/html-analyzer/internal/app/fetchers"
+	"github.com/erainogo/html-analyzer/internal/app/netguard"
+	"github.com/erainogo/html-analyzer/internal/app/robots"
 	"github.com/erainogo/html-analyzer/internal/app/services"
+	"github.com/erainogo/html-analyzer/internal/app/transport"
 	"github.com/erainogo/html-analyzer/internal/config"
+	"github.com/erainogo/html-analyzer/internal/core/adapters"
 	"github.com/erainogo/html-analyzer/internal/handlers"
+	"github.com/erainogo/html-analyzer/pkg/entities"
 )
 
 //---------------------------------------- HTTP ENTRYPOINT FOR THE APPLICATION --------------------------------------- //
@@ -29,6 +36,137 @@ func setUpLogger() *zap.SugaredLogger {
 	return zapLogger.With(zap.String("app", appName)).Sugar()
 }
 
+// load the policy file if one is configured
+func loadPolicy(logger *zap.SugaredLogger) *entities.Policy {
+	if *config.Config.PolicyFile == "" {
+		return nil
+	}
+
+	policy, err := services.LoadPolicy(*config.Config.PolicyFile)
+	if err != nil {
+		logger.Fatalf("Failed to load policy: %v", err)
+	}
+
+	return policy
+}
+
+// add the credentials of the requests, and the per domain ones of the credentials file if one is
+// configured, to the requests of the client
+func setUpCredentials(logger *zap.SugaredLogger, hc *http.Client) {
+	store, err := fetchers.LoadCredentials(*config.Config.CredentialsFile)
+	if err != nil {
+		logger.Fatalf("Failed to load credentials: %v", err)
+	}
+
+	hc.Transport = fetchers.NewCredentialTransport(hc.Transport, store)
+}
+
+// set up the outbound transport, proxy and tls, from the config
+func setUpTransport() (*http.Transport, error) {
+	return transport.New(
+		transport.WithProxy(*config.Config.Proxy),
+		transport.WithNoProxy(*config.Config.NoProxy),
+		transport.WithCABundles(*config.Config.CABundles),
+		transport.WithClientCert(*config.Config.ClientCert, *config.Config.ClientKey))
+}
+
+// set up the fetcher for the pages from the config
+func setUpFetcher(hc *http.Client) (adapters.Fetcher, error) {
+	// the file and fixture fetchers read local files, the api only fetches over the network
+	if kind := *config.Config.Fetcher; kind != fetchers.KindHTTP && kind != "" {
+		return nil, fmt.Errorf("the server only fetches over http, --fetcher %s is for the cli", kind)
+	}
+
+	headers, err := fetchers.ParseHeaders(*config.Config.FetchHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	maxBodySize := int64(*config.Config.MaxBodySize) << 20
+
+	return fetchers.New(*config.Config.Fetcher, *config.Config.FetchSource, hc, maxBodySize,
+		fetchers.WithTimeout(time.Duration(*config.Config.FetchTimeOut)*time.Second),
+		fetchers.WithMaxRedirects(*config.Config.MaxRedirects),
+		fetchers.WithUserAgent(*config.Config.UserAgent),
+		fetchers.WithHeaders(headers))
+}
+
+// guard the client against requests to internal addresses
+func setUpGuard(hc *http.Client) (*http.Client, error) {
+	allow, err := netguard.ParseCIDRs(*config.Config.AllowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	deny, err := netguard.ParseCIDRs(*config.Config.DenyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	ports, err := netguard.ParsePorts(*config.Config.AllowedPorts)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := transport.ProxyURL(*config.Config.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	guard := netguard.New(
+		netguard.WithAllowCIDRs(allow),
+		netguard.WithDenyCIDRs(deny),
+		netguard.WithSchemes(*config.Config.AllowedSchemes),
+		netguard.WithPorts(ports),
+		netguard.WithProxy(proxy))
+
+	return guard.Client(hc), nil
+}
+
+// set up the robots.txt checks, off unless configured or asked for by a request
+func setUpRobots(hc *http.Client) *robots.Checker {
+	return robots.New(hc, *config.Config.UserAgent,
+		robots.WithEnabled(*config.Config.Robots),
+		robots.WithMaxAge(time.Duration(*config.Config.RobotsMaxAge)*time.Second))
+}
+
+// set up the crawl jobs of the api, the configured scope applies to every crawl
+func setUpCrawlJobs(
+	ctx context.Context,
+	logger *zap.SugaredLogger,
+	fetcher adapters.Fetcher,
+	service adapters.AnalyzeService,
+	robotsTxt *robots.Checker,
+) *crawler.Jobs {
+	include, err := crawler.ParsePatterns(*config.Config.CrawlInclude)
+	if err != nil {
+		logger.Fatalf("Failed to parse crawl include patterns: %v", err)
+	}
+
+	exclude, err := crawler.ParsePatterns(*config.Config.CrawlExclude)
+	if err != nil {
+		logger.Fatalf("Failed to parse crawl exclude patterns: %v", err)
+	}
+
+	jobs := crawler.NewJobs(ctx, fetcher, service,
+		crawler.WithLogger(logger),
+		crawler.WithMaxDepth(*config.Config.CrawlDepth),
+		crawler.WithMaxPages(*config.Config.CrawlMaxPages),
+		crawler.WithScope(include, exclude),
+		crawler.WithRobots(robotsTxt))
+
+	jobs.LimitRunning(*config.Config.CrawlMaxJobs)
+
+	// jobs running at the last shutdown go on where they stopped
+	if dir := *config.Config.CrawlStateDir; dir != "" {
+		if err := jobs.Restore(dir); err != nil {
+			logger.Fatalf("Failed to restore crawl jobs: %v", err)
+		}
+	}
+
+	return jobs
+}
+
 func main() {
 	logger := setUpLogger()
 
@@ -43,10 +181,26 @@ func main() {
 	}
 
 	// set up http client
+	tr, err := setUpTransport()
+	if err != nil {
+		logger.Fatalf("Failed to set up transport: %v", err)
+	}
+
 	hc := &http.Client{
-		Timeout: 30 * time.Second,
+		Timeout:   time.Duration(*config.Config.FetchTimeOut) * time.Second,
+		Transport: tr,
 	}
 
+	// both the page fetch and the link checks use this client
+	if *config.Config.SSRFGuard {
+		hc, err = setUpGuard(hc)
+		if err != nil {
+			logger.Fatalf("Failed to set up ssrf guard: %v", err)
+		}
+	}
+
+	setUpCredentials(logger, hc)
+
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
 
@@ -62,9 +216,7 @@ func main() {
 
 		defer shutdownRelease()
 
-		if tr, ok := hc.Transport.(*http.Transport); ok {
-			tr.CloseIdleConnections()
-		}
+		hc.CloseIdleConnections()
 
 		if err := srv.Shutdown(shutdownCtx); err != nil {
 			logger.Errorf("Shutdown error: %s", err)
@@ -79,13 +231,28 @@ func main() {
 		logger.Info("Server gracefully stopped")
 	}()
 
+	policy := loadPolicy(logger)
+
+	fetcher, err := setUpFetcher(hc)
+	if err != nil {
+		logger.Fatalf("Failed to set up fetcher: %v", err)
+	}
+
+	robotsTxt := setUpRobots(hc)
+
 	// service will hold the logic to get the required details from parsed url
 	service := services.NewAnalyzeService(
-		ctx, hc, services.WithLogger(logger))
+		ctx, hc, services.WithLogger(logger),
+		services.WithReadability(*config.Config.Readability),
+		services.WithPolicy(policy),
+		services.WithUserAgent(*config.Config.UserAgent),
+		services.WithRobots(robotsTxt))
+
+	crawls := setUpCrawlJobs(ctx, logger, fetcher, service, robotsTxt)
 
 	// http handler for routes like analyze
 	srv.Handler = handlers.NewHTTPServer(
-		ctx, service, handlers.WithLogger(logger))
+		ctx, service, fetcher, handlers.WithLogger(logger), handlers.WithCrawlJobs(crawls))
 
 	log.Println("Server started at :", *config.Config.HttpPort)
 
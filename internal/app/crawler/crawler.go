@@ -0,0 +1,523 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/erainogo/html-analyzer/internal/app/robots"
+	"github.com/erainogo/html-analyzer/internal/core/adapters"
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// Crawler analyzes a site from a seed url, following the internal links of every analyzed page.
+type Crawler struct {
+	fetcher adapters.Fetcher
+	service adapters.AnalyzeService
+	logger  *zap.SugaredLogger
+
+	maxDepth    int
+	maxPages    int
+	concurrency int
+	include     []*regexp.Regexp
+	exclude     []*regexp.Regexp
+	rules       []entities.ExtractionRule
+	stateFile   string
+	robots      *robots.Checker
+}
+
+type Option func(*Crawler)
+
+func WithLogger(logger *zap.SugaredLogger) Option {
+	return func(c *Crawler) {
+		c.logger = logger
+	}
+}
+
+// WithMaxDepth links are followed this many levels from the seed, 0 analyzes the seed only.
+func WithMaxDepth(depth int) Option {
+	return func(c *Crawler) {
+		c.maxDepth = depth
+	}
+}
+
+// WithMaxPages the most pages a crawl analyzes, the seed included.
+func WithMaxPages(pages int) Option {
+	return func(c *Crawler) {
+		c.maxPages = pages
+	}
+}
+
+// WithConcurrency the number of pages analyzed at the same time.
+func WithConcurrency(n int) Option {
+	return func(c *Crawler) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithScope only urls matching an include pattern, when there are any, and no exclude pattern
+// are crawled.
+func WithScope(include, exclude []*regexp.Regexp) Option {
+	return func(c *Crawler) {
+		c.include = include
+		c.exclude = exclude
+	}
+}
+
+// WithExtractionRules extracts the rules from every crawled page.
+func WithExtractionRules(rules []entities.ExtractionRule) Option {
+	return func(c *Crawler) {
+		c.rules = rules
+	}
+}
+
+// WithRobots doesn't crawl the pages robots.txt doesn't allow and waits its Crawl-delay between
+// the pages of a host, when the checker is enabled for the crawl.
+func WithRobots(checker *robots.Checker) Option {
+	return func(c *Crawler) {
+		c.robots = checker
+	}
+}
+
+// WithStateFile keeps the queue, the visited urls and the summary of the crawl in the file, so the
+// crawl can be stopped and resumed, by a later run even. The file belongs to the crawl of one seed.
+func WithStateFile(path string) Option {
+	return func(c *Crawler) {
+		c.stateFile = path
+	}
+}
+
+// ParsePatterns compiles the scope patterns.
+func ParsePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: pattern %q: %v", entities.ErrInvalidCrawlRequest, p, err)
+		}
+
+		compiled = append(compiled, re)
+	}
+
+	return compiled, nil
+}
+
+func patternStrings(patterns []*regexp.Regexp) []string {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	s := make([]string, 0, len(patterns))
+	for _, re := range patterns {
+		s = append(s, re.String())
+	}
+
+	return s
+}
+
+func New(fetcher adapters.Fetcher, service adapters.AnalyzeService, opts ...Option) *Crawler {
+	c := &Crawler{
+		fetcher:     fetcher,
+		service:     service,
+		logger:      zap.NewNop().Sugar(),
+		maxDepth:    constants.CrawlMaxDepth,
+		maxPages:    constants.CrawlMaxPages,
+		concurrency: constants.CrawlWorkerCount,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// visit what analyzing one page gave: the page and the links to follow from it.
+type visit struct {
+	page    entities.CrawlPage
+	links   []string
+	skipped bool
+	// final the normalized url the page was fetched from, after redirects
+	final string
+}
+
+// Crawl analyzes the pages reachable from seed breadth first. onPage is called with every page as
+// it finishes, from the calling goroutine. A cancelled ctx stops the crawl; the summary of the
+// pages analyzed so far is returned with the context's error. With a state file the crawl goes on
+// from where the last run on the file stopped.
+func (c *Crawler) Crawl(
+	ctx context.Context, seed string, onPage func(entities.CrawlPage),
+) (*entities.CrawlSummary, error) {
+	start, ok := normalizeURL(seed)
+	if !ok {
+		return nil, fmt.Errorf("%w: seed %q is not an http url", entities.ErrInvalidCrawlRequest, seed)
+	}
+
+	state, err := c.openState(start)
+	if err != nil {
+		return nil, err
+	}
+	defer state.close()
+
+	if state.finished != nil {
+		return state.result(), nil
+	}
+
+	results := make(chan visit)
+	inflight := 0
+
+	var failure error
+
+	for {
+		for failure == nil && ctx.Err() == nil && inflight < c.concurrency {
+			u, depth, ok := state.queue.pop()
+			if !ok {
+				break
+			}
+
+			inflight++
+
+			go func() {
+				results <- c.visit(ctx, u, depth)
+			}()
+		}
+
+		if inflight == 0 {
+			break
+		}
+
+		v := <-results
+		inflight--
+
+		// a page the cancellation cut off stays queued for the next run
+		if failure != nil || (v.page.Result == nil && ctx.Err() != nil) {
+			continue
+		}
+
+		failure = c.visited(state, v, onPage)
+	}
+
+	if failure != nil {
+		return state.result(), failure
+	}
+
+	if err := ctx.Err(); err != nil {
+		return state.result(), err
+	}
+
+	now := time.Now().UTC()
+	if err := state.write(record{Op: opFinished, Status: entities.CrawlDone, Time: &now}); err != nil {
+		return state.result(), err
+	}
+
+	return state.result(), nil
+}
+
+// openState the state of a new crawl from start, or of the state file.
+func (c *Crawler) openState(start string) (*crawlState, error) {
+	req := entities.CrawlRequest{
+		URL:      start,
+		MaxDepth: c.maxDepth,
+		MaxPages: c.maxPages,
+		Include:  patternStrings(c.include),
+		Exclude:  patternStrings(c.exclude),
+	}
+
+	if c.stateFile != "" {
+		return openState(c.stateFile, req)
+	}
+
+	s := newCrawlState(false)
+
+	return s, s.start(req)
+}
+
+// visited records a visited page and queues the links to follow from it. A page redirecting to
+// one visited already is recorded as a duplicate and left out.
+func (c *Crawler) visited(state *crawlState, v visit, onPage func(entities.CrawlPage)) error {
+	if v.final != "" && state.finals[v.final] {
+		return state.write(record{Op: opDuplicate, URL: v.page.URL})
+	}
+
+	// links to the host the seed redirects to are followed too
+	if v.page.Depth == 0 && v.page.Result != nil && v.page.Result.Fetch != nil {
+		if final, ok := normalizeURL(v.page.Result.Fetch.FinalURL); ok && !state.hosts[hostOf(final)] {
+			if err := state.write(record{Op: opHost, URL: hostOf(final)}); err != nil {
+				return err
+			}
+		}
+	}
+
+	page := newVisitedPage(v.page)
+	page.Final = v.final
+
+	if err := state.write(record{Op: opVisited, Page: page, Skipped: v.skipped}); err != nil {
+		return err
+	}
+
+	onPage(v.page)
+
+	if v.page.Depth >= c.maxDepth {
+		return nil
+	}
+
+	for _, link := range v.links {
+		if !state.hosts[hostOf(link)] || !c.inScope(link) || state.queue.seen(link) || state.finals[link] {
+			continue
+		}
+
+		if state.scheduled >= c.maxPages {
+			if !state.summary.Truncated {
+				if err := state.write(record{Op: opTruncated}); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if err := state.write(record{Op: opQueued, URL: link, Depth: v.page.Depth + 1}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// visit fetches and analyzes a page.
+func (c *Crawler) visit(ctx context.Context, u string, depth int) visit {
+	v := visit{page: entities.CrawlPage{URL: u, Depth: depth}}
+
+	if c.robots.Enabled(ctx) {
+		if !c.robots.Allowed(ctx, u) {
+			v.page.Error = entities.ErrDisallowedByRobots.Error()
+			v.page.Disallowed = true
+
+			return v
+		}
+
+		if err := c.robots.Wait(ctx, u); err != nil {
+			v.page.Error = err.Error()
+
+			return v
+		}
+	}
+
+	resp, err := c.fetcher.Fetch(ctx, u)
+	if err != nil {
+		c.logger.Warnw("crawl failed to fetch page", "url", entities.RedactURL(u), "error", err)
+
+		v.page.Error = err.Error()
+		// links to images, pdfs and the like aren't pages
+		v.skipped = errors.Is(err, entities.ErrUnsupportedContentType)
+
+		return v
+	}
+
+	if final, ok := normalizeURL(resp.URL); ok {
+		v.final = final
+	}
+
+	result, err := c.service.Parse(ctx, resp.Body, resp.URL, resp.Metadata, c.rules...)
+	if err != nil {
+		c.logger.Warnw("crawl failed to analyze page", "url", entities.RedactURL(u), "error", err)
+
+		v.page.Error = err.Error()
+
+		return v
+	}
+
+	v.page.Result = result
+
+	base, err := url.Parse(resp.URL)
+	if err != nil {
+		return v
+	}
+
+	for _, link := range result.Links.Items {
+		if !link.Internal {
+			continue
+		}
+
+		ref, err := url.Parse(strings.TrimSpace(link.Href))
+		if err != nil {
+			continue
+		}
+
+		if target, ok := normalizeURL(base.ResolveReference(ref).String()); ok {
+			v.links = append(v.links, target)
+		}
+	}
+
+	return v
+}
+
+// inScope the seed is always crawled, other urls have to pass the scope patterns.
+func (c *Crawler) inScope(u string) bool {
+	for _, re := range c.exclude {
+		if re.MatchString(u) {
+			return false
+		}
+	}
+
+	if len(c.include) == 0 {
+		return true
+	}
+
+	for _, re := range c.include {
+		if re.MatchString(u) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// summarize adds a visited page to the summary.
+func summarize(summary *entities.CrawlSummary, scoreSum *int, p *visitedPage, skipped bool) {
+	if p.Depth > summary.Depth {
+		summary.Depth = p.Depth
+	}
+
+	switch {
+	case p.Disallowed:
+		summary.Disallowed++
+
+		return
+	case skipped:
+		summary.Skipped++
+
+		return
+	case !p.Analyzed:
+		summary.Failed++
+
+		return
+	}
+
+	summary.Pages++
+	*scoreSum += p.Score
+	summary.InaccessibleLinks += p.InaccessibleLinks
+
+	if p.Findings != nil {
+		summary.Findings.Errors += p.Findings.Errors
+		summary.Findings.Warnings += p.Findings.Warnings
+		summary.Findings.Info += p.Findings.Info
+		summary.Findings.Total += p.Findings.Total
+	}
+
+	for rule, n := range p.Rules {
+		summary.Rules[rule] += n
+	}
+}
+
+// normalizeURL the form urls are queued and compared in: http(s) only, lower case scheme and host,
+// no default port, no fragment and at least a / path.
+func normalizeURL(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return "", false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+
+	u.Host = host
+	if port != "" {
+		u.Host = host + ":" + port
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u.String(), true
+}
+
+// hostOf the site host of the url, the same with or without www. as the link analysis counts it.
+func hostOf(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+
+	return siteHost(parsed.Host)
+}
+
+// siteHost the host, lower case and without www.
+func siteHost(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+// frontier the urls waiting to be crawled, in order, and every url queued so far.
+type frontier struct {
+	queue   []queued
+	visited map[string]bool
+}
+
+type queued struct {
+	url   string
+	depth int
+}
+
+func newFrontier() *frontier {
+	return &frontier{visited: map[string]bool{}}
+}
+
+func (f *frontier) push(u string, depth int) {
+	f.visited[u] = true
+	f.queue = append(f.queue, queued{url: u, depth: depth})
+}
+
+func (f *frontier) pop() (string, int, bool) {
+	if len(f.queue) == 0 {
+		return "", 0, false
+	}
+
+	q := f.queue[0]
+	f.queue = f.queue[1:]
+
+	return q.url, q.depth, true
+}
+
+func (f *frontier) seen(u string) bool {
+	return f.visited[u]
+}
+
+func (f *frontier) len() int {
+	return len(f.queue)
+}
+
+// drop takes the urls already visited off the queue, they stay seen.
+func (f *frontier) drop(visited map[string]bool) {
+	queue := f.queue[:0]
+
+	for _, q := range f.queue {
+		if !visited[q.url] {
+			queue = append(queue, q)
+		}
+	}
+
+	f.queue = queue
+}
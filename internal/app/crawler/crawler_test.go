@@ -0,0 +1,530 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erainogo/html-analyzer/internal/app/fetchers"
+	"github.com/erainogo/html-analyzer/internal/app/robots"
+	"github.com/erainogo/html-analyzer/internal/app/services"
+	"github.com/erainogo/html-analyzer/internal/core/adapters"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// newTestSite serves a small site: / links to /a, /b and an image, /a to /c and /private/x,
+// /c to /d. The links carry fragments and duplicates which shouldn't be crawled twice. Its
+// robots.txt disallows /private/.
+func newTestSite(t *testing.T) (*httptest.Server, *Crawler) {
+	t.Helper()
+
+	pages := map[string][]string{
+		"/":          {"/a", "/b", "/b#top", "/img.png"},
+		"/a":         {"/c", "/private/x", "/"},
+		"/b":         {"/"},
+		"/c":         {"/d"},
+		"/d":         {},
+		"/private/x": {},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+
+			return
+		}
+
+		if r.URL.Path == "/img.png" {
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("\x89PNG"))
+
+			return
+		}
+
+		links, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		var body strings.Builder
+
+		fmt.Fprintf(&body, "<!DOCTYPE html><html><head><title>%s</title></head><body>", r.URL.Path)
+
+		for _, l := range links {
+			fmt.Fprintf(&body, `<a href="%s">link</a>`, l)
+		}
+
+		body.WriteString("</body></html>")
+
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(body.String()))
+	}))
+	t.Cleanup(server.Close)
+
+	hc := server.Client()
+	service := services.NewAnalyzeService(context.Background(), hc)
+
+	return server, New(fetchers.NewHTTPFetcher(hc), service)
+}
+
+// Test the crawl follows internal links up to the depth and page limits and within the scope
+func TestCrawl(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		pages     []string
+		skipped   int
+		depth     int
+		truncated bool
+	}{
+		{
+			name:  "Seed only",
+			opts:  []Option{WithMaxDepth(0)},
+			pages: []string{"/"},
+		},
+		{
+			name:    "One level",
+			opts:    []Option{WithMaxDepth(1)},
+			pages:   []string{"/", "/a", "/b"},
+			skipped: 1,
+			depth:   1,
+		},
+		{
+			name:    "Whole site",
+			opts:    []Option{WithMaxDepth(5)},
+			pages:   []string{"/", "/a", "/b", "/c", "/d", "/private/x"},
+			skipped: 1,
+			depth:   3,
+		},
+		{
+			name:      "Page limit",
+			opts:      []Option{WithMaxDepth(5), WithMaxPages(3)},
+			pages:     []string{"/", "/a", "/b"},
+			depth:     1,
+			truncated: true,
+		},
+		{
+			name:    "Excluded",
+			opts:    []Option{WithMaxDepth(5), WithScope(nil, []*regexp.Regexp{regexp.MustCompile(`/private/`)})},
+			pages:   []string{"/", "/a", "/b", "/c", "/d"},
+			skipped: 1,
+			depth:   3,
+		},
+		{
+			name:  "Included",
+			opts:  []Option{WithMaxDepth(5), WithScope([]*regexp.Regexp{regexp.MustCompile(`/[ac]$`)}, nil)},
+			pages: []string{"/", "/a", "/c"},
+			depth: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, c := newTestSite(t)
+
+			for _, opt := range tt.opts {
+				opt(c)
+			}
+
+			var analyzed []string
+
+			summary, err := c.Crawl(context.Background(), server.URL, func(page entities.CrawlPage) {
+				if page.Result != nil {
+					analyzed = append(analyzed, strings.TrimPrefix(page.URL, server.URL))
+				}
+			})
+
+			assert.NoError(t, err)
+
+			sort.Strings(analyzed)
+			sort.Strings(tt.pages)
+
+			assert.Equal(t, tt.pages, analyzed)
+			assert.Equal(t, len(tt.pages), summary.Pages)
+			assert.Equal(t, 0, summary.Failed)
+			assert.Equal(t, tt.skipped, summary.Skipped)
+			assert.Equal(t, tt.depth, summary.Depth)
+			assert.Equal(t, tt.truncated, summary.Truncated)
+			assert.Equal(t, server.URL+"/", summary.Seed)
+		})
+	}
+}
+
+// Test the pages robots.txt disallows aren't crawled when the checks are on for the crawl
+func TestCrawlRobots(t *testing.T) {
+	server, c := newTestSite(t)
+
+	WithMaxDepth(5)(c)
+	WithRobots(robots.New(server.Client(), "html-analyzer/1.0"))(c)
+
+	crawl := func(ctx context.Context) (*entities.CrawlSummary, []entities.CrawlPage) {
+		var disallowed []entities.CrawlPage
+
+		summary, err := c.Crawl(ctx, server.URL, func(page entities.CrawlPage) {
+			if page.Disallowed {
+				disallowed = append(disallowed, page)
+			}
+		})
+		assert.NoError(t, err)
+
+		return summary, disallowed
+	}
+
+	// off unless the checker or the context turns them on
+	summary, disallowed := crawl(context.Background())
+	assert.Equal(t, 6, summary.Pages)
+	assert.Empty(t, disallowed)
+
+	summary, disallowed = crawl(entities.ContextWithRobots(context.Background(), true))
+	assert.Equal(t, 5, summary.Pages)
+	assert.Equal(t, 0, summary.Failed)
+	assert.Equal(t, 1, summary.Disallowed)
+
+	if assert.Len(t, disallowed, 1) {
+		assert.Equal(t, server.URL+"/private/x", disallowed[0].URL)
+		assert.Equal(t, entities.ErrDisallowedByRobots.Error(), disallowed[0].Error)
+	}
+}
+
+// Test links to the www. host of the seed are followed, as the link analysis counts them internal
+func TestCrawlWWWHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+
+		if r.URL.Path == "/" {
+			_, _ = w.Write([]byte(`<html><body><a href="http://www.example.test/a">a</a></body></html>`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`<html><body></body></html>`))
+	}))
+	defer server.Close()
+
+	// every host is served by the test server
+	hc := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+		},
+	}}
+
+	c := New(fetchers.NewHTTPFetcher(hc), services.NewAnalyzeService(context.Background(), hc))
+
+	var analyzed []string
+
+	summary, err := c.Crawl(context.Background(), "http://example.test/", func(page entities.CrawlPage) {
+		if page.Result != nil {
+			analyzed = append(analyzed, page.URL)
+		}
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, summary.Pages)
+	assert.Equal(t, []string{"http://example.test/", "http://www.example.test/a"}, analyzed)
+}
+
+// Test a page redirecting to one linked directly is analyzed once
+func TestCrawlRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><body><a href="/old">old</a><a href="/new">new</a></body></html>`))
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><body><a href="/old">old</a></body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	c := New(fetchers.NewHTTPFetcher(server.Client()), services.NewAnalyzeService(context.Background(), server.Client()))
+
+	// with more workers /old and /new are fetched at the same time, either one is analyzed
+	for _, concurrency := range []int{1, 4} {
+		WithConcurrency(concurrency)(c)
+
+		var analyzed int
+
+		summary, err := c.Crawl(context.Background(), server.URL, func(page entities.CrawlPage) {
+			if page.Result != nil {
+				analyzed++
+			}
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, summary.Pages)
+		assert.Equal(t, 2, analyzed)
+	}
+}
+
+// Test a seed that isn't an http url is rejected
+func TestCrawlInvalidSeed(t *testing.T) {
+	_, c := newTestSite(t)
+
+	_, err := c.Crawl(context.Background(), "ftp://example.com/", func(entities.CrawlPage) {})
+
+	assert.ErrorIs(t, err, entities.ErrInvalidCrawlRequest)
+}
+
+// Test urls are compared in one form
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		raw        string
+		normalized string
+		ok         bool
+	}{
+		{raw: "https://Example.COM", normalized: "https://example.com/", ok: true},
+		{raw: "HTTP://example.com:80/a#top", normalized: "http://example.com/a", ok: true},
+		{raw: "https://example.com:443/a?q=1", normalized: "https://example.com/a?q=1", ok: true},
+		{raw: "https://example.com:8443/", normalized: "https://example.com:8443/", ok: true},
+		{raw: "mailto:someone@example.com"},
+		{raw: "/relative"},
+	}
+
+	for _, tt := range tests {
+		normalized, ok := normalizeURL(tt.raw)
+
+		assert.Equal(t, tt.ok, ok, tt.raw)
+		assert.Equal(t, tt.normalized, normalized, tt.raw)
+	}
+}
+
+// Test a crawl job runs in the background to its summary, within the configured limits
+func TestJobs(t *testing.T) {
+	server, c := newTestSite(t)
+
+	jobs := NewJobs(context.Background(), c.fetcher, c.service, WithMaxDepth(1))
+
+	_, err := jobs.Start(entities.CrawlRequest{URL: "not a url"})
+	assert.ErrorIs(t, err, entities.ErrInvalidCrawlRequest)
+
+	_, err = jobs.Start(entities.CrawlRequest{URL: server.URL, Exclude: []string{"("}})
+	assert.ErrorIs(t, err, entities.ErrInvalidCrawlRequest)
+
+	job, err := jobs.Start(entities.CrawlRequest{URL: server.URL, MaxDepth: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, job.Request.MaxDepth)
+
+	assert.Eventually(t, func() bool {
+		job, _ = jobs.Get(job.ID)
+
+		return job.Status == entities.CrawlDone
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Len(t, job.Pages, 4)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, 3, job.Summary.Pages)
+	assert.Equal(t, 1, job.Summary.Skipped)
+
+	// the job keeps the summaries of the pages, not their results
+	assert.Nil(t, job.Pages[0].Result)
+	assert.NotNil(t, job.Pages[0].Summary)
+
+	_, ok := jobs.Get("unknown")
+	assert.False(t, ok)
+}
+
+// Test no more jobs than the limit run at once
+func TestJobsLimitRunning(t *testing.T) {
+	server, c := newTestSite(t)
+
+	fetcher := &gatedFetcher{next: c.fetcher, gate: make(chan struct{})}
+
+	jobs := NewJobs(context.Background(), fetcher, c.service)
+	jobs.LimitRunning(1)
+
+	first, err := jobs.Start(entities.CrawlRequest{URL: server.URL})
+	assert.NoError(t, err)
+
+	_, err = jobs.Start(entities.CrawlRequest{URL: server.URL})
+	assert.ErrorIs(t, err, entities.ErrTooManyCrawls)
+
+	assert.True(t, jobs.Cancel(first.ID))
+
+	assert.Eventually(t, func() bool {
+		job, _ := jobs.Get(first.ID)
+
+		return job.Status == entities.CrawlCancelled
+	}, 5*time.Second, 10*time.Millisecond)
+
+	second, err := jobs.Start(entities.CrawlRequest{URL: server.URL})
+	assert.NoError(t, err)
+	assert.True(t, jobs.Cancel(second.ID))
+}
+
+// gatedFetcher holds every fetch until the gate is opened or the fetch is cancelled.
+type gatedFetcher struct {
+	next adapters.Fetcher
+	gate chan struct{}
+}
+
+func (f *gatedFetcher) Fetch(ctx context.Context, url string) (*entities.FetchResponse, error) {
+	select {
+	case <-f.gate:
+		return f.next.Fetch(ctx, url)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Test a crawl stopped part way goes on from its state file, without visiting a page twice
+func TestCrawlResume(t *testing.T) {
+	server, c := newTestSite(t)
+	statePath := filepath.Join(t.TempDir(), "site.crawl")
+
+	WithMaxDepth(5)(c)
+	WithConcurrency(1)(c)
+	WithStateFile(statePath)(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	visited := map[string]int{}
+
+	summary, err := c.Crawl(ctx, server.URL, func(page entities.CrawlPage) {
+		visited[page.URL]++
+
+		if len(visited) == 2 {
+			cancel()
+		}
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 2, summary.Pages)
+
+	progress, err := ReadProgress(statePath)
+	assert.NoError(t, err)
+	assert.Equal(t, entities.CrawlPaused, progress.Status)
+	assert.Equal(t, 2, progress.Visited)
+	assert.Equal(t, 4, progress.Queued)
+
+	// a record cut off by a crash is dropped
+	file, err := os.OpenFile(statePath, os.O_WRONLY|os.O_APPEND, 0o644)
+	assert.NoError(t, err)
+	_, _ = file.WriteString(`{"op":"queued","url":"ht`)
+	assert.NoError(t, file.Close())
+
+	summary, err = c.Crawl(context.Background(), server.URL, func(page entities.CrawlPage) {
+		visited[page.URL]++
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, visited, 7)
+
+	for u, n := range visited {
+		assert.Equal(t, 1, n, u)
+	}
+
+	assert.Equal(t, 6, summary.Pages)
+	assert.Equal(t, 1, summary.Skipped)
+
+	progress, err = ReadProgress(statePath)
+	assert.NoError(t, err)
+	assert.Equal(t, entities.CrawlDone, progress.Status)
+	assert.Equal(t, 0, progress.Queued)
+	assert.Equal(t, *summary, progress.Summary)
+
+	// the state file keeps what the summary needs, not the results
+	data, err := os.ReadFile(statePath)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), `"result"`)
+
+	// a finished crawl isn't crawled again
+	again, err := c.Crawl(context.Background(), server.URL, func(entities.CrawlPage) {
+		t.Error("finished crawl visited a page")
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, summary, again)
+
+	_, err = c.Crawl(context.Background(), "https://other.example/", func(entities.CrawlPage) {})
+	assert.ErrorIs(t, err, entities.ErrInvalidCrawlRequest)
+}
+
+// Test jobs with a state dir pause, resume and survive a restart
+func TestJobsStateDir(t *testing.T) {
+	server, c := newTestSite(t)
+	dir := t.TempDir()
+
+	fetcher := &gatedFetcher{next: c.fetcher, gate: make(chan struct{})}
+
+	waitFor := func(jobs *Jobs, id string, status entities.CrawlStatus) *entities.CrawlJob {
+		var job *entities.CrawlJob
+
+		assert.Eventually(t, func() bool {
+			job, _ = jobs.Get(id)
+
+			return job.Status == status
+		}, 5*time.Second, 10*time.Millisecond)
+
+		return job
+	}
+
+	// without a state dir there is nothing to resume from
+	memory := NewJobs(context.Background(), fetcher, c.service)
+	job, err := memory.Start(entities.CrawlRequest{URL: server.URL})
+	assert.NoError(t, err)
+
+	_, err = memory.Pause(job.ID)
+	assert.ErrorIs(t, err, entities.ErrCrawlConflict)
+	assert.True(t, memory.Cancel(job.ID))
+
+	_, err = memory.Pause("unknown")
+	assert.ErrorIs(t, err, entities.ErrCrawlNotFound)
+
+	ctx, shutdown := context.WithCancel(context.Background())
+
+	jobs := NewJobs(ctx, fetcher, c.service, WithMaxDepth(1))
+	assert.NoError(t, jobs.Restore(dir))
+
+	job, err = jobs.Start(entities.CrawlRequest{URL: server.URL})
+	assert.NoError(t, err)
+
+	_, err = jobs.Pause(job.ID)
+	assert.NoError(t, err)
+	waitFor(jobs, job.ID, entities.CrawlPaused)
+
+	_, err = jobs.Resume(job.ID)
+	assert.NoError(t, err)
+	waitFor(jobs, job.ID, entities.CrawlRunning)
+
+	// the process stops while the crawl waits for its first page
+	shutdown()
+	waitFor(jobs, job.ID, entities.CrawlPaused)
+
+	close(fetcher.gate)
+
+	restarted := NewJobs(context.Background(), fetcher, c.service, WithMaxDepth(1))
+	assert.NoError(t, restarted.Restore(dir))
+
+	done := waitFor(restarted, job.ID, entities.CrawlDone)
+	assert.Len(t, done.Pages, 4)
+	assert.Equal(t, 3, done.Summary.Pages)
+
+	_, err = restarted.Resume(job.ID)
+	assert.ErrorIs(t, err, entities.ErrCrawlConflict)
+
+	// finished jobs are loaded as they were
+	again := NewJobs(context.Background(), fetcher, c.service)
+	assert.NoError(t, again.Restore(dir))
+
+	loaded, ok := again.Get(job.ID)
+	assert.True(t, ok)
+	assert.Equal(t, entities.CrawlDone, loaded.Status)
+	assert.Len(t, loaded.Pages, 4)
+	assert.Nil(t, loaded.Pages[0].Result)
+	assert.Equal(t, done.Summary, loaded.Summary)
+	assert.NotNil(t, loaded.FinishedAt)
+}
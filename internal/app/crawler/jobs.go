@@ -0,0 +1,529 @@
+package crawler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/erainogo/html-analyzer/internal/core/adapters"
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// stateExt the extension of the state files of the jobs in the state dir.
+const stateExt = ".crawl"
+
+// Jobs runs the crawls requested through the API in the background and keeps their progress.
+type Jobs struct {
+	ctx     context.Context
+	fetcher adapters.Fetcher
+	service adapters.AnalyzeService
+	logger  *zap.SugaredLogger
+	// opts the configured limits and scope, requests can lower the limits and add to the scope
+	opts     []Option
+	defaults *Crawler
+	// stateDir where the jobs keep their state files, none when empty
+	stateDir string
+	// maxRunning the most jobs queued or running at once, any number when 0
+	maxRunning int
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	created []string
+}
+
+type job struct {
+	crawl  entities.CrawlJob
+	cancel context.CancelFunc
+	// pausing the job is cancelled to be resumed later
+	pausing bool
+}
+
+// NewJobs the crawls stop when ctx is done.
+func NewJobs(
+	ctx context.Context, fetcher adapters.Fetcher, service adapters.AnalyzeService, opts ...Option,
+) *Jobs {
+	// the limits and scope of the options, so requests can be checked against them
+	defaults := New(fetcher, service, opts...)
+
+	return &Jobs{
+		ctx:      ctx,
+		fetcher:  fetcher,
+		service:  service,
+		logger:   defaults.logger,
+		opts:     opts,
+		defaults: defaults,
+		jobs:     map[string]*job{},
+	}
+}
+
+// Restore keeps the state of the jobs in dir from now on, and loads the jobs already there. Jobs
+// that were running when the process stopped are resumed, paused ones stay paused.
+func (j *Jobs) Restore(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("unable to create crawl state dir: %w", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+stateExt))
+	if err != nil {
+		return err
+	}
+
+	var restored []*job
+
+	for _, path := range paths {
+		jb, err := restoreJob(path)
+		if err != nil {
+			j.logger.Warnw("skipping crawl state", "path", path, "error", err)
+
+			continue
+		}
+
+		restored = append(restored, jb)
+	}
+
+	sort.Slice(restored, func(a, b int) bool {
+		return restored[a].crawl.CreatedAt.Before(restored[b].crawl.CreatedAt)
+	})
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.stateDir = dir
+
+	for _, jb := range restored {
+		j.jobs[jb.crawl.ID] = jb
+		j.created = append(j.created, jb.crawl.ID)
+
+		if jb.crawl.Status == entities.CrawlRunning {
+			if err := j.resume(jb, false); err != nil {
+				j.logger.Warnw("failed to resume crawl", "id", jb.crawl.ID, "error", err)
+			}
+		}
+	}
+
+	j.prune()
+
+	return nil
+}
+
+// LimitRunning caps the jobs queued or running at once, Start and Resume refuse more. Jobs resumed
+// by Restore aren't held back.
+func (j *Jobs) LimitRunning(n int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.maxRunning = n
+}
+
+// checkRunning whether another job may run, with the lock held.
+func (j *Jobs) checkRunning() error {
+	if j.maxRunning <= 0 {
+		return nil
+	}
+
+	running := 0
+
+	for _, jb := range j.jobs {
+		if jb.crawl.Status == entities.CrawlQueued || jb.crawl.Status == entities.CrawlRunning {
+			running++
+		}
+	}
+
+	if running >= j.maxRunning {
+		return fmt.Errorf("%w: %d of %d", entities.ErrTooManyCrawls, running, j.maxRunning)
+	}
+
+	return nil
+}
+
+// restoreJob the job of a state file, running when it was neither finished nor paused.
+func restoreJob(path string) (*job, error) {
+	s, _, err := readState(path, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.request.URL == "" {
+		return nil, errors.New("crawl state has no start")
+	}
+
+	// the state file has the summaries of the pages, as the job keeps them
+	pages := s.pages
+	if pages == nil {
+		pages = []entities.CrawlPage{}
+	}
+
+	jb := &job{
+		crawl: entities.CrawlJob{
+			ID:        strings.TrimSuffix(filepath.Base(path), stateExt),
+			Status:    entities.CrawlRunning,
+			Request:   s.request,
+			CreatedAt: s.createdAt,
+			Pages:     pages,
+			Summary:   s.result(),
+		},
+		cancel: func() {},
+	}
+
+	switch {
+	case s.finished != nil:
+		jb.crawl.Status = s.finished.Status
+		jb.crawl.FinishedAt = s.finished.Time
+		jb.crawl.Error = s.finished.Error
+	case s.paused:
+		jb.crawl.Status = entities.CrawlPaused
+	}
+
+	return jb, nil
+}
+
+// Start validates the request and starts crawling in the background.
+func (j *Jobs) Start(req entities.CrawlRequest) (*entities.CrawlJob, error) {
+	if _, ok := normalizeURL(req.URL); !ok {
+		return nil, fmt.Errorf("%w: url %q is not an http url", entities.ErrInvalidCrawlRequest, req.URL)
+	}
+
+	include, err := ParsePatterns(req.Include)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude, err := ParsePatterns(req.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	req.MaxDepth = limit(req.MaxDepth, j.defaults.maxDepth)
+	req.MaxPages = limit(req.MaxPages, j.defaults.maxPages)
+
+	// the configured excludes always apply, the request's includes replace the configured ones
+	exclude = append(append([]*regexp.Regexp{}, j.defaults.exclude...), exclude...)
+	if len(include) == 0 {
+		include = j.defaults.include
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	jb := &job{
+		crawl: entities.CrawlJob{
+			ID:        id,
+			Status:    entities.CrawlQueued,
+			Request:   req,
+			CreatedAt: time.Now().UTC(),
+			Pages:     []entities.CrawlPage{},
+		},
+	}
+
+	j.mu.Lock()
+
+	if err := j.checkRunning(); err != nil {
+		j.mu.Unlock()
+
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(jobContext(j.ctx, req))
+	jb.cancel = cancel
+
+	j.jobs[id] = jb
+	j.created = append(j.created, id)
+	j.prune()
+	c := j.crawler(id, req, include, exclude)
+	snapshot := jb.snapshot()
+	j.mu.Unlock()
+
+	go j.run(ctx, c, jb)
+
+	return snapshot, nil
+}
+
+// Get the progress of a job, its pages so far and the summary once finished.
+func (j *Jobs) Get(id string) (*entities.CrawlJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	jb, ok := j.jobs[id]
+	if !ok {
+		return nil, false
+	}
+
+	return jb.snapshot(), true
+}
+
+// Cancel stops a running or paused job for good, the pages analyzed so far are kept.
+func (j *Jobs) Cancel(id string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	jb, ok := j.jobs[id]
+	if !ok {
+		return false
+	}
+
+	if jb.crawl.Status == entities.CrawlPaused {
+		j.finish(jb, entities.CrawlCancelled, nil)
+	}
+
+	jb.pausing = false
+	jb.cancel()
+
+	return true
+}
+
+// Pause stops a running job, Resume goes on from where it stopped. Only jobs with a state dir
+// can be paused.
+func (j *Jobs) Pause(id string) (*entities.CrawlJob, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	jb, ok := j.jobs[id]
+	if !ok {
+		return nil, entities.ErrCrawlNotFound
+	}
+
+	if j.stateDir == "" {
+		return nil, fmt.Errorf("%w: crawls are only paused with a state dir", entities.ErrCrawlConflict)
+	}
+
+	if jb.crawl.Status != entities.CrawlQueued && jb.crawl.Status != entities.CrawlRunning {
+		return nil, fmt.Errorf("%w: crawl is %s", entities.ErrCrawlConflict, jb.crawl.Status)
+	}
+
+	jb.pausing = true
+	jb.cancel()
+
+	return jb.snapshot(), nil
+}
+
+// Resume goes on with a paused job.
+func (j *Jobs) Resume(id string) (*entities.CrawlJob, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	jb, ok := j.jobs[id]
+	if !ok {
+		return nil, entities.ErrCrawlNotFound
+	}
+
+	if jb.crawl.Status != entities.CrawlPaused {
+		return nil, fmt.Errorf("%w: crawl is %s", entities.ErrCrawlConflict, jb.crawl.Status)
+	}
+
+	if err := j.checkRunning(); err != nil {
+		return nil, err
+	}
+
+	if err := j.resume(jb, true); err != nil {
+		return nil, err
+	}
+
+	return jb.snapshot(), nil
+}
+
+// resume runs the crawl of the job again from its state file, explicit when it was paused through
+// the API rather than stopped with the process.
+func (j *Jobs) resume(jb *job, explicit bool) error {
+	req := jb.crawl.Request
+
+	include, err := ParsePatterns(req.Include)
+	if err != nil {
+		return err
+	}
+
+	exclude, err := ParsePatterns(req.Exclude)
+	if err != nil {
+		return err
+	}
+
+	if explicit {
+		if err := appendRecord(j.statePath(jb.crawl.ID), record{Op: opResumed}); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(jobContext(j.ctx, req))
+
+	jb.cancel = cancel
+	jb.pausing = false
+	jb.crawl.Status = entities.CrawlQueued
+
+	go j.run(ctx, j.crawler(jb.crawl.ID, req, include, exclude), jb)
+
+	return nil
+}
+
+// jobContext the context of the crawl of req, with its robots.txt choice.
+func jobContext(ctx context.Context, req entities.CrawlRequest) context.Context {
+	if req.Robots != nil {
+		return entities.ContextWithRobots(ctx, *req.Robots)
+	}
+
+	return ctx
+}
+
+// crawler the crawler of a job.
+func (j *Jobs) crawler(id string, req entities.CrawlRequest, include, exclude []*regexp.Regexp) *Crawler {
+	opts := append(append([]Option{}, j.opts...),
+		WithMaxDepth(req.MaxDepth), WithMaxPages(req.MaxPages), WithScope(include, exclude))
+
+	if j.stateDir != "" {
+		opts = append(opts, WithStateFile(j.statePath(id)))
+	}
+
+	return New(j.fetcher, j.service, opts...)
+}
+
+func (j *Jobs) run(ctx context.Context, c *Crawler, jb *job) {
+	defer jb.cancel()
+
+	j.setStatus(jb, entities.CrawlRunning)
+
+	// the job keeps the summaries of the pages, the results would pile up in memory
+	summary, err := c.Crawl(ctx, jb.crawl.Request.URL, func(page entities.CrawlPage) {
+		j.mu.Lock()
+		jb.crawl.Pages = append(jb.crawl.Pages, newVisitedPage(page).page())
+		j.mu.Unlock()
+	})
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if summary != nil {
+		jb.crawl.Summary = summary
+	}
+
+	// with a state file a crawl stopped by a pause or a shutdown goes on later
+	paused := j.stateDir != "" && (jb.pausing || j.ctx.Err() != nil)
+
+	switch {
+	case err == nil:
+		now := time.Now().UTC()
+		jb.crawl.FinishedAt = &now
+		jb.crawl.Status = entities.CrawlDone
+	case errors.Is(err, context.Canceled) && paused:
+		jb.crawl.Status = entities.CrawlPaused
+
+		if jb.pausing {
+			if err := appendRecord(j.statePath(jb.crawl.ID), record{Op: opPaused}); err != nil {
+				j.logger.Errorw("failed to pause crawl", "id", jb.crawl.ID, "error", err)
+			}
+		}
+	case errors.Is(err, context.Canceled):
+		j.finish(jb, entities.CrawlCancelled, nil)
+	default:
+		j.finish(jb, entities.CrawlFailed, err)
+	}
+
+	jb.pausing = false
+
+	j.logger.Infow("crawl stopped", "id", jb.crawl.ID, "status", jb.crawl.Status)
+}
+
+// finish the job with a status other than done, which the crawler records itself.
+func (j *Jobs) finish(jb *job, status entities.CrawlStatus, err error) {
+	now := time.Now().UTC()
+
+	jb.crawl.Status = status
+	jb.crawl.FinishedAt = &now
+
+	r := record{Op: opFinished, Status: status, Time: &now}
+
+	if err != nil {
+		jb.crawl.Error = err.Error()
+		r.Error = err.Error()
+	}
+
+	if j.stateDir == "" {
+		return
+	}
+
+	if err := appendRecord(j.statePath(jb.crawl.ID), r); err != nil && !errors.Is(err, os.ErrNotExist) {
+		j.logger.Errorw("failed to record crawl status", "id", jb.crawl.ID, "error", err)
+	}
+}
+
+func (j *Jobs) setStatus(jb *job, status entities.CrawlStatus) {
+	j.mu.Lock()
+	jb.crawl.Status = status
+	j.mu.Unlock()
+}
+
+func (j *Jobs) statePath(id string) string {
+	return filepath.Join(j.stateDir, id+stateExt)
+}
+
+// prune drops the oldest finished jobs past the number kept, running and paused jobs are never
+// dropped.
+func (j *Jobs) prune() {
+	if len(j.created) <= constants.CrawlJobsKept {
+		return
+	}
+
+	kept := j.created[:0]
+	excess := len(j.created) - constants.CrawlJobsKept
+
+	for _, id := range j.created {
+		if excess > 0 && j.jobs[id].crawl.FinishedAt != nil {
+			delete(j.jobs, id)
+			excess--
+
+			if j.stateDir != "" {
+				if err := os.Remove(j.statePath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+					j.logger.Warnw("failed to remove crawl state", "id", id, "error", err)
+				}
+			}
+
+			continue
+		}
+
+		kept = append(kept, id)
+	}
+
+	j.created = kept
+}
+
+// snapshot a copy that is safe to encode while the crawl goes on.
+func (jb *job) snapshot() *entities.CrawlJob {
+	c := jb.crawl
+	c.Pages = append([]entities.CrawlPage{}, jb.crawl.Pages...)
+
+	if s := jb.crawl.Summary; s != nil {
+		summary := *s
+		c.Summary = &summary
+	}
+
+	return &c
+}
+
+// limit the requested value, the configured one when not requested or above it.
+func limit(requested, configured int) int {
+	if requested <= 0 || requested > configured {
+		return configured
+	}
+
+	return requested
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to create job id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
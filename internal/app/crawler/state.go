@@ -0,0 +1,365 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// the records of a state file
+const (
+	opStart     = "start"
+	opQueued    = "queued"
+	opHost      = "host"
+	opTruncated = "truncated"
+	opVisited   = "visited"
+	opDuplicate = "duplicate"
+	opPaused    = "paused"
+	opResumed   = "resumed"
+	opFinished  = "finished"
+)
+
+// record one change to the state of a crawl, a line of its state file.
+type record struct {
+	Op      string                 `json:"op"`
+	URL     string                 `json:"url,omitempty"` // the site host, of host records
+	Depth   int                    `json:"depth,omitempty"`
+	Request *entities.CrawlRequest `json:"request,omitempty"`
+	Page    *visitedPage           `json:"page,omitempty"`
+	Skipped bool                   `json:"skipped,omitempty"`
+	Status  entities.CrawlStatus   `json:"status,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+	Time    *time.Time             `json:"time,omitempty"`
+}
+
+// visitedPage what a state file keeps of a visited page: where it is and what it adds to the
+// summary, not its analysis, so the file and the replayed state stay small however many pages the
+// crawl visits. The results themselves go to the csv or the job.
+type visitedPage struct {
+	URL        string `json:"url"`
+	Final      string `json:"final,omitempty"` // the url after redirects, of fetched pages
+	Depth      int    `json:"depth,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Disallowed bool   `json:"disallowed,omitempty"`
+	// Analyzed the page has a result, the fields below are of it
+	Analyzed          bool                      `json:"analyzed,omitempty"`
+	Score             int                       `json:"score,omitempty"`
+	InaccessibleLinks int                       `json:"inaccessibleLinks,omitempty"`
+	Findings          *entities.FindingsSummary `json:"findings,omitempty"`
+	Rules             map[string]int            `json:"rules,omitempty"` // findings per rule id
+}
+
+func newVisitedPage(page entities.CrawlPage) *visitedPage {
+	p := &visitedPage{URL: page.URL, Depth: page.Depth, Error: page.Error, Disallowed: page.Disallowed}
+
+	if r := page.Result; r != nil {
+		p.Analyzed = true
+		p.Score = r.Score.Overall
+		p.InaccessibleLinks = r.Links.Inaccessible
+		p.Findings = &r.FindingsSummary
+		p.Rules = map[string]int{}
+
+		for _, f := range r.Findings {
+			p.Rules[f.RuleID]++
+		}
+	}
+
+	return p
+}
+
+// page the crawl page of the record, with the summary of its result.
+func (p *visitedPage) page() entities.CrawlPage {
+	page := entities.CrawlPage{URL: p.URL, Depth: p.Depth, Error: p.Error, Disallowed: p.Disallowed}
+
+	if p.Analyzed {
+		page.Summary = &entities.CrawlPageSummary{Score: p.Score, InaccessibleLinks: p.InaccessibleLinks}
+
+		if p.Findings != nil {
+			page.Summary.Findings = *p.Findings
+		}
+	}
+
+	return page
+}
+
+// crawlState what a crawl needs to go on: the frontier, the hosts it follows and the summary so far.
+// With a state file every change is appended to the file as a record before it is applied, so
+// replaying the file gives the state back after a restart. Pages taken off the queue but not
+// visited when the process stopped are queued again.
+type crawlState struct {
+	request   entities.CrawlRequest
+	createdAt time.Time
+	queue     *frontier
+	hosts     map[string]bool
+	// finals the urls pages were fetched from after redirects, so a page is only analyzed once
+	// however it is linked
+	finals    map[string]bool
+	summary   *entities.CrawlSummary
+	scoreSum  int
+	scheduled int
+	visits    int
+
+	// set by the job records, the crawler itself only finishes
+	paused   bool
+	finished *record
+
+	// pages the visited pages with the summaries of their results, only kept when asked for
+	pages     []entities.CrawlPage
+	keepPages bool
+
+	file *os.File
+}
+
+func newCrawlState(keepPages bool) *crawlState {
+	return &crawlState{
+		queue:     newFrontier(),
+		hosts:     map[string]bool{},
+		finals:    map[string]bool{},
+		summary:   &entities.CrawlSummary{Rules: map[string]int{}},
+		keepPages: keepPages,
+	}
+}
+
+// openState the state of the crawl in the file at path, a new one when the file doesn't exist
+// yet. Further changes are appended to the file until it is closed.
+func openState(path string, req entities.CrawlRequest) (*crawlState, error) {
+	s, size, err := readState(path, false)
+	if errors.Is(err, os.ErrNotExist) {
+		s, size, err = newCrawlState(false), 0, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if s.request.URL != "" && s.request.URL != req.URL {
+		return nil, fmt.Errorf("%w: state file %s is of the crawl of %s",
+			entities.ErrInvalidCrawlRequest, path, s.request.URL)
+	}
+
+	s.file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open crawl state: %w", err)
+	}
+
+	// a record cut off by a crash is dropped
+	if err := s.file.Truncate(size); err != nil {
+		s.close()
+
+		return nil, fmt.Errorf("unable to open crawl state: %w", err)
+	}
+
+	if _, err := s.file.Seek(size, io.SeekStart); err != nil {
+		s.close()
+
+		return nil, fmt.Errorf("unable to open crawl state: %w", err)
+	}
+
+	if s.request.URL == "" {
+		if err := s.start(req); err != nil {
+			s.close()
+
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// readState replays the state file at path, along with the size of its complete records.
+func readState(path string, keepPages bool) (*crawlState, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	s := newCrawlState(keepPages)
+	visited := map[string]bool{}
+
+	var size int64
+
+	reader := bufio.NewReader(file)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// the last record is only complete with its new line
+			break
+		}
+
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to read crawl state: %w", err)
+		}
+
+		var r record
+		if err := json.Unmarshal(bytes.TrimSpace(line), &r); err != nil {
+			return nil, 0, fmt.Errorf("unable to read crawl state %s at offset %d: %w", path, size, err)
+		}
+
+		switch r.Op {
+		case opVisited:
+			visited[r.Page.URL] = true
+		case opDuplicate:
+			visited[r.URL] = true
+		}
+
+		s.apply(r)
+
+		size += int64(len(line))
+	}
+
+	s.queue.drop(visited)
+
+	return s, size, nil
+}
+
+// ReadProgress the progress of the crawl in the state file at path. A crawl that isn't finished
+// is paused, whether it was stopped or the process died; either way it can be resumed.
+func ReadProgress(path string) (*entities.CrawlProgress, error) {
+	s, _, err := readState(path, false)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &entities.CrawlProgress{
+		Request:   s.request,
+		CreatedAt: s.createdAt,
+		Status:    entities.CrawlPaused,
+		Visited:   s.visits,
+		Queued:    s.queue.len(),
+		Summary:   *s.result(),
+	}
+
+	if s.finished != nil {
+		progress.Status = s.finished.Status
+		progress.FinishedAt = s.finished.Time
+		progress.Error = s.finished.Error
+	}
+
+	return progress, nil
+}
+
+// appendRecord adds a record to the state file of a crawl that isn't running.
+func appendRecord(path string, r record) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to update crawl state: %w", err)
+	}
+
+	if err := writeRecord(file, r); err != nil {
+		file.Close()
+
+		return err
+	}
+
+	return file.Close()
+}
+
+// writeRecord writes a record as one line in a single write, a crash leaves at most that line cut off.
+func writeRecord(w io.Writer, r record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("unable to encode crawl state: %w", err)
+	}
+
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("unable to write crawl state: %w", err)
+	}
+
+	return nil
+}
+
+// apply a record to the state.
+func (s *crawlState) apply(r record) {
+	switch r.Op {
+	case opStart:
+		s.request = *r.Request
+		s.summary.Seed = r.Request.URL
+		s.hosts[hostOf(r.Request.URL)] = true
+
+		if r.Time != nil {
+			s.createdAt = *r.Time
+		}
+	case opQueued:
+		s.queue.push(r.URL, r.Depth)
+		s.scheduled++
+	case opHost:
+		s.hosts[r.URL] = true
+	case opTruncated:
+		s.summary.Truncated = true
+	case opVisited:
+		s.visits++
+
+		if r.Page.Final != "" {
+			s.finals[r.Page.Final] = true
+		}
+
+		summarize(s.summary, &s.scoreSum, r.Page, r.Skipped)
+
+		if s.keepPages {
+			s.pages = append(s.pages, r.Page.page())
+		}
+	case opPaused:
+		s.paused = true
+	case opResumed:
+		s.paused = false
+	case opFinished:
+		s.finished = &r
+	}
+}
+
+// write a record to the state file, if there is one, and apply it.
+func (s *crawlState) write(r record) error {
+	if s.file != nil {
+		if err := writeRecord(s.file, r); err != nil {
+			return err
+		}
+	}
+
+	s.apply(r)
+
+	return nil
+}
+
+// start a new crawl from the seed of req.
+func (s *crawlState) start(req entities.CrawlRequest) error {
+	now := time.Now().UTC()
+
+	if err := s.write(record{Op: opStart, Request: &req, Time: &now}); err != nil {
+		return err
+	}
+
+	return s.write(record{Op: opQueued, URL: req.URL})
+}
+
+// result the summary so far.
+func (s *crawlState) result() *entities.CrawlSummary {
+	summary := *s.summary
+
+	summary.Rules = make(map[string]int, len(s.summary.Rules))
+	for rule, n := range s.summary.Rules {
+		summary.Rules[rule] = n
+	}
+
+	if summary.Pages > 0 {
+		summary.AverageScore = s.scoreSum / summary.Pages
+	}
+
+	return &summary
+}
+
+func (s *crawlState) close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	return s.file.Close()
+}
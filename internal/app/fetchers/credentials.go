@@ -0,0 +1,134 @@
+package fetchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// CredentialStore credentials keyed by domain, a domain also covers its subdomains.
+type CredentialStore map[string]*entities.Credentials
+
+// LoadCredentials reads a credentials file, json when the extension says so and yaml otherwise.
+// ${VAR} references are expanded from the environment so secrets can stay out of the file.
+// Without a path the store is empty, the credentials of the requests still apply.
+func LoadCredentials(path string) (CredentialStore, error) {
+	if path == "" {
+		return CredentialStore{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read credentials: %w", err)
+	}
+
+	data = []byte(os.ExpandEnv(string(data)))
+
+	store := CredentialStore{}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &store)
+	} else {
+		err = yaml.Unmarshal(data, &store)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials %s: %w", path, err)
+	}
+
+	normalized := make(CredentialStore, len(store))
+	for domain, c := range store {
+		normalized[strings.ToLower(strings.TrimPrefix(domain, "."))] = c
+	}
+
+	return normalized, nil
+}
+
+// lookup the credentials of the most specific domain covering host.
+func (s CredentialStore) lookup(host string) *entities.Credentials {
+	host = strings.ToLower(host)
+
+	for {
+		if c, ok := s[host]; ok {
+			return c
+		}
+
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			return nil
+		}
+
+		host = host[i+1:]
+	}
+}
+
+// credentialTransport adds the credentials of the request's host. The ones of the request
+// context win over the store, and neither is sent to other hosts, redirects included.
+type credentialTransport struct {
+	next  http.RoundTripper
+	store CredentialStore
+}
+
+// NewCredentialTransport wraps next, a nil next is the default transport.
+func NewCredentialTransport(next http.RoundTripper, store CredentialStore) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &credentialTransport{next: next, store: store}
+}
+
+func (t *credentialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+
+	c := entities.CredentialsFromContext(req.Context(), host)
+	if c == nil {
+		c = t.store.lookup(host)
+	}
+
+	if c == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	// a round tripper must not modify the caller's request
+	req = req.Clone(entities.ContextWithCredentialsSent(req.Context()))
+
+	applyCredentials(req, c)
+
+	return t.next.RoundTrip(req)
+}
+
+func (t *credentialTransport) CloseIdleConnections() {
+	if c, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+func applyCredentials(req *http.Request, c *entities.Credentials) {
+	for name, value := range c.Headers {
+		req.Header.Set(name, value)
+	}
+
+	for name, value := range c.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+
+	if c.Basic != nil {
+		req.SetBasicAuth(c.Basic.Username, c.Basic.Password)
+	}
+
+	if c.Bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Bearer)
+	}
+
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+}
@@ -0,0 +1,63 @@
+package fetchers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/erainogo/html-analyzer/internal/core/adapters"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// kinds of fetchers that can be selected in the config
+const (
+	KindHTTP    = "http"
+	KindFile    = "file"
+	KindFixture = "fixture"
+)
+
+// New returns the fetcher of the given kind. source is the root directory for
+// the file fetcher and the fixture file for the fixture fetcher. Every kind rejects
+// bodies bigger than maxBodySize bytes, zero or less doesn't limit them.
+func New(
+	kind, source string,
+	hc *http.Client,
+	maxBodySize int64,
+	opts ...HTTPFetcherOption,
+) (adapters.Fetcher, error) {
+	switch kind {
+	case KindHTTP, "":
+		return NewHTTPFetcher(hc, append([]HTTPFetcherOption{WithMaxBodySize(maxBodySize)}, opts...)...), nil
+	case KindFile:
+		return NewFileFetcher(source, maxBodySize), nil
+	case KindFixture:
+		f, err := NewFixtureFetcher(source, maxBodySize)
+		if err != nil {
+			return nil, err
+		}
+
+		return f, nil
+	default:
+		return nil, fmt.Errorf("unknown fetcher %q", kind)
+	}
+}
+
+// checkResponse every fetcher treats anything but a 200 html page as a failed fetch.
+func checkResponse(resp *entities.FetchResponse) (*entities.FetchResponse, error) {
+	if err := checkStatus(resp.StatusCode); err != nil {
+		return nil, err
+	}
+
+	if err := checkContentType(resp.Header.Get("Content-Type"), resp.Body); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func checkStatus(status int) error {
+	if status != http.StatusOK {
+		return fmt.Errorf("received non-200 status: %d %s", status, http.StatusText(status))
+	}
+
+	return nil
+}
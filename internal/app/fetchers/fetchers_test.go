@@ -0,0 +1,621 @@
+package fetchers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erainogo/html-analyzer/internal/app/warc"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test the http fetcher sends the configured headers and follows redirects
+func TestHTTPFetcher(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/page", http.StatusMovedPermanently)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(r.UserAgent() + "|" + r.Header.Get("X-Test")))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		path         string
+		maxRedirects int
+		expectedURL  string
+		expectedBody string
+		expectErr    bool
+	}{
+		{
+			name:         "Follows redirects",
+			path:         "/moved",
+			maxRedirects: 10,
+			expectedURL:  srv.URL + "/page",
+			expectedBody: "test-agent|yes",
+		},
+		{
+			name:         "Redirects disabled",
+			path:         "/moved",
+			maxRedirects: 0,
+			expectErr:    true,
+		},
+		{
+			name:         "Not found",
+			path:         "/missing",
+			maxRedirects: 10,
+			expectErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewHTTPFetcher(srv.Client(),
+				WithMaxRedirects(tt.maxRedirects),
+				WithUserAgent("test-agent"),
+				WithHeaders(http.Header{"X-Test": {"yes"}}))
+
+			resp, err := f.Fetch(context.Background(), srv.URL+tt.path)
+			if tt.expectErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedURL, resp.URL)
+			assert.Equal(t, tt.expectedBody, string(resp.Body))
+			assert.Equal(t, "text/html", resp.Header.Get("Content-Type"))
+		})
+	}
+}
+
+// Test the file and fixture fetchers serve pages without the network
+func TestOfflineFetchers(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<p>file</p>"), 0o644)
+	assert.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, "report.pdf"), []byte("%PDF-1.4"), 0o644)
+	assert.NoError(t, err)
+
+	fixtures := filepath.Join(dir, "fixtures.json")
+	err = os.WriteFile(fixtures, []byte(`[
+		{"url": "https://example.com", "status": 200, "body": "<p>fixture</p>"},
+		{"url": "https://example.com/gone", "status": 404, "body": ""}
+	]`), 0o644)
+	assert.NoError(t, err)
+
+	fileFetcher, err := New(KindFile, dir, http.DefaultClient, 1<<20)
+	assert.NoError(t, err)
+
+	fixtureFetcher, err := New(KindFixture, fixtures, http.DefaultClient, 1<<20)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		fetcher      func() (string, error)
+		expectedBody string
+		expectErr    bool
+	}{
+		{
+			name:         "File relative to root",
+			fetcher:      fetchBody(fileFetcher.Fetch, "index.html"),
+			expectedBody: "<p>file</p>",
+		},
+		{
+			name:         "File url",
+			fetcher:      fetchBody(fileFetcher.Fetch, "file://"+filepath.ToSlash(filepath.Join(dir, "index.html"))),
+			expectedBody: "<p>file</p>",
+		},
+		{
+			name:      "File that isn't html",
+			fetcher:   fetchBody(fileFetcher.Fetch, "report.pdf"),
+			expectErr: true,
+		},
+		{
+			name:      "Missing file",
+			fetcher:   fetchBody(fileFetcher.Fetch, "missing.html"),
+			expectErr: true,
+		},
+		{
+			name:         "Fixture without scheme",
+			fetcher:      fetchBody(fixtureFetcher.Fetch, "example.com"),
+			expectedBody: "<p>fixture</p>",
+		},
+		{
+			name:      "Fixture with error status",
+			fetcher:   fetchBody(fixtureFetcher.Fetch, "https://example.com/gone"),
+			expectErr: true,
+		},
+		{
+			name:      "Unrecorded fixture",
+			fetcher:   fetchBody(fixtureFetcher.Fetch, "https://example.org"),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := tt.fetcher()
+			if tt.expectErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, body)
+		})
+	}
+
+	// files outside the root aren't read, however they are named
+	outside := filepath.Join(t.TempDir(), "secret.html")
+	assert.NoError(t, os.WriteFile(outside, []byte("<p>secret</p>"), 0o644))
+
+	rel, err := filepath.Rel(dir, outside)
+	assert.NoError(t, err)
+
+	for _, raw := range []string{outside, "file://" + filepath.ToSlash(outside), rel} {
+		_, err = fileFetcher.Fetch(context.Background(), raw)
+		assert.ErrorIs(t, err, entities.ErrDestinationBlocked, raw)
+	}
+
+	_, err = New("ftp", "", http.DefaultClient, 0)
+	assert.Error(t, err)
+}
+
+// Test the http fetcher rejects pages that are too large or not html
+func TestHTTPFetcherLimits(t *testing.T) {
+	page := []byte("<html><body>" + strings.Repeat("a", 100) + "</body></html>")
+	bomb := bytes.Repeat([]byte("<p>"), 1<<20)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write(page)
+		case "/sniffed":
+			w.Header()["Content-Type"] = nil
+			_, _ = w.Write(page)
+		case "/pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF-1.4"))
+		case "/gzip", "/bomb":
+			body := page
+			if r.URL.Path == "/bomb" {
+				body = bomb
+			}
+
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Encoding", "gzip")
+
+			gz := gzip.NewWriter(w)
+			_, _ = gz.Write(body)
+			_ = gz.Close()
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		path        string
+		maxBodySize int64
+		expectedErr error
+	}{
+		{name: "Within limit", path: "/page", maxBodySize: 1024},
+		{name: "No limit", path: "/page", maxBodySize: 0},
+		{name: "Too large", path: "/page", maxBodySize: 64, expectedErr: entities.ErrBodyTooLarge},
+		{name: "Sniffed html", path: "/sniffed", maxBodySize: 1024},
+		{name: "Not html", path: "/pdf", maxBodySize: 1024, expectedErr: entities.ErrUnsupportedContentType},
+		{name: "Gzip decoded", path: "/gzip", maxBodySize: 1024},
+		{name: "Gzip bomb", path: "/bomb", maxBodySize: 1024, expectedErr: entities.ErrBodyTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewHTTPFetcher(srv.Client(), WithMaxBodySize(tt.maxBodySize))
+
+			resp, err := f.Fetch(context.Background(), srv.URL+tt.path)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, page, resp.Body)
+			assert.Empty(t, resp.Header.Get("Content-Encoding"))
+		})
+	}
+}
+
+// Test a recorded session replays the same pages and statuses without the server
+func TestRecorder(t *testing.T) {
+	page := []byte("<html><body><a href='/gone'>gone</a></body></html>")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/page", http.StatusFound)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Set-Cookie", "session=secret")
+
+			gz := gzip.NewWriter(w)
+			_, _ = gz.Write(page)
+			_ = gz.Close()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	recorder := NewRecorder(1024)
+	hc := &http.Client{Transport: recorder.Transport(srv.Client().Transport)}
+
+	// a HEAD of the page first, the GET after it records the body
+	resp, err := hc.Head(srv.URL + "/page")
+	assert.NoError(t, err)
+
+	_ = resp.Body.Close()
+
+	live, err := NewHTTPFetcher(hc).Fetch(context.Background(), srv.URL+"/moved")
+	assert.NoError(t, err)
+	assert.Equal(t, page, live.Body)
+
+	resp, err = hc.Head(srv.URL + "/gone")
+	assert.NoError(t, err)
+
+	_ = resp.Body.Close()
+
+	srv.Close()
+
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	assert.NoError(t, recorder.Save(path))
+
+	fixtures, err := NewFixtureFetcher(path, 1024)
+	assert.NoError(t, err)
+
+	replayed, err := fixtures.Fetch(context.Background(), srv.URL+"/moved")
+	assert.NoError(t, err)
+	assert.Equal(t, live.URL, replayed.URL)
+	assert.Equal(t, page, replayed.Body)
+	assert.Empty(t, replayed.Header.Get("Set-Cookie"))
+	assert.Empty(t, replayed.Header.Get("Content-Encoding"))
+
+	hc = &http.Client{Transport: fixtures.Transport(nil)}
+
+	resp, err = hc.Head(srv.URL + "/gone")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_ = resp.Body.Close()
+
+	// nothing was recorded for it, the replay fails instead of going to the network
+	_, err = hc.Get(srv.URL + "/other")
+	assert.Error(t, err)
+}
+
+// Test the protocol, sizes and timing of the final request are returned with the page
+func TestHTTPFetcherMetadata(t *testing.T) {
+	page := []byte("<html><body>" + strings.Repeat("metadata ", 100) + "</body></html>")
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/moved" {
+			http.Redirect(w, r, "/page", http.StatusFound)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Content-Encoding", "gzip")
+
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write(page)
+		_ = gz.Close()
+	})
+
+	h2 := httptest.NewUnstartedServer(handler)
+	h2.EnableHTTP2 = true
+	h2.StartTLS()
+
+	defer h2.Close()
+
+	h1 := httptest.NewServer(handler)
+	defer h1.Close()
+
+	tests := []struct {
+		name     string
+		srv      *httptest.Server
+		protocol string
+		tls      bool
+	}{
+		{name: "HTTP/2 over tls", srv: h2, protocol: "HTTP/2.0", tls: true},
+		{name: "HTTP/1.1", srv: h1, protocol: "HTTP/1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := NewHTTPFetcher(tt.srv.Client()).Fetch(context.Background(), tt.srv.URL+"/moved")
+			assert.NoError(t, err)
+
+			m := resp.Metadata
+			assert.NotNil(t, m)
+			assert.Equal(t, tt.srv.URL+"/page", m.FinalURL)
+			assert.Equal(t, tt.protocol, m.Protocol)
+			assert.Equal(t, "127.0.0.1", m.RemoteIP)
+			assert.Equal(t, int64(len(page)), m.UncompressedSize)
+			assert.Less(t, m.CompressedSize, m.UncompressedSize)
+			assert.Greater(t, m.CompressedSize, int64(0))
+
+			// the redirect's connection is reused for the final request
+			assert.True(t, m.Timing.ReusedConnection)
+			assert.Greater(t, m.Timing.TimeToFirstByte, 0.0)
+			assert.GreaterOrEqual(t, m.Timing.Total, m.Timing.TimeToFirstByte)
+		})
+	}
+
+	// a new connection has the connect and handshake phases
+	h2.Client().CloseIdleConnections()
+
+	resp, err := NewHTTPFetcher(h2.Client()).Fetch(context.Background(), h2.URL+"/page")
+	assert.NoError(t, err)
+	assert.False(t, resp.Metadata.Timing.ReusedConnection)
+	assert.Greater(t, resp.Metadata.Timing.Connect, 0.0)
+	assert.Greater(t, resp.Metadata.Timing.TLSHandshake, 0.0)
+
+	// pages that aren't fetched over http have no metadata
+	fixtures := newFixtureFetcher([]recordedResponse{{URL: "https://example.com/", StatusCode: 200, Body: string(page)}}, 0)
+
+	resp, err = fixtures.Fetch(context.Background(), "https://example.com/")
+	assert.NoError(t, err)
+	assert.Nil(t, resp.Metadata)
+}
+
+// Test parsing of the extra header flags
+func TestParseHeaders(t *testing.T) {
+	headers, err := ParseHeaders([]string{"Accept-Language: en", "X-Token:abc"})
+	assert.NoError(t, err)
+	assert.Equal(t, "en", headers.Get("Accept-Language"))
+	assert.Equal(t, "abc", headers.Get("X-Token"))
+
+	_, err = ParseHeaders([]string{"no-colon"})
+	assert.Error(t, err)
+}
+
+func fetchBody(
+	fetch func(context.Context, string) (*entities.FetchResponse, error), url string,
+) func() (string, error) {
+	return func() (string, error) {
+		resp, err := fetch(context.Background(), url)
+		if err != nil {
+			return "", err
+		}
+
+		return string(resp.Body), nil
+	}
+}
+
+// Test the credentials reach their own hosts only
+func TestCredentialTransport(t *testing.T) {
+	var seen []*http.Request
+
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		seen = append(seen, req)
+
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	t.Setenv("TEST_TOKEN", "s3cret")
+
+	path := filepath.Join(t.TempDir(), "credentials.yaml")
+	err := os.WriteFile(path, []byte("example.org:\n  bearer: ${TEST_TOKEN}\n"), 0o644)
+	assert.NoError(t, err)
+
+	store, err := LoadCredentials(path)
+	assert.NoError(t, err)
+
+	hc := &http.Client{Transport: NewCredentialTransport(next, store)}
+
+	page := &entities.Credentials{
+		Headers:   map[string]string{"X-Api-Key": "key"},
+		Cookies:   map[string]string{"session": "abc"},
+		Basic:     &entities.BasicAuth{Username: "user", Password: "pass"},
+		UserAgent: "custom-agent",
+	}
+	ctx := entities.ContextWithCredentials(context.Background(), "example.com", page)
+
+	for _, u := range []string{"https://example.com/a", "https://docs.example.org/b", "https://other.net/c"} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+		resp, err := hc.Do(req)
+		assert.NoError(t, err)
+
+		_ = resp.Body.Close()
+	}
+
+	// request credentials for the page host
+	user, pass, ok := seen[0].BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", user)
+	assert.Equal(t, "pass", pass)
+	assert.Equal(t, "key", seen[0].Header.Get("X-Api-Key"))
+	assert.Equal(t, "custom-agent", seen[0].UserAgent())
+
+	cookie, err := seen[0].Cookie("session")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", cookie.Value)
+
+	// stored credentials cover subdomains, with the secret read from the environment
+	assert.Equal(t, "Bearer s3cret", seen[1].Header.Get("Authorization"))
+
+	// nothing for other hosts
+	assert.Empty(t, seen[2].Header.Get("Authorization"))
+	assert.Empty(t, seen[2].Header.Get("X-Api-Key"))
+	assert.Empty(t, seen[2].Header.Get("Cookie"))
+
+	// secrets are not printed
+	assert.NotContains(t, page.String(), "pass")
+	assert.NotContains(t, page.String(), "abc")
+	assert.NotContains(t, store["example.org"].String(), "s3cret")
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Test pages and link statuses are served from a HAR
+func TestHARFetcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.har")
+
+	err := os.WriteFile(path, []byte(`{"log": {"version": "1.2", "entries": [
+		{"request": {"method": "GET", "url": "https://example.com/login"},
+		 "response": {"status": 302, "redirectURL": "https://example.com/account", "headers": [], "content": {}}},
+		{"request": {"method": "GET", "url": "https://example.com/account"},
+		 "response": {"status": 200, "headers": [{"name": "Content-Encoding", "value": "gzip"}],
+		  "content": {"mimeType": "text/html; charset=utf-8", "text": "PGgxPkFjY291bnQ8L2gxPg==", "encoding": "base64"}}},
+		{"request": {"method": "GET", "url": "https://example.com/logo.png"},
+		 "response": {"status": 200, "headers": [], "content": {"mimeType": "image/png", "text": ""}}},
+		{"request": {"method": "GET", "url": "https://example.com/missing"},
+		 "response": {"status": 404, "headers": [], "content": {"mimeType": "text/html", "text": "gone"}}},
+		{"request": {"method": "GET", "url": "https://example.com/blocked"},
+		 "response": {"status": 0, "headers": [], "content": {}}},
+		{"request": {"method": "POST", "url": "https://example.com/api"},
+		 "response": {"status": 200, "headers": [], "content": {"mimeType": "text/html", "text": "<p>post</p>"}}}
+	]}}`), 0o644)
+	assert.NoError(t, err)
+
+	f, err := NewHARFetcher(path, 1<<20)
+	assert.NoError(t, err)
+
+	// only the html pages that loaded are analyzed
+	assert.Equal(t, []string{"https://example.com/account"}, f.Documents())
+
+	resp, err := f.Fetch(context.Background(), "https://example.com/login")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/account", resp.URL)
+	assert.Equal(t, "<h1>Account</h1>", string(resp.Body))
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+
+	hc := &http.Client{Transport: f.Transport(nil)}
+
+	head := func(u string) (int, error) {
+		resp, err := hc.Head(u)
+		if err != nil {
+			return 0, err
+		}
+
+		_ = resp.Body.Close()
+
+		return resp.StatusCode, nil
+	}
+
+	status, err := head("https://example.com/logo.png")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+
+	status, err = head("https://example.com/missing")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// blocked and unrecorded requests go to the next transport, here there's none
+	_, err = head("https://example.com/blocked")
+	assert.Error(t, err)
+
+	_, err = head("https://example.org/")
+	assert.Error(t, err)
+}
+
+// Test a run recorded to a WARC can be analyzed again from it
+func TestWARCFetcher(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<a href="/gone">gone</a>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "run.warc.gz")
+
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+
+	recorder := warc.NewRecorder(warc.NewWriter(file, true), nil, 1<<20)
+	live := NewHTTPFetcher(&http.Client{Transport: recorder})
+
+	_, err = live.Fetch(context.Background(), srv.URL+"/")
+	assert.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: recorder}).Head(srv.URL + "/gone")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.NoError(t, file.Close())
+
+	f, err := NewWARCFetcher(path, 1<<20)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{srv.URL + "/"}, f.Documents())
+
+	page, err := f.Fetch(context.Background(), srv.URL+"/")
+	assert.NoError(t, err)
+	assert.Equal(t, `<a href="/gone">gone</a>`, string(page.Body))
+
+	resp, err = (&http.Client{Transport: f.Transport(nil)}).Head(srv.URL + "/gone")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// Test local directories are listed with filters and served at the base url
+func TestLocalInput(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"index.html", "docs/guide.htm", "docs/notes.txt", "drafts/wip.html", "docs/old.html"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte("<p>"+name+"</p>"), 0o644))
+	}
+
+	files, err := ListFiles(dir, []string{"*.html", "*.htm"}, []string{"drafts", "docs/old.html"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"docs/guide.htm", "index.html"}, files)
+
+	base, _ := url.Parse("https://example.com/site")
+	f := NewFileFetcher(dir, 1<<20, FileWithBaseURL(base))
+
+	resp, err := f.Fetch(context.Background(), "docs/guide.htm")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/site/docs/guide.htm", resp.URL)
+	assert.Equal(t, "<p>docs/guide.htm</p>", string(resp.Body))
+
+	stdin, err := NewReaderFetcher(strings.NewReader("<h1>piped</h1>"), "https://example.com/", 1<<20)
+	assert.NoError(t, err)
+
+	resp, err = stdin.Fetch(context.Background(), "https://example.com/")
+	assert.NoError(t, err)
+	assert.Equal(t, "<h1>piped</h1>", string(resp.Body))
+
+	_, err = NewReaderFetcher(strings.NewReader(strings.Repeat("a", 100)), "-", 10)
+	assert.ErrorIs(t, err, entities.ErrBodyTooLarge)
+}
@@ -0,0 +1,214 @@
+package fetchers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/erainogo/html-analyzer/internal/core/adapters"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// FileFetcher reads pages from disk, urls are file:// urls or paths relative to the root. Only
+// files under the root are read, the current directory without one.
+type FileFetcher struct {
+	root        string
+	maxBodySize int64
+	baseURL     *url.URL
+}
+
+type FileFetcherOption func(*FileFetcher)
+
+// FileWithBaseURL the root is served at the base url, pages under the root get their url from it
+// so links are classified and resolved as on the site.
+func FileWithBaseURL(base *url.URL) FileFetcherOption {
+	return func(f *FileFetcher) {
+		if base == nil {
+			return
+		}
+
+		root := *base
+		if !strings.HasSuffix(root.Path, "/") {
+			root.Path += "/"
+		}
+
+		f.baseURL = &root
+	}
+}
+
+func NewFileFetcher(root string, maxBodySize int64, opts ...FileFetcherOption) adapters.Fetcher {
+	if root == "" {
+		root = "."
+	}
+
+	f := &FileFetcher{root: root, maxBodySize: maxBodySize}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+func (f *FileFetcher) Fetch(ctx context.Context, rawURL string) (*entities.FetchResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	path, err := f.resolve(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("unable to read file " + path)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	body, err := readBody(file, "", f.maxBodySize)
+	if errors.Is(err, entities.ErrBodyTooLarge) {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, errors.New("unable to read file " + path)
+	}
+
+	header := http.Header{}
+
+	// the extension gives the content type, unknown extensions are sniffed
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	return checkResponse(&entities.FetchResponse{
+		URL:        f.pageURL(path),
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       body,
+	})
+}
+
+// resolve the absolute path of the file of rawURL, which has to be under the root: file:// urls,
+// absolute paths and ../ segments can't read other files.
+func (f *FileFetcher) resolve(rawURL string) (string, error) {
+	path := rawURL
+
+	if strings.HasPrefix(rawURL, "file://") {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return "", errors.New("invalid file URL")
+		}
+
+		path = filepath.FromSlash(u.Path)
+	} else if !filepath.IsAbs(path) {
+		path = filepath.Join(f.root, path)
+	}
+
+	root, err := realPath(f.root)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve the root %s: %w", f.root, err)
+	}
+
+	abs, err := realPath(path)
+	if err != nil {
+		return "", errors.New("unable to read file " + path)
+	}
+
+	if rel, err := filepath.Rel(root, abs); err != nil || rel == ".." ||
+		strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is outside %s", entities.ErrDestinationBlocked, path, f.root)
+	}
+
+	return abs, nil
+}
+
+// realPath the absolute path with the symlinks followed, so a link can't point out of the root.
+func realPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(abs)
+}
+
+// pageURL the url of the page under the base url, a file url without one.
+func (f *FileFetcher) pageURL(abs string) string {
+	fileURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+
+	if f.baseURL == nil {
+		return fileURL
+	}
+
+	root, err := realPath(f.root)
+	if err != nil {
+		return fileURL
+	}
+
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return fileURL
+	}
+
+	return f.baseURL.ResolveReference(&url.URL{Path: filepath.ToSlash(rel)}).String()
+}
+
+// ListFiles the files under root whose name matches an include pattern and whose name or path
+// doesn't match an exclude pattern, as slash separated paths relative to root.
+func ListFiles(root string, include, exclude []string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if rel != "." && (matchAny(exclude, d.Name()) || matchAny(exclude, rel)) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !d.IsDir() && matchAny(include, d.Name()) {
+			files = append(files, rel)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list %s: %w", root, err)
+	}
+
+	return files, nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,217 @@
+package fetchers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// redirects followed within the recorded responses
+const maxRecordedRedirects = 10
+
+// recordedResponse one response of a fixture file.
+type recordedResponse struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// FixtureFetcher serves pages from recorded responses, never touching the network.
+type FixtureFetcher struct {
+	responses   map[string]recordedResponse
+	maxBodySize int64
+	// documents the urls of the recorded html pages, in recording order
+	documents []string
+}
+
+func NewFixtureFetcher(path string, maxBodySize int64) (*FixtureFetcher, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read fixtures: %w", err)
+	}
+
+	var recorded []recordedResponse
+
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("invalid fixtures %s: %w", path, err)
+	}
+
+	return newFixtureFetcher(recorded, maxBodySize), nil
+}
+
+// newFixtureFetcher the first recording of a url wins.
+func newFixtureFetcher(recorded []recordedResponse, maxBodySize int64) *FixtureFetcher {
+	f := &FixtureFetcher{
+		responses:   make(map[string]recordedResponse, len(recorded)),
+		maxBodySize: maxBodySize,
+	}
+
+	for _, r := range recorded {
+		key := recordingKey(r.URL)
+		if _, ok := f.responses[key]; ok {
+			continue
+		}
+
+		f.responses[key] = r
+
+		if r.StatusCode == http.StatusOK && checkContentType(r.Header.Get("Content-Type"), []byte(r.Body)) == nil {
+			f.documents = append(f.documents, r.URL)
+		}
+	}
+
+	return f
+}
+
+// NewReaderFetcher serves the html read from r, e.g. stdin, as the page at pageURL.
+func NewReaderFetcher(r io.Reader, pageURL string, maxBodySize int64) (*FixtureFetcher, error) {
+	body, err := readBody(r, "", maxBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFixtureFetcher([]recordedResponse{{
+		URL:        pageURL,
+		StatusCode: http.StatusOK,
+		Body:       string(body),
+	}}, maxBodySize), nil
+}
+
+// Documents the urls of the recorded html pages.
+func (f *FixtureFetcher) Documents() []string {
+	return f.documents
+}
+
+func (f *FixtureFetcher) Fetch(ctx context.Context, url string) (*entities.FetchResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r, ok := f.lookup(url)
+	if !ok && !strings.HasPrefix(url, "http") {
+		r, ok = f.lookup("https://" + url)
+	}
+
+	if !ok {
+		return nil, errors.New("no recorded response for " + url)
+	}
+
+	for i := 0; isRedirect(r.StatusCode); i++ {
+		next, ok := f.redirect(r)
+		if !ok {
+			break
+		}
+
+		if i == maxRecordedRedirects {
+			return nil, fmt.Errorf("stopped after %d redirects", maxRecordedRedirects)
+		}
+
+		r = next
+	}
+
+	// recorded bodies are already decoded, the limit still applies so replays fail like live fetches
+	body, err := readBody(strings.NewReader(r.Body), "", f.maxBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkResponse(&entities.FetchResponse{
+		URL:        r.URL,
+		StatusCode: r.StatusCode,
+		Header:     r.Header,
+		Body:       body,
+	})
+}
+
+func (f *FixtureFetcher) lookup(url string) (recordedResponse, bool) {
+	r, ok := f.responses[recordingKey(url)]
+
+	return r, ok
+}
+
+// redirect the recorded response of the redirect's target.
+func (f *FixtureFetcher) redirect(r recordedResponse) (recordedResponse, bool) {
+	location := r.Header.Get("Location")
+	if location == "" {
+		return recordedResponse{}, false
+	}
+
+	base, err := url.Parse(r.URL)
+	if err != nil {
+		return recordedResponse{}, false
+	}
+
+	target, err := base.Parse(location)
+	if err != nil {
+		return recordedResponse{}, false
+	}
+
+	return f.lookup(target.String())
+}
+
+// Transport answers the requests that were recorded and sends the others to next, a nil next
+// fails them instead. Link checks then use the recorded statuses.
+func (f *FixtureFetcher) Transport(next http.RoundTripper) http.RoundTripper {
+	return &recordedTransport{fetcher: f, next: next}
+}
+
+type recordedTransport struct {
+	fetcher *FixtureFetcher
+	next    http.RoundTripper
+}
+
+func (t *recordedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r, ok := t.fetcher.lookup(req.URL.String())
+	if ok && (req.Method == http.MethodGet || req.Method == http.MethodHead) {
+		body := []byte(r.Body)
+		if req.Method == http.MethodHead {
+			body = nil
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+			StatusCode:    r.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        r.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	if t.next == nil {
+		return nil, errors.New("no recorded response for " + req.URL.Redacted())
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+func (t *recordedTransport) CloseIdleConnections() {
+	if c, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// recordingKey urls match without their fragment.
+func recordingKey(raw string) string {
+	if i := strings.IndexByte(raw, '#'); i >= 0 {
+		return raw[:i]
+	}
+
+	return raw
+}
+
+func isRedirect(status int) bool {
+	return status >= http.StatusMultipleChoices && status < http.StatusBadRequest && status != http.StatusNotModified
+}
@@ -0,0 +1,108 @@
+package fetchers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// har the parts of a HAR 1.2 archive the analyzer reads.
+type har struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"request"`
+	Response struct {
+		Status      int         `json:"status"`
+		Headers     []harHeader `json:"headers"`
+		RedirectURL string      `json:"redirectURL"`
+		Content     struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// NewHARFetcher serves the GET responses recorded in a HAR file, e.g. exported from browser devtools.
+func NewHARFetcher(path string, maxBodySize int64) (*FixtureFetcher, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read har: %w", err)
+	}
+
+	archive := &har{}
+
+	if err := json.Unmarshal(data, archive); err != nil {
+		return nil, fmt.Errorf("invalid har %s: %w", path, err)
+	}
+
+	recorded := make([]recordedResponse, 0, len(archive.Log.Entries))
+
+	for _, e := range archive.Log.Entries {
+		// status 0 marks requests that were blocked or never answered
+		if e.Request.Method != http.MethodGet || e.Response.Status == 0 {
+			continue
+		}
+
+		r, err := e.recorded()
+		if err != nil {
+			return nil, fmt.Errorf("invalid har %s: %s: %w", path, e.Request.URL, err)
+		}
+
+		recorded = append(recorded, r)
+	}
+
+	return newFixtureFetcher(recorded, maxBodySize), nil
+}
+
+func (e harEntry) recorded() (recordedResponse, error) {
+	header := http.Header{}
+
+	for _, h := range e.Response.Headers {
+		header.Add(h.Name, h.Value)
+	}
+
+	// the recorded content is decoded already
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+
+	if header.Get("Content-Type") == "" && e.Response.Content.MimeType != "" {
+		header.Set("Content-Type", e.Response.Content.MimeType)
+	}
+
+	if header.Get("Location") == "" && e.Response.RedirectURL != "" {
+		header.Set("Location", e.Response.RedirectURL)
+	}
+
+	body := e.Response.Content.Text
+
+	if e.Response.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return recordedResponse{}, err
+		}
+
+		body = string(decoded)
+	}
+
+	return recordedResponse{
+		URL:        e.Request.URL,
+		StatusCode: e.Response.Status,
+		Header:     header,
+		Body:       body,
+	}, nil
+}
@@ -0,0 +1,187 @@
+package fetchers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/erainogo/html-analyzer/internal/core/adapters"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+type HTTPFetcher struct {
+	hc        *http.Client
+	timeout   time.Duration
+	userAgent string
+	headers   http.Header
+
+	maxBodySize int64
+}
+
+type HTTPFetcherOption func(*HTTPFetcher)
+
+// WithTimeout limits the whole fetch, including reading the body.
+func WithTimeout(timeout time.Duration) HTTPFetcherOption {
+	return func(f *HTTPFetcher) {
+		f.timeout = timeout
+	}
+}
+
+// WithMaxRedirects follows at most n redirects, zero doesn't follow any.
+func WithMaxRedirects(n int) HTTPFetcherOption {
+	return func(f *HTTPFetcher) {
+		f.hc.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if n == 0 {
+				return http.ErrUseLastResponse
+			}
+
+			if len(via) > n {
+				return fmt.Errorf("stopped after %d redirects", n)
+			}
+
+			return nil
+		}
+	}
+}
+
+// WithMaxBodySize rejects pages bigger than n bytes once decompressed, zero or less doesn't limit them.
+func WithMaxBodySize(n int64) HTTPFetcherOption {
+	return func(f *HTTPFetcher) {
+		f.maxBodySize = n
+	}
+}
+
+func WithUserAgent(userAgent string) HTTPFetcherOption {
+	return func(f *HTTPFetcher) {
+		f.userAgent = userAgent
+	}
+}
+
+// WithHeaders adds the headers to every request.
+func WithHeaders(headers http.Header) HTTPFetcherOption {
+	return func(f *HTTPFetcher) {
+		for k, v := range headers {
+			f.headers[k] = append(f.headers[k], v...)
+		}
+	}
+}
+
+func NewHTTPFetcher(hc *http.Client, opts ...HTTPFetcherOption) adapters.Fetcher {
+	// copy the client, the redirect policy is ours and shouldn't leak into the link checks
+	client := *hc
+
+	f := &HTTPFetcher{
+		hc:      &client,
+		headers: http.Header{},
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) (*entities.FetchResponse, error) {
+	if !strings.HasPrefix(url, "http") {
+		url = "https://" + url
+	}
+
+	if f.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+	}
+
+	trace := newFetchTrace()
+
+	req, err := http.NewRequestWithContext(trace.context(ctx), http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.New("invalid URL")
+	}
+
+	for k, v := range f.headers {
+		req.Header[k] = v
+	}
+
+	if f.userAgent != "" {
+		req.Header.Set("User-Agent", f.userAgent)
+	}
+
+	// asking for the encoding ourselves turns off the transport's transparent gzip,
+	// the body is then decompressed by readBody under the size limit
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+
+	resp, err := f.hc.Do(req)
+	if errors.Is(err, entities.ErrDestinationBlocked) || errors.Is(err, entities.ErrNotCached) {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, errors.New("unable to reach URL")
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	// reject before downloading what the headers already rule out
+	if err := checkStatus(resp.StatusCode); err != nil {
+		return nil, err
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		if err := checkContentType(contentType, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.maxBodySize > 0 && resp.ContentLength > f.maxBodySize {
+		return nil, fmt.Errorf("%w: %d bytes", entities.ErrBodyTooLarge, resp.ContentLength)
+	}
+
+	wire := &countingReader{r: resp.Body}
+
+	body, err := readBody(wire, resp.Header.Get("Content-Encoding"), f.maxBodySize)
+	if errors.Is(err, entities.ErrBodyTooLarge) {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, errors.New("failed to read response body")
+	}
+
+	metadata := trace.metadata(resp, wire.n, int64(len(body)))
+
+	// the body is decoded now, as the transport does for transparent gzip
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+
+	return checkResponse(&entities.FetchResponse{
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		Metadata:   metadata,
+	})
+}
+
+// ParseHeaders parses "Name: value" pairs into headers.
+func ParseHeaders(pairs []string) (http.Header, error) {
+	headers := http.Header{}
+
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header %q, expected \"Name: value\"", pair)
+		}
+
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	return headers, nil
+}
@@ -0,0 +1,80 @@
+package fetchers
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// content types that are analyzed, anything else is rejected
+var htmlContentTypes = map[string]bool{
+	"text/html":             true,
+	"application/xhtml+xml": true,
+}
+
+// checkContentType rejects pages that aren't html. Without a content type the body is sniffed.
+func checkContentType(contentType string, body []byte) error {
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%w: %q", entities.ErrUnsupportedContentType, contentType)
+	}
+
+	if !htmlContentTypes[mediaType] {
+		return fmt.Errorf("%w: %s", entities.ErrUnsupportedContentType, mediaType)
+	}
+
+	return nil
+}
+
+// readBody reads the body decoded by its content encoding, failing once more than limit bytes
+// come out of it. The limit is applied to the decoded stream so a small compressed bomb can't
+// expand past it. A limit of zero or less reads everything.
+func readBody(r io.Reader, encoding string, limit int64) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer gz.Close()
+
+		r = gz
+	case "deflate":
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deflate body: %w", err)
+		}
+		defer zr.Close()
+
+		r = zr
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+
+	// one byte over the limit tells a body of exactly limit bytes from a bigger one
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%w: more than %d bytes", entities.ErrBodyTooLarge, limit)
+	}
+
+	return body, nil
+}
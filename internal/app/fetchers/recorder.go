@@ -0,0 +1,139 @@
+package fetchers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Recorder records the responses of the transports it wraps as a fixture file, which the
+// fixture fetcher and Transport of a FixtureFetcher replay without the network.
+type Recorder struct {
+	mu          sync.Mutex
+	responses   []recordedResponse
+	index       map[string]int
+	maxBodySize int64
+}
+
+func NewRecorder(maxBodySize int64) *Recorder {
+	return &Recorder{index: map[string]int{}, maxBodySize: maxBodySize}
+}
+
+// Transport records the responses of next.
+func (r *Recorder) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &recordingTransport{recorder: r, next: next}
+}
+
+// Save writes the recorded responses to path, replacing it atomically.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.responses, "", "  ")
+	r.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("unable to encode fixtures: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".fixtures-*")
+	if err != nil {
+		return fmt.Errorf("unable to write fixtures: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+
+		return fmt.Errorf("unable to write fixtures: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write fixtures: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("unable to write fixtures: %w", err)
+	}
+
+	return nil
+}
+
+// record the first response of a url wins, except a GET replaces a HEAD as it has the body.
+func (r *Recorder) record(method string, rec recordedResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := recordingKey(rec.URL)
+
+	i, ok := r.index[key]
+	if !ok {
+		r.index[key] = len(r.responses)
+		r.responses = append(r.responses, rec)
+
+		return
+	}
+
+	if method == http.MethodGet && r.responses[i].Body == "" {
+		r.responses[i] = rec
+	}
+}
+
+type recordingTransport struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return resp, err
+	}
+
+	// fixtures hold decoded bodies, the response continues decoded too. Bodies over the limit
+	// fail like the fetchers fail them.
+	var body []byte
+
+	if req.Method == http.MethodGet {
+		body, err = readBody(resp.Body, resp.Header.Get("Content-Encoding"), t.recorder.maxBodySize)
+	}
+
+	_ = resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(len(body))
+	resp.Uncompressed = true
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	// cookies are session secrets, they aren't needed to replay
+	header.Del("Set-Cookie")
+
+	t.recorder.record(req.Method, recordedResponse{
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       string(body),
+	})
+
+	return resp, nil
+}
+
+func (t *recordingTransport) CloseIdleConnections() {
+	if c, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
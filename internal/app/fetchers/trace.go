@@ -0,0 +1,144 @@
+package fetchers
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// fetchTrace times the phases of a fetch. Redirects restart the phases, so they describe the
+// request of the final url, while the total covers the whole fetch.
+type fetchTrace struct {
+	mu  sync.Mutex
+	now func() time.Time
+
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	requestStart time.Time
+
+	timing   entities.FetchTiming
+	remoteIP string
+}
+
+func newFetchTrace() *fetchTrace {
+	t := &fetchTrace{now: time.Now}
+	t.start = t.now()
+
+	return t
+}
+
+// context the context of the requests to trace.
+func (t *fetchTrace) context(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			// a new request, after a redirect the phases of the previous one are dropped
+			t.requestStart = t.now()
+			t.timing = entities.FetchTiming{}
+			t.remoteIP = ""
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = t.now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.timing.DNS = milliseconds(t.now().Sub(t.dnsStart))
+			t.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			t.connectStart = t.now()
+			t.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			// with several addresses the one that connected counts
+			if err == nil {
+				t.timing.Connect = milliseconds(t.now().Sub(t.connectStart))
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = t.now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			t.timing.TLSHandshake = milliseconds(t.now().Sub(t.tlsStart))
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			t.timing.ReusedConnection = info.Reused
+
+			if info.Conn == nil || info.Conn.RemoteAddr() == nil {
+				return
+			}
+
+			if addr, ok := info.Conn.RemoteAddr().(*net.TCPAddr); ok {
+				t.remoteIP = addr.IP.String()
+			} else if host, _, err := net.SplitHostPort(info.Conn.RemoteAddr().String()); err == nil {
+				t.remoteIP = host
+			}
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.timing.TimeToFirstByte = milliseconds(t.now().Sub(t.requestStart))
+			t.mu.Unlock()
+		},
+	})
+}
+
+// metadata how the page was fetched, once its body is read.
+func (t *fetchTrace) metadata(resp *http.Response, compressed, uncompressed int64) *entities.FetchMetadata {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timing := t.timing
+	timing.Total = milliseconds(t.now().Sub(t.start))
+
+	return &entities.FetchMetadata{
+		FinalURL:         resp.Request.URL.String(),
+		Protocol:         resp.Proto,
+		RemoteIP:         t.remoteIP,
+		CompressedSize:   compressed,
+		UncompressedSize: uncompressed,
+		Timing:           timing,
+		ConnectionState:  resp.TLS,
+	}
+}
+
+// countingReader counts the bytes read, the size of the body as it came over the wire.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
+
+// milliseconds with microsecond precision.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
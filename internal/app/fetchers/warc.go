@@ -0,0 +1,86 @@
+package fetchers
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/erainogo/html-analyzer/internal/app/warc"
+)
+
+// NewWARCFetcher serves the http responses archived in a plain or gzipped WARC file.
+func NewWARCFetcher(path string, maxBodySize int64) (*FixtureFetcher, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read warc: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader, err := warc.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid warc %s: %w", path, err)
+	}
+
+	var recorded []recordedResponse
+
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid warc %s: %w", path, err)
+		}
+
+		if record.Type() != warc.TypeResponse ||
+			!strings.HasPrefix(record.Header.Get("Content-Type"), "application/http") {
+			continue
+		}
+
+		// truncated or oversized records are left out, like a failed fetch
+		r, err := httpRecord(record, maxBodySize)
+		if err != nil {
+			continue
+		}
+
+		recorded = append(recorded, r)
+	}
+
+	return newFixtureFetcher(recorded, maxBodySize), nil
+}
+
+// httpRecord the recorded response of a response record, its body decoded.
+func httpRecord(record *warc.Record, maxBodySize int64) (recordedResponse, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(record.Block)), nil)
+	if err != nil {
+		return recordedResponse{}, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := readBody(resp.Body, resp.Header.Get("Content-Encoding"), maxBodySize)
+	if err != nil {
+		return recordedResponse{}, err
+	}
+
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+
+	return recordedResponse{
+		URL:        record.TargetURI(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(body),
+	}, nil
+}
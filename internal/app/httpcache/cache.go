@@ -0,0 +1,430 @@
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// XCache header telling how a response was served.
+const (
+	XCache      = "X-Cache"
+	Hit         = "HIT"
+	Revalidated = "REVALIDATED"
+	Miss        = "MISS"
+)
+
+// statuses stored, the ones cacheable by default that the analyzer cares about
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusGone:                 true,
+}
+
+// Cache a private on-disk http cache. Fresh responses are served from disk, stale ones are
+// revalidated with If-None-Match and If-Modified-Since, and offline only the disk is read.
+type Cache struct {
+	dir          string
+	next         http.RoundTripper
+	maxAge       time.Duration
+	maxEntrySize int64
+	offline      bool
+	now          func() time.Time
+}
+
+type Option func(*Cache)
+
+// WithMaxAge how long responses without an explicit lifetime stay fresh.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(c *Cache) {
+		c.maxAge = maxAge
+	}
+}
+
+// WithMaxEntrySize larger responses are passed through without being stored.
+func WithMaxEntrySize(n int64) Option {
+	return func(c *Cache) {
+		c.maxEntrySize = n
+	}
+}
+
+// WithOffline serves only from the cache, whatever the age, and never touches the network.
+func WithOffline(offline bool) Option {
+	return func(c *Cache) {
+		c.offline = offline
+	}
+}
+
+// New caches the responses of next in dir, a nil next is the default transport.
+func New(dir string, next http.RoundTripper, opts ...Option) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create cache dir: %w", err)
+	}
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	c := &Cache{
+		dir:  dir,
+		next: next,
+		now:  time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// entry a stored response with the request headers it varies on.
+type entry struct {
+	URL        string            `json:"url"`
+	Method     string            `json:"method"`
+	StatusCode int               `json:"status"`
+	Header     http.Header       `json:"header"`
+	Body       []byte            `json:"body"`
+	Vary       map[string]string `json:"vary,omitempty"`
+	StoredAt   time.Time         `json:"storedAt"`
+}
+
+func (c *Cache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !cacheableRequest(req) {
+		if c.offline {
+			return nil, fmt.Errorf("%w: %s %s", entities.ErrNotCached, req.Method, req.URL.Redacted())
+		}
+
+		return c.next.RoundTrip(req)
+	}
+
+	cached := c.load(req)
+
+	if c.offline {
+		if cached == nil {
+			return nil, fmt.Errorf("%w: %s %s", entities.ErrNotCached, req.Method, req.URL.Redacted())
+		}
+
+		return cached.response(req, Hit), nil
+	}
+
+	if cached != nil && c.fresh(cached) {
+		return cached.response(req, Hit), nil
+	}
+
+	outgoing := req
+
+	if cached != nil {
+		outgoing = conditional(req, cached)
+	}
+
+	resp, err := c.next.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		_ = resp.Body.Close()
+
+		// the 304 carries the updated metadata of the stored response
+		for name, values := range resp.Header {
+			cached.Header[name] = values
+		}
+
+		cached.StoredAt = c.now()
+		c.store(cached)
+
+		return cached.response(req, Revalidated), nil
+	}
+
+	return c.save(req, resp)
+}
+
+func (c *Cache) CloseIdleConnections() {
+	if ci, ok := c.next.(interface{ CloseIdleConnections() }); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
+// cacheableRequest a shared cache must not reuse authorized responses, and this one is shared
+// between the requests of a run, so requests with credentials aren't cached. Besides the standard
+// headers, any credentials the credential transport added, custom headers included, count.
+func cacheableRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+
+	if entities.CredentialsSent(req.Context()) {
+		return false
+	}
+
+	if req.Header.Get("Authorization") != "" || req.Header.Get("Cookie") != "" {
+		return false
+	}
+
+	return !hasDirective(req.Header, "no-store")
+}
+
+func cacheableResponse(resp *http.Response) bool {
+	if !cacheableStatus[resp.StatusCode] {
+		return false
+	}
+
+	if hasDirective(resp.Header, "no-store") || resp.Header.Get("Vary") == "*" {
+		return false
+	}
+
+	// responses setting cookies are personal
+	return len(resp.Header.Values("Set-Cookie")) == 0
+}
+
+// save stores the response when it's cacheable and small enough, the body is read once
+// and handed back to the caller either way.
+func (c *Cache) save(req *http.Request, resp *http.Response) (*http.Response, error) {
+	resp.Header.Set(XCache, Miss)
+
+	if !cacheableResponse(resp) {
+		return resp, nil
+	}
+
+	limit := c.maxEntrySize
+	reader := io.Reader(resp.Body)
+
+	if limit > 0 {
+		reader = io.LimitReader(resp.Body, limit+1)
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		_ = resp.Body.Close()
+
+		return nil, err
+	}
+
+	if limit > 0 && int64(len(body)) > limit {
+		// too big to keep, pass it on with what's already read in front
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+
+		return resp, nil
+	}
+
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	header.Del(XCache)
+
+	e := &entry{
+		URL:        req.URL.String(),
+		Method:     req.Method,
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       body,
+		Vary:       varyValues(req, header),
+		StoredAt:   c.now(),
+	}
+
+	c.store(e)
+
+	return resp, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// fresh the stored response is younger than its lifetime and doesn't have to be revalidated.
+func (c *Cache) fresh(e *entry) bool {
+	if hasDirective(e.Header, "no-cache") {
+		return false
+	}
+
+	age := c.now().Sub(e.StoredAt)
+
+	if seconds, err := strconv.Atoi(e.Header.Get("Age")); err == nil {
+		age += time.Duration(seconds) * time.Second
+	}
+
+	return age < c.lifetime(e)
+}
+
+// lifetime max-age or Expires of the response, the configured max age when it has neither.
+func (c *Cache) lifetime(e *entry) time.Duration {
+	if value, ok := directive(e.Header, "max-age"); ok {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	if expires := e.Header.Get("Expires"); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+
+		date, err := http.ParseTime(e.Header.Get("Date"))
+		if err != nil {
+			date = e.StoredAt
+		}
+
+		return expiresAt.Sub(date)
+	}
+
+	return c.maxAge
+}
+
+// conditional a copy of the request asking only for changes to the stored response.
+func conditional(req *http.Request, e *entry) *http.Request {
+	etag := e.Header.Get("ETag")
+	lastModified := e.Header.Get("Last-Modified")
+
+	if etag == "" && lastModified == "" {
+		return req
+	}
+
+	out := req.Clone(req.Context())
+
+	if etag != "" {
+		out.Header.Set("If-None-Match", etag)
+	}
+
+	if lastModified != "" {
+		out.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	return out
+}
+
+func (e *entry) response(req *http.Request, status string) *http.Response {
+	header := e.Header.Clone()
+	header.Set(XCache, status)
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+func (c *Cache) path(method, url string) string {
+	sum := sha256.Sum256([]byte(method + " " + url))
+	key := hex.EncodeToString(sum[:])
+
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// load the stored response of the request, nil when missing, unreadable or varying.
+func (c *Cache) load(req *http.Request) *entry {
+	data, err := os.ReadFile(c.path(req.Method, req.URL.String()))
+	if err != nil {
+		return nil
+	}
+
+	e := &entry{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil
+	}
+
+	for name, value := range e.Vary {
+		if req.Header.Get(name) != value {
+			return nil
+		}
+	}
+
+	return e
+}
+
+// store writes the entry atomically, a failed write only costs a later refetch.
+func (c *Cache) store(e *entry) {
+	path := c.path(e.Method, e.URL)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return
+	}
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+func varyValues(req *http.Request, header http.Header) map[string]string {
+	var values map[string]string
+
+	for _, field := range header.Values("Vary") {
+		for _, name := range strings.Split(field, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+
+			if values == nil {
+				values = map[string]string{}
+			}
+
+			values[name] = req.Header.Get(name)
+		}
+	}
+
+	return values
+}
+
+// directive the value of a Cache-Control directive, ok when the directive is present.
+func directive(header http.Header, name string) (string, bool) {
+	for _, field := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(field, ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if strings.EqualFold(key, name) {
+				return strings.Trim(value, `"`), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func hasDirective(header http.Header, name string) bool {
+	_, ok := directive(header, name)
+
+	return ok
+}
@@ -0,0 +1,177 @@
+package httpcache
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test fresh hits, revalidation of stale responses and offline mode
+func TestCache(t *testing.T) {
+	var requests, notModified atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		switch r.URL.Path {
+		case "/etag":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+
+				return
+			}
+
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/modified":
+			if r.Header.Get("If-Modified-Since") != "" {
+				notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+
+				return
+			}
+
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		}
+
+		_, _ = w.Write([]byte("page " + r.URL.Path))
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+
+	cache, err := New(t.TempDir(), http.DefaultTransport, WithMaxAge(10*time.Second))
+	assert.NoError(t, err)
+
+	cache.now = func() time.Time { return now }
+	hc := &http.Client{Transport: cache}
+
+	get := func(path string) (string, string) {
+		resp, err := hc.Get(srv.URL + path)
+		assert.NoError(t, err)
+
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		body, _ := io.ReadAll(resp.Body)
+
+		return resp.Header.Get(XCache), string(body)
+	}
+
+	status, body := get("/etag")
+	assert.Equal(t, Miss, status)
+	assert.Equal(t, "page /etag", body)
+
+	status, body = get("/etag")
+	assert.Equal(t, Hit, status)
+	assert.Equal(t, "page /etag", body)
+	assert.Equal(t, int32(1), requests.Load())
+
+	// past the server's max-age the response is revalidated with its etag
+	now = now.Add(2 * time.Minute)
+
+	status, body = get("/etag")
+	assert.Equal(t, Revalidated, status)
+	assert.Equal(t, "page /etag", body)
+	assert.Equal(t, int32(1), notModified.Load())
+
+	// revalidation made it fresh again
+	status, _ = get("/etag")
+	assert.Equal(t, Hit, status)
+
+	// without max-age the configured one applies, then Last-Modified is used
+	get("/modified")
+
+	status, _ = get("/modified")
+	assert.Equal(t, Hit, status)
+
+	now = now.Add(time.Minute)
+
+	status, _ = get("/modified")
+	assert.Equal(t, Revalidated, status)
+	assert.Equal(t, int32(2), notModified.Load())
+
+	get("/no-store")
+
+	status, _ = get("/no-store")
+	assert.Equal(t, Miss, status)
+
+	// offline serves what is stored whatever its age and nothing else
+	before := requests.Load()
+	cache.offline = true
+	now = now.Add(24 * time.Hour)
+
+	status, body = get("/etag")
+	assert.Equal(t, Hit, status)
+	assert.Equal(t, "page /etag", body)
+	assert.Equal(t, before, requests.Load())
+
+	_, err = hc.Get(srv.URL + "/no-store")
+	assert.ErrorIs(t, err, entities.ErrNotCached)
+}
+
+// Test credentialed requests and big responses are passed through without being stored
+func TestCachePassThrough(t *testing.T) {
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+
+		if r.URL.Path == "/big" {
+			_, _ = w.Write(make([]byte, 2048))
+
+			return
+		}
+
+		_, _ = w.Write([]byte("private"))
+	}))
+	defer srv.Close()
+
+	cache, err := New(t.TempDir(), nil, WithMaxEntrySize(1024))
+	assert.NoError(t, err)
+
+	hc := &http.Client{Transport: cache}
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/account", nil)
+		req.SetBasicAuth("user", "pass")
+
+		resp, err := hc.Do(req)
+		assert.NoError(t, err)
+
+		_ = resp.Body.Close()
+
+		// the credential transport marks the requests it added credentials to, custom headers too
+		req, _ = http.NewRequestWithContext(
+			entities.ContextWithCredentialsSent(context.Background()), http.MethodGet, srv.URL+"/api", nil)
+		req.Header.Set("X-Api-Key", "secret")
+
+		resp, err = hc.Do(req)
+		assert.NoError(t, err)
+
+		_ = resp.Body.Close()
+
+		resp, err = hc.Get(srv.URL + "/big")
+		assert.NoError(t, err)
+
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		assert.Len(t, body, 2048)
+		assert.Equal(t, Miss, resp.Header.Get(XCache))
+	}
+
+	assert.Equal(t, int32(6), requests.Load())
+}
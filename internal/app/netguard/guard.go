@@ -0,0 +1,339 @@
+package netguard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// DefaultDenyCIDRs loopback, private, link local (cloud metadata), shared, reserved and multicast
+// ranges, and the NAT64 and 6to4 prefixes that embed an ipv4 address in an ipv6 one.
+var DefaultDenyCIDRs = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+	"2002::/16",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+}
+
+// Guard decides which destinations may be fetched. Addresses are checked when dialing,
+// after the name is resolved, so a host that later resolves somewhere else is still caught.
+type Guard struct {
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	schemes map[string]bool
+	ports   map[int]bool
+	// proxies "host:port" of the proxies, dialed without the address check
+	proxies map[string]bool
+	mu      sync.RWMutex
+}
+
+type Option func(*Guard)
+
+// WithAllowCIDRs lets the ranges through even when a deny range covers them.
+func WithAllowCIDRs(cidrs []*net.IPNet) Option {
+	return func(g *Guard) {
+		g.allow = append(g.allow, cidrs...)
+	}
+}
+
+// WithDenyCIDRs blocks the ranges on top of the default ones.
+func WithDenyCIDRs(cidrs []*net.IPNet) Option {
+	return func(g *Guard) {
+		g.deny = append(g.deny, cidrs...)
+	}
+}
+
+// WithSchemes only allows the schemes, http and https by default.
+func WithSchemes(schemes []string) Option {
+	return func(g *Guard) {
+		g.schemes = make(map[string]bool, len(schemes))
+
+		for _, s := range schemes {
+			g.schemes[strings.ToLower(s)] = true
+		}
+	}
+}
+
+// WithPorts only allows the ports, an empty list allows any port.
+func WithPorts(ports []int) Option {
+	return func(g *Guard) {
+		g.ports = make(map[int]bool, len(ports))
+
+		for _, p := range ports {
+			g.ports[p] = true
+		}
+	}
+}
+
+// WithProxy trusts the proxy's own address, which often is an internal one. The proxies the
+// client's transport picks, the ones of HTTP_PROXY/HTTPS_PROXY included, are trusted as well.
+// The destinations of proxied requests are resolved and checked before they are handed to the
+// proxy, but the proxy resolves them again, so they aren't protected against dns rebinding.
+func WithProxy(proxy *url.URL) Option {
+	return func(g *Guard) {
+		g.trustProxy(proxy)
+	}
+}
+
+// trustProxy lets the proxy be dialed without the address check.
+func (g *Guard) trustProxy(proxy *url.URL) {
+	if proxy == nil {
+		return
+	}
+
+	port := proxy.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}[proxy.Scheme]
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.proxies[net.JoinHostPort(proxy.Hostname(), port)] = true
+}
+
+func (g *Guard) isProxy(address string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.proxies[address]
+}
+
+func New(opts ...Option) *Guard {
+	deny, _ := ParseCIDRs(DefaultDenyCIDRs)
+
+	g := &Guard{
+		deny:    deny,
+		schemes: map[string]bool{"http": true, "https": true},
+		proxies: map[string]bool{},
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// ParseCIDRs parses the ranges, a bare ip is taken as a single address.
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+
+		if ip := net.ParseIP(v); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %q: %w", v, err)
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+// ParsePorts parses the port numbers.
+func ParsePorts(values []string) ([]int, error) {
+	ports := make([]int, 0, len(values))
+
+	for _, v := range values {
+		p, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || p < 1 || p > 65535 {
+			return nil, fmt.Errorf("invalid port %q", v)
+		}
+
+		ports = append(ports, p)
+	}
+
+	return ports, nil
+}
+
+// CheckURL checks the scheme and port of the url, the address is checked when dialing.
+func (g *Guard) CheckURL(u *url.URL) error {
+	scheme := strings.ToLower(u.Scheme)
+	if !g.schemes[scheme] {
+		return fmt.Errorf("%w: scheme %q", entities.ErrDestinationBlocked, u.Scheme)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[scheme]
+	}
+
+	return g.checkPort(port)
+}
+
+// CheckAddress checks a resolved "ip:port" address.
+func (g *Guard) CheckAddress(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", entities.ErrDestinationBlocked, err)
+	}
+
+	if err := g.checkPort(port); err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: unresolved address %q", entities.ErrDestinationBlocked, host)
+	}
+
+	return g.checkIP(ip)
+}
+
+// checkHost resolves the host and checks every address, for requests the guard doesn't dial itself.
+func (g *Guard) checkHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		return g.checkIP(ip)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+
+	for _, addr := range addrs {
+		if err := g.checkIP(addr.IP); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *Guard) checkPort(port string) error {
+	if len(g.ports) == 0 {
+		return nil
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil || !g.ports[p] {
+		return fmt.Errorf("%w: port %s", entities.ErrDestinationBlocked, port)
+	}
+
+	return nil
+}
+
+func (g *Guard) checkIP(ip net.IP) error {
+	for _, n := range g.allow {
+		if n.Contains(ip) {
+			return nil
+		}
+	}
+
+	for _, n := range g.deny {
+		if n.Contains(ip) {
+			return fmt.Errorf("%w: address %s", entities.ErrDestinationBlocked, ip)
+		}
+	}
+
+	return nil
+}
+
+// Client returns a copy of the client whose requests, redirects included, go through the guard.
+func (g *Guard) Client(hc *http.Client) *http.Client {
+	var transport *http.Transport
+
+	if t, ok := hc.Transport.(*http.Transport); ok {
+		transport = t.Clone()
+	} else {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			return g.CheckAddress(address)
+		},
+	}
+
+	direct := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if g.isProxy(address) {
+			return direct.DialContext(ctx, network, address)
+		}
+
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	client := *hc
+	client.Transport = &guardedTransport{guard: g, next: transport, proxy: transport.Proxy}
+
+	return &client
+}
+
+// guardedTransport rejects disallowed schemes and ports before dialing.
+type guardedTransport struct {
+	guard *Guard
+	next  http.RoundTripper
+	proxy func(*http.Request) (*url.URL, error)
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.guard.CheckURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	// the proxy dials the destination, so it has to be checked here. The proxy resolves the
+	// name itself, a rebinding dns answer between the two lookups isn't caught.
+	if t.proxy != nil {
+		if proxy, err := t.proxy(req); err == nil && proxy != nil {
+			if err := t.guard.checkHost(req.Context(), req.URL.Hostname()); err != nil {
+				return nil, err
+			}
+
+			// the transport's own choice, from the environment too, is dialed as a proxy
+			t.guard.trustProxy(proxy)
+		}
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+func (t *guardedTransport) CloseIdleConnections() {
+	if c, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
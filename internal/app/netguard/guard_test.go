@@ -0,0 +1,150 @@
+package netguard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test resolved addresses against the default and configured ranges
+func TestCheckAddress(t *testing.T) {
+	allow, err := ParseCIDRs([]string{"10.1.0.0/16"})
+	assert.NoError(t, err)
+
+	deny, err := ParseCIDRs([]string{"203.0.113.7"})
+	assert.NoError(t, err)
+
+	g := New(WithAllowCIDRs(allow), WithDenyCIDRs(deny), WithPorts([]int{80, 443}))
+
+	tests := []struct {
+		name    string
+		address string
+		blocked bool
+	}{
+		{name: "Public", address: "93.184.215.14:443"},
+		{name: "Loopback", address: "127.0.0.1:80", blocked: true},
+		{name: "Metadata", address: "169.254.169.254:80", blocked: true},
+		{name: "Private", address: "192.168.1.10:443", blocked: true},
+		{name: "IPv4 mapped private", address: "[::ffff:10.0.0.1]:443", blocked: true},
+		{name: "IPv6 loopback", address: "[::1]:443", blocked: true},
+		{name: "IPv6 unique local", address: "[fd00::1]:443", blocked: true},
+		{name: "NAT64 loopback", address: "[64:ff9b::7f00:1]:443", blocked: true},
+		{name: "6to4 metadata", address: "[2002:a9fe:a9fe::1]:80", blocked: true},
+		{name: "Allowed private range", address: "10.1.2.3:443"},
+		{name: "Denied public address", address: "203.0.113.7:443", blocked: true},
+		{name: "Port not allowed", address: "93.184.215.14:22", blocked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := g.CheckAddress(tt.address)
+			if tt.blocked {
+				assert.ErrorIs(t, err, entities.ErrDestinationBlocked)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+
+	_, err = ParseCIDRs([]string{"not-a-cidr"})
+	assert.Error(t, err)
+
+	_, err = ParsePorts([]string{"70000"})
+	assert.Error(t, err)
+}
+
+// Test the schemes and default ports of urls
+func TestCheckURL(t *testing.T) {
+	g := New(WithPorts([]int{443}))
+
+	for _, raw := range []string{"https://example.com", "https://example.com:443/page"} {
+		u, _ := url.Parse(raw)
+		assert.NoError(t, g.CheckURL(u), raw)
+	}
+
+	for _, raw := range []string{"http://example.com", "https://example.com:8443", "file:///etc/passwd", "gopher://example.com"} {
+		u, _ := url.Parse(raw)
+		assert.ErrorIs(t, g.CheckURL(u), entities.ErrDestinationBlocked, raw)
+	}
+}
+
+// Test the guarded client checks the dialed address, redirects included
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	// the test server listens on loopback, which is denied by default
+	hc := New().Client(&http.Client{})
+
+	_, err := hc.Get(srv.URL)
+	assert.ErrorIs(t, err, entities.ErrDestinationBlocked)
+
+	// a hostname resolving to loopback is caught at dial time
+	u, _ := url.Parse(srv.URL)
+	_, err = hc.Get("http://localhost:" + u.Port())
+	assert.ErrorIs(t, err, entities.ErrDestinationBlocked)
+
+	allow, _ := ParseCIDRs([]string{"127.0.0.0/8", "::1"})
+	hc = New(WithAllowCIDRs(allow)).Client(&http.Client{})
+
+	resp, err := hc.Get(srv.URL)
+	assert.NoError(t, err)
+
+	_ = resp.Body.Close()
+}
+
+// Test a trusted proxy on an internal address can be used, while destinations are still checked
+func TestClientWithProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	hc := New(WithProxy(proxyURL)).Client(&http.Client{
+		Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)},
+	})
+
+	resp, err := hc.Get("http://93.184.215.14/page")
+	assert.NoError(t, err)
+
+	_ = resp.Body.Close()
+
+	_, err = hc.Get("http://169.254.169.254/latest/meta-data")
+	assert.ErrorIs(t, err, entities.ErrDestinationBlocked)
+}
+
+// Test the proxy the transport picks by itself, as one from the environment, is trusted too
+func TestClientWithTransportProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	hc := New().Client(&http.Client{
+		Transport: &http.Transport{Proxy: func(*http.Request) (*url.URL, error) { return proxyURL, nil }},
+	})
+
+	resp, err := hc.Get("http://93.184.215.14/page")
+	assert.NoError(t, err)
+
+	_ = resp.Body.Close()
+
+	_, err = hc.Get("http://169.254.169.254/latest/meta-data")
+	assert.ErrorIs(t, err, entities.ErrDestinationBlocked)
+
+	// without a proxy the loopback address of the proxy server stays blocked
+	_, err = New().Client(&http.Client{}).Get(proxy.URL)
+	assert.ErrorIs(t, err, entities.ErrDestinationBlocked)
+}
@@ -0,0 +1,240 @@
+package robots
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// Checker fetches the robots.txt of the hosts a run visits, caches it and applies it for the
+// user agent of the run.
+type Checker struct {
+	hc        *http.Client
+	userAgent string
+	enabled   bool
+	maxAge    time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*host
+}
+
+// host the robots.txt of a scheme and host, and when it may be fetched next.
+type host struct {
+	// ready is closed once the robots.txt is fetched
+	ready   chan struct{}
+	robots  *Robots
+	fetched time.Time
+	next    time.Time
+}
+
+type Option func(*Checker)
+
+// WithEnabled whether robots.txt is honored when the context doesn't say, off by default.
+func WithEnabled(enabled bool) Option {
+	return func(c *Checker) {
+		c.enabled = enabled
+	}
+}
+
+// WithMaxAge how long a fetched robots.txt is used before it is fetched again.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(c *Checker) {
+		if maxAge > 0 {
+			c.maxAge = maxAge
+		}
+	}
+}
+
+func New(hc *http.Client, userAgent string, opts ...Option) *Checker {
+	c := &Checker{
+		hc:        hc,
+		userAgent: userAgent,
+		maxAge:    constants.RobotsMaxAge,
+		now:       time.Now,
+		hosts:     map[string]*host{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Enabled whether robots.txt is honored for the run of ctx.
+func (c *Checker) Enabled(ctx context.Context) bool {
+	if c == nil {
+		return false
+	}
+
+	if enabled, ok := entities.RobotsFromContext(ctx); ok {
+		return enabled
+	}
+
+	return c.enabled
+}
+
+// Allowed whether robots.txt allows the url to be fetched. Urls that aren't http are allowed.
+func (c *Checker) Allowed(ctx context.Context, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return true
+	}
+
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	return c.robots(ctx, u).Allowed(c.userAgent, path)
+}
+
+// Wait waits for the Crawl-delay of the url's host since the last fetch Wait let through to it.
+func (c *Checker) Wait(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil
+	}
+
+	delay := c.robots(ctx, u).CrawlDelay(c.userAgent)
+	if delay <= 0 {
+		return nil
+	}
+
+	// reserve the next slot, concurrent fetches of a host are spread out by the delay
+	c.mu.Lock()
+
+	h, ok := c.hosts[origin(u)]
+	if !ok {
+		// the fetch of the robots.txt was cut off
+		c.mu.Unlock()
+
+		return ctx.Err()
+	}
+
+	now := c.now()
+
+	at := h.next
+	if at.Before(now) {
+		at = now
+	}
+
+	h.next = at.Add(delay)
+	c.mu.Unlock()
+
+	timer := time.NewTimer(at.Sub(now))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Robots the robots.txt of the url's host, e.g. for its sitemaps.
+func (c *Checker) Robots(ctx context.Context, rawURL string) (*Robots, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("not an http url: %q", rawURL)
+	}
+
+	return c.robots(ctx, u), nil
+}
+
+// robots the cached robots.txt of the url's host, fetched once by concurrent callers.
+func (c *Checker) robots(ctx context.Context, u *url.URL) *Robots {
+	key := origin(u)
+
+	c.mu.Lock()
+
+	h, ok := c.hosts[key]
+	if ok && h.robots != nil && c.now().Sub(h.fetched) > c.maxAge {
+		// stale, fetched again, the crawl delay carries over
+		h = &host{ready: make(chan struct{}), next: h.next}
+		c.hosts[key] = h
+		ok = false
+	}
+
+	if !ok {
+		if h == nil {
+			h = &host{ready: make(chan struct{})}
+			c.hosts[key] = h
+		}
+
+		c.mu.Unlock()
+
+		robots := c.fetch(ctx, key)
+
+		c.mu.Lock()
+		h.robots = robots
+		h.fetched = c.now()
+
+		// a fetch cut off by the caller's context isn't kept
+		if ctx.Err() != nil && c.hosts[key] == h {
+			delete(c.hosts, key)
+		}
+		c.mu.Unlock()
+
+		close(h.ready)
+
+		return robots
+	}
+
+	c.mu.Unlock()
+
+	<-h.ready
+
+	return h.robots
+}
+
+// fetch the robots.txt of the origin. Without one everything is allowed. When the server fails
+// nothing is, as RFC 9309 asks, but when it can't be reached at all everything is, so the link
+// checks still find the links broken.
+func (c *Checker) fetch(ctx context.Context, origin string) *Robots {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return AllowAll()
+	}
+
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return AllowAll()
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return DisallowAll()
+	case resp.StatusCode >= http.StatusBadRequest:
+		return AllowAll()
+	case resp.StatusCode >= http.StatusMultipleChoices:
+		// redirects the client didn't follow
+		return AllowAll()
+	}
+
+	// rules past the size limit are ignored
+	data, err := io.ReadAll(io.LimitReader(resp.Body, constants.RobotsMaxSize))
+	if err != nil {
+		return DisallowAll()
+	}
+
+	return Parse(data)
+}
+
+// origin the scheme and host of the url, lower case.
+func origin(u *url.URL) string {
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
@@ -0,0 +1,252 @@
+package robots
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Robots the rules of a robots.txt file, as in RFC 9309.
+type Robots struct {
+	groups []group
+	// disallowAll the file couldn't be fetched because the server failed, nothing may be crawled
+	disallowAll bool
+
+	// Sitemaps the urls of the Sitemap lines, which apply to every user agent
+	Sitemaps []string
+}
+
+// group the rules for the user agents of consecutive User-agent lines.
+type group struct {
+	agents   []string
+	rules    []rule
+	delay    time.Duration
+	hasDelay bool
+}
+
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+// AllowAll the rules of a host without a robots.txt.
+func AllowAll() *Robots {
+	return &Robots{}
+}
+
+// DisallowAll the rules of a host whose robots.txt is unreachable.
+func DisallowAll() *Robots {
+	return &Robots{disallowAll: true}
+}
+
+// Parse the rules of a robots.txt. Lines that can't be parsed are ignored, as crawlers do.
+func Parse(data []byte) *Robots {
+	r := &Robots{}
+
+	var (
+		current *group
+		// inRules the current group has rules, a User-agent line starts a new group
+		inRules bool
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || inRules {
+				r.groups = append(r.groups, group{})
+				current = &r.groups[len(r.groups)-1]
+				inRules = false
+			}
+
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+
+			inRules = true
+
+			// an empty disallow allows everything, the same as no rule
+			if value != "" {
+				current.rules = append(current.rules, rule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+
+			inRules = true
+
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+				current.delay = time.Duration(seconds * float64(time.Second))
+				current.hasDelay = true
+			}
+		case "sitemap":
+			if value != "" {
+				r.Sitemaps = append(r.Sitemaps, value)
+			}
+		}
+	}
+
+	return r
+}
+
+// Allowed whether the user agent may fetch the path, with its query. The longest matching rule
+// wins, an allow rule over a disallow rule of the same length.
+func (r *Robots) Allowed(userAgent, path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	// the rules themselves are always allowed
+	if path == "/robots.txt" {
+		return true
+	}
+
+	if r.disallowAll {
+		return false
+	}
+
+	allowed := true
+	longest := -1
+
+	for _, g := range r.groupsFor(userAgent) {
+		for _, rl := range g.rules {
+			if !match(rl.pattern, path) {
+				continue
+			}
+
+			n := len(rl.pattern)
+			if n > longest || (n == longest && rl.allow) {
+				longest = n
+				allowed = rl.allow
+			}
+		}
+	}
+
+	return allowed
+}
+
+// CrawlDelay the time to wait between requests of the user agent, zero when not set.
+func (r *Robots) CrawlDelay(userAgent string) time.Duration {
+	for _, g := range r.groupsFor(userAgent) {
+		if g.hasDelay {
+			return g.delay
+		}
+	}
+
+	return 0
+}
+
+// groupsFor the groups of the product tokens of the user agent, the * groups when there are
+// none. Groups of the same agent are combined.
+func (r *Robots) groupsFor(userAgent string) []group {
+	tokens := ProductTokens(userAgent)
+
+	var matched, any []group
+
+	for _, g := range r.groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				any = append(any, g)
+
+				break
+			}
+
+			if tokens[agent] {
+				matched = append(matched, g)
+
+				break
+			}
+		}
+	}
+
+	if len(matched) > 0 {
+		return matched
+	}
+
+	return any
+}
+
+// productTokenPattern the product/version tokens of a user agent string.
+var productTokenPattern = regexp.MustCompile(`([A-Za-z0-9_-]+)/[0-9]`)
+
+// ProductTokens the lower case names a user agent matches User-agent lines by: the product names
+// of its product/version tokens, but the Mozilla compatibility token, or the first word when it
+// has none.
+func ProductTokens(userAgent string) map[string]bool {
+	tokens := map[string]bool{}
+
+	for _, m := range productTokenPattern.FindAllStringSubmatch(userAgent, -1) {
+		if name := strings.ToLower(m[1]); name != "mozilla" {
+			tokens[name] = true
+		}
+	}
+
+	if len(tokens) == 0 {
+		if fields := strings.FieldsFunc(userAgent, func(r rune) bool {
+			return r == ' ' || r == '/' || r == ';' || r == '(' || r == ')'
+		}); len(fields) > 0 {
+			tokens[strings.ToLower(fields[0])] = true
+		}
+	}
+
+	return tokens
+}
+
+// match whether the pattern matches the start of the path. * matches any characters and a $ at
+// the end anchors the pattern to the end of the path.
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	// the position in the path after the last *, and the pattern position after it
+	star, next := -1, 0
+	p, s := 0, 0
+
+	for s < len(path) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, next = p, s
+			p++
+		case p < len(pattern) && pattern[p] == path[s]:
+			p++
+			s++
+		case p == len(pattern) && !anchored:
+			return true
+		case star >= 0:
+			// let the * take one more character
+			next++
+			p, s = star+1, next
+		default:
+			return false
+		}
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern)
+}
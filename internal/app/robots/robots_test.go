@@ -0,0 +1,175 @@
+package robots
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAgent = "Mozilla/5.0 (compatible; html-analyzer/1.0)"
+
+// Test the groups, wildcards, longest match and crawl delay of a robots.txt
+func TestParse(t *testing.T) {
+	r := Parse([]byte(`# comment
+User-agent: *
+Disallow: /private
+Allow: /private/open
+Disallow: /*.pdf$
+Crawl-delay: 2
+
+User-agent: other-bot
+User-agent: html-analyzer
+Disallow: /admin/
+Allow: /admin/public*
+Disallow: /search?q=
+Crawl-delay: 0.5
+
+Sitemap: https://example.com/sitemap.xml
+`))
+
+	assert.Equal(t, []string{"https://example.com/sitemap.xml"}, r.Sitemaps)
+
+	tests := []struct {
+		agent   string
+		path    string
+		allowed bool
+	}{
+		{"somebot/2.0", "/", true},
+		{"somebot/2.0", "/private/page", false},
+		{"somebot/2.0", "/private/open/page", true},
+		{"somebot/2.0", "/files/report.pdf", false},
+		{"somebot/2.0", "/files/report.pdf?download=1", true},
+		{"somebot/2.0", "/robots.txt", true},
+		// the html-analyzer group replaces the * group
+		{testAgent, "/private/page", true},
+		{testAgent, "/admin/settings", false},
+		{testAgent, "/admin/public/page", true},
+		{testAgent, "/search?q=term", false},
+		{testAgent, "/search", true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, r.Allowed(tt.agent, tt.path), "%s %s", tt.agent, tt.path)
+	}
+
+	assert.Equal(t, 2*time.Second, r.CrawlDelay("somebot/2.0"))
+	assert.Equal(t, 500*time.Millisecond, r.CrawlDelay(testAgent))
+
+	assert.True(t, AllowAll().Allowed(testAgent, "/anything"))
+	assert.False(t, DisallowAll().Allowed(testAgent, "/anything"))
+	assert.True(t, DisallowAll().Allowed(testAgent, "/robots.txt"))
+}
+
+// Test an allow rule wins over a disallow rule of the same length
+func TestAllowedTie(t *testing.T) {
+	r := Parse([]byte("User-agent: *\nDisallow: /page\nAllow: /page\n"))
+
+	assert.True(t, r.Allowed(testAgent, "/page"))
+}
+
+// Test the names user agents are matched by
+func TestProductTokens(t *testing.T) {
+	assert.Equal(t, map[string]bool{"html-analyzer": true}, ProductTokens(testAgent))
+	assert.Equal(t, map[string]bool{"curl": true}, ProductTokens("curl/8.0"))
+	assert.Equal(t, map[string]bool{"crawler": true}, ProductTokens("Crawler"))
+}
+
+// Test the wildcards and end anchor of the patterns
+func TestMatch(t *testing.T) {
+	assert.True(t, match("/a", "/a/b"))
+	assert.True(t, match("/*/b", "/a/b"))
+	assert.True(t, match("/a*c", "/abbbc/d"))
+	assert.True(t, match("/a$", "/a"))
+	assert.False(t, match("/a$", "/a/b"))
+	assert.True(t, match("/*.php$", "/x/y.php"))
+	assert.False(t, match("/*.php$", "/x/y.php5"))
+	assert.False(t, match("/b", "/a/b"))
+}
+
+// Test the checker caches the robots.txt of a host and treats the fetch failures as RFC 9309 asks
+func TestChecker(t *testing.T) {
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	}))
+	defer srv.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	c := New(srv.Client(), testAgent, WithMaxAge(time.Minute))
+	c.now = func() time.Time { return now }
+
+	assert.True(t, c.Allowed(ctx, srv.URL+"/"))
+	assert.False(t, c.Allowed(ctx, srv.URL+"/private/page"))
+	assert.Equal(t, int32(1), requests.Load())
+
+	// stale after the max age
+	now = now.Add(2 * time.Minute)
+
+	assert.False(t, c.Allowed(ctx, srv.URL+"/private"))
+	assert.Equal(t, int32(2), requests.Load())
+
+	assert.False(t, c.Allowed(ctx, failing.URL+"/"))
+	assert.True(t, c.Allowed(ctx, missing.URL+"/private"))
+	assert.True(t, c.Allowed(ctx, "mailto:someone@example.com"))
+
+	// unreachable hosts are allowed, the link checks find them broken
+	assert.True(t, c.Allowed(ctx, "http://127.0.0.1:1/"))
+}
+
+// Test the context turns the checks on and off for a run
+func TestCheckerEnabled(t *testing.T) {
+	var c *Checker
+
+	assert.False(t, c.Enabled(context.Background()))
+
+	c = New(http.DefaultClient, testAgent)
+	assert.False(t, c.Enabled(context.Background()))
+	assert.True(t, c.Enabled(entities.ContextWithRobots(context.Background(), true)))
+
+	c = New(http.DefaultClient, testAgent, WithEnabled(true))
+	assert.True(t, c.Enabled(context.Background()))
+	assert.False(t, c.Enabled(entities.ContextWithRobots(context.Background(), false)))
+}
+
+// Test the crawl delay spreads the fetches of a host out
+func TestCheckerWait(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("User-agent: *\nCrawl-delay: 0.05\n"))
+	}))
+	defer srv.Close()
+
+	c := New(srv.Client(), testAgent)
+	ctx := context.Background()
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, c.Wait(ctx, srv.URL+"/page"))
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	assert.ErrorIs(t, c.Wait(cancelled, srv.URL+"/page"), context.Canceled)
+}
@@ -1,15 +1,16 @@
 package services
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"net/http"
+	"time"
 
 	"go.uber.org/zap"
 
-	"github.com/PuerkitoBio/goquery"
+	"github.com/erainogo/html-analyzer/internal/app/robots"
 	"github.com/erainogo/html-analyzer/internal/core/adapters"
+	"github.com/erainogo/html-analyzer/pkg/constants"
 	"github.com/erainogo/html-analyzer/pkg/entities"
 )
 
@@ -17,6 +18,13 @@ type AnalyzeService struct {
 	logger *zap.SugaredLogger
 	ctx    context.Context
 	hc     *http.Client
+
+	readability bool
+	policy      *entities.Policy
+
+	checkExternalLinks bool
+	userAgent          string
+	robots             *robots.Checker
 }
 
 type AnalyzeServiceOption func(*AnalyzeService)
@@ -27,14 +35,80 @@ func WithLogger(logger *zap.SugaredLogger) AnalyzeServiceOption {
 	}
 }
 
+// WithReadability enables the readability metrics for the main content of the page.
+func WithReadability(enabled bool) AnalyzeServiceOption {
+	return func(u *AnalyzeService) {
+		u.readability = enabled
+	}
+}
+
+// WithPolicy applies the rule overrides and thresholds of the policy and adds a verdict to the result.
+func WithPolicy(policy *entities.Policy) AnalyzeServiceOption {
+	return func(u *AnalyzeService) {
+		u.policy = policy
+	}
+}
+
+// WithExternalLinkChecks disabled, external links aren't requested and count as accessible.
+func WithExternalLinkChecks(enabled bool) AnalyzeServiceOption {
+	return func(u *AnalyzeService) {
+		u.checkExternalLinks = enabled
+	}
+}
+
+// WithUserAgent the user agent of the link checks, the configured one of the page fetches.
+func WithUserAgent(userAgent string) AnalyzeServiceOption {
+	return func(u *AnalyzeService) {
+		if userAgent != "" {
+			u.userAgent = userAgent
+		}
+	}
+}
+
+// WithRobots skips the link checks robots.txt doesn't allow, when the checker is enabled for the
+// run. They are reported as disallowed instead of inaccessible.
+func WithRobots(checker *robots.Checker) AnalyzeServiceOption {
+	return func(u *AnalyzeService) {
+		u.robots = checker
+	}
+}
+
+// WithRecorder records every response of the analysis, link checks included, so the analysis
+// can be replayed from the saved fixture file.
+func WithRecorder(recorder adapters.TransportWrapper) AnalyzeServiceOption {
+	return func(u *AnalyzeService) {
+		u.hc = withTransport(u.hc, recorder.Transport(u.hc.Transport))
+	}
+}
+
+// WithReplay answers the requests of the analysis from recorded responses, never the network.
+// Requests that weren't recorded fail, replay gets no transport to send them to.
+func WithReplay(fixtures adapters.TransportWrapper) AnalyzeServiceOption {
+	return func(u *AnalyzeService) {
+		u.hc = withTransport(u.hc, fixtures.Transport(nil))
+	}
+}
+
+// withTransport a copy of the client, so the caller's client is left as it is.
+func withTransport(hc *http.Client, rt http.RoundTripper) *http.Client {
+	c := *hc
+	c.Transport = rt
+
+	return &c
+}
+
 func NewAnalyzeService(
 	ctx context.Context,
 	hc *http.Client,
 	opts ...AnalyzeServiceOption,
 ) adapters.AnalyzeService {
 	svc := &AnalyzeService{
-		ctx: ctx,
-		hc:  hc,
+		ctx:    ctx,
+		hc:     hc,
+		logger: zap.NewNop().Sugar(),
+
+		checkExternalLinks: true,
+		userAgent:          constants.USERAGENT,
 	}
 
 	for _, opt := range opts {
@@ -44,57 +118,162 @@ func NewAnalyzeService(
 	return svc
 }
 
-func (u *AnalyzeService) Parse(ctx context.Context, htmlBytes []byte, url string) (*entities.AnalysisResult, error) {
+func (u *AnalyzeService) Parse(
+	ctx context.Context,
+	htmlBytes []byte,
+	url string,
+	fetch *entities.FetchMetadata,
+	rules ...entities.ExtractionRule,
+) (*entities.AnalysisResult, error) {
 	select {
 	case <-ctx.Done():
 		u.logger.Info("application context done", ctx.Err())
 
 		return nil, ctx.Err()
 	default:
-		u.logger.Info("analyzer started for url ", url)
+		// the url may carry credentials, the logs get it redacted
+		logURL := entities.RedactURL(url)
+
+		u.logger.Info("analyzer started for url ", logURL)
 
 		if len(htmlBytes) == 0 {
 			return nil, errors.New("empty HTML input")
 		}
+
+		extractors, err := compileExtractionRules(rules)
+		if err != nil {
+			return nil, err
+		}
+
 		// detect HTML version from raw HTML
 		htmlVersion := detectHTMLVersion(htmlBytes)
 
-		// parse document with goquery
-		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(htmlBytes))
+		// parse document with goquery, keeping track of where every element is in the source
+		doc, src, err := parseWithPositions(htmlBytes)
 		if err != nil {
 			return nil, errors.New("failed to parse HTML")
 		}
 
+		u.logger.Info("linting markup for ", logURL)
+		// goquery repairs broken markup, so lint the raw token stream.
+		// every analyzer reports its problems as findings collected in a single list
+		findings := lintHTML(htmlBytes, src)
+
 		// retrieve the title.
 		title := doc.Find("title").Text()
+		findings = append(findings, titleFindings(doc, src)...)
 
-		u.logger.Info("analyzing headings for ", url)
+		u.logger.Info("analyzing headings for ", logURL)
 		// find the heading count
 		headings := findHeadings(doc)
+		outline := outlineHeadings(doc, src)
+		findings = append(findings, headingFindings(outline)...)
 
-		u.logger.Info("analyzing links for ", url)
+		u.logger.Info("analyzing links for ", logURL)
 
-		baseHost := getHost(url)
 		// concurrently checking to improve the look-up
-		linkResult := analyzeLinks(ctx, u.hc, doc, baseHost, u.logger)
+		tlsHosts := newTLSCollector()
+
+		var robotsTxt *robots.Checker
+		if u.robots.Enabled(ctx) {
+			robotsTxt = u.robots
+		}
 
-		u.logger.Info("analyzing login forms for ", url)
+		linkResult := analyzeLinks(
+			ctx, u.hc, doc, src, url, u.checkExternalLinks, u.userAgent, tlsHosts, robotsTxt, u.logger)
+		findings = append(findings, linkFindings(linkResult.Items)...)
+
+		u.logger.Info("analyzing tls for ", logURL)
+		// the page's own connection comes with its fetch, the others with the link checks.
+		// the result gets a copy of the fetch, with the summary of its connection
+		now := time.Now()
+		pageHost := ""
+
+		if fetch != nil {
+			f := *fetch
+			fetch = &f
+		}
+
+		if fetch != nil && fetch.ConnectionState != nil {
+			pageHost = getHost(fetch.FinalURL)
+
+			info, tlsIssues := analyzeTLSHost(pageHost, tlsHost{state: fetch.ConnectionState}, now)
+			fetch.TLS = info
+			findings = append(findings, tlsIssues...)
+		}
+
+		linkTLS, tlsIssues := tlsHosts.analyze(now, pageHost)
+		findings = append(findings, tlsIssues...)
+
+		u.logger.Info("analyzing login forms for ", logURL)
 		// Login form detection
 		// going to use password keyword for the look-up
 		// usually page yields a small number of forms
 		hasLoginForm := detectForm(doc)
+		forms := findForms(doc, src)
+		findings = append(findings, formFindings(forms, url)...)
+
+		u.logger.Info("analyzing accessibility for ", logURL)
+		findings = append(findings, accessibilityFindings(doc, src)...)
+
+		u.logger.Info("fingerprinting ", logURL)
+		// simhashes for near duplicate detection across pages
+		fingerprint := fingerprintPage(doc)
+
+		var readability *entities.ReadabilityAnalysis
+
+		if u.readability {
+			u.logger.Info("analyzing readability for ", logURL)
+
+			readability = analyzeReadability(doc)
+			findings = append(findings, readabilityFindings(readability)...)
+		}
 
 		// consolidate all the results for the response.
-		return &entities.AnalysisResult{
+		result := &entities.AnalysisResult{
 			HTMLVersion: htmlVersion,
 			Title:       title,
 			Headings:    headings,
+			Outline:     outline,
 			Links: entities.LinkAnalysis{
 				Internal:     linkResult.Internal,
 				External:     linkResult.External,
 				Inaccessible: linkResult.Inaccessible,
+				Disallowed:   linkResult.Disallowed,
+				Unchecked:    linkResult.Unchecked,
+				Items:        linkResult.Items,
+				TLS:          linkTLS,
 			},
 			HasLoginForm: hasLoginForm,
-		}, nil
+			Forms:        forms,
+			Fingerprint:  fingerprint,
+			Readability:  readability,
+			Fetch:        fetch,
+		}
+
+		var weights map[string]float64
+
+		if u.policy != nil {
+			u.logger.Info("applying policy for ", logURL)
+
+			weights = u.policy.Weights
+
+			findings = append(findings, thresholdFindings(u.policy.Thresholds, result)...)
+			findings = applyPolicy(u.policy, findings)
+
+			result.Verdict = evaluatePolicy(u.policy, findings)
+		}
+
+		result.Findings = findings
+		result.FindingsSummary = summarizeFindings(findings)
+		result.Score = scorePage(findings, weights)
+
+		if len(extractors) > 0 {
+			u.logger.Info("extracting custom fields for ", logURL)
+
+			result.Extracted = extract(doc, extractors)
+		}
+
+		return result, nil
 	}
 }
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// input types that are labelled by their value or need no label
+var unlabelledInputTypes = setOf("hidden", "submit", "reset", "button", "image")
+
+// accessibilityFindings the basic checks a screen reader user runs into first:
+// page language, image alternatives, labelled form controls and link text.
+func accessibilityFindings(doc *goquery.Document, src *sourceMap) []entities.Finding {
+	findings := []entities.Finding{}
+
+	if html := doc.Find("html").First(); strings.TrimSpace(html.AttrOr("lang", "")) == "" {
+		findings = append(findings, newFinding(constants.RuleHTMLMissingLang, src.locate(html.Get(0)),
+			"<html> has no lang attribute"))
+	}
+
+	doc.Find("img").Each(func(i int, s *goquery.Selection) {
+		if _, ok := s.Attr("alt"); !ok {
+			findings = append(findings, newFinding(constants.RuleImageMissingAlt, src.locate(s.Get(0)),
+				"image %s has no alt attribute", s.AttrOr("src", "")))
+		}
+	})
+
+	doc.Find("input, select, textarea").Each(func(i int, s *goquery.Selection) {
+		if goquery.NodeName(s) == "input" && unlabelledInputTypes[strings.ToLower(s.AttrOr("type", "text"))] {
+			return
+		}
+
+		if !hasLabel(doc, s) {
+			findings = append(findings, newFinding(constants.RuleControlMissingLbl, src.locate(s.Get(0)),
+				"<%s> has no label", goquery.NodeName(s)))
+		}
+	})
+
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		if !hasAccessibleName(s) && s.Find("img[alt]:not([alt=''])").Length() == 0 {
+			findings = append(findings, newFinding(constants.RuleLinkMissingText, src.locate(s.Get(0)),
+				"link %s has no text", s.AttrOr("href", "")))
+		}
+	})
+
+	return findings
+}
+
+func hasLabel(doc *goquery.Document, s *goquery.Selection) bool {
+	if hasAriaName(s) || s.ParentsFiltered("label").Length() > 0 {
+		return true
+	}
+
+	id := s.AttrOr("id", "")
+
+	return id != "" && doc.Find("label").FilterFunction(func(i int, l *goquery.Selection) bool {
+		return l.AttrOr("for", "") == id
+	}).Length() > 0
+}
+
+func hasAccessibleName(s *goquery.Selection) bool {
+	return strings.TrimSpace(s.Text()) != "" || hasAriaName(s)
+}
+
+func hasAriaName(s *goquery.Selection) bool {
+	for _, attr := range []string{"aria-label", "aria-labelledby", "title"} {
+		if strings.TrimSpace(s.AttrOr(attr, "")) != "" {
+			return true
+		}
+	}
+
+	return false
+}
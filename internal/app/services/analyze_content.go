@@ -0,0 +1,106 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// elements that never belong to the readable content of a page
+const boilerplateSelector = "script, style, noscript, template, nav, header, footer, aside, form, iframe, svg"
+
+// block level elements we read text from, each one treated as a paragraph
+const textBlockSelector = "p, li, blockquote, pre, dd, dt, figcaption, td, th, h1, h2, h3, h4, h5, h6"
+
+// extractMainContent finds the part of the page that holds the actual content.
+// it prefers the semantic elements (article, main) and falls back to the
+// container holding the most paragraph text, and finally to the body.
+// returns the selection with the boilerplate stripped and the selector used.
+func extractMainContent(doc *goquery.Document) (*goquery.Selection, string) {
+	if article := largestByText(doc.Find("article")); article != nil {
+		return stripBoilerplate(article), "article"
+	}
+
+	for _, sel := range []string{"main", "[role='main']"} {
+		if main := largestByText(doc.Find(sel)); main != nil {
+			return stripBoilerplate(main), sel
+		}
+	}
+
+	// score containers by the amount of text in their direct paragraphs
+	var (
+		best      *goquery.Selection
+		bestScore int
+	)
+
+	doc.Find("div, section, td").Each(func(i int, s *goquery.Selection) {
+		score := 0
+
+		s.ChildrenFiltered("p").Each(func(i int, p *goquery.Selection) {
+			score += len(strings.TrimSpace(p.Text()))
+		})
+
+		if score > bestScore {
+			best, bestScore = s, score
+		}
+	})
+
+	if best != nil {
+		return stripBoilerplate(best), "paragraph-density"
+	}
+
+	return stripBoilerplate(doc.Find("body")), "body"
+}
+
+// largestByText returns the matched element with the most text, nil if none has text.
+func largestByText(s *goquery.Selection) *goquery.Selection {
+	var (
+		best    *goquery.Selection
+		longest int
+	)
+
+	s.Each(func(i int, el *goquery.Selection) {
+		if l := len(strings.TrimSpace(el.Text())); l > longest {
+			best, longest = el, l
+		}
+	})
+
+	return best
+}
+
+// stripBoilerplate works on a copy so the document stays intact for the other analyzers.
+func stripBoilerplate(s *goquery.Selection) *goquery.Selection {
+	content := s.First().Clone()
+	content.Find(boilerplateSelector).Remove()
+
+	return content
+}
+
+// textBlocks returns the normalized text of each innermost block in the content.
+// falls back to the whole text when the content has no block elements.
+func textBlocks(content *goquery.Selection) []string {
+	var blocks []string
+
+	content.Find(textBlockSelector).Each(func(i int, s *goquery.Selection) {
+		// nested blocks (li > p) are read at the innermost level only
+		if s.Find(textBlockSelector).Length() > 0 {
+			return
+		}
+
+		if text := normalizeSpace(s.Text()); text != "" {
+			blocks = append(blocks, text)
+		}
+	})
+
+	if len(blocks) == 0 {
+		if text := normalizeSpace(content.Text()); text != "" {
+			blocks = append(blocks, text)
+		}
+	}
+
+	return blocks
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+	"golang.org/x/net/html"
+)
+
+// fingerprintPage computes the simhashes for the content and the structure of the page.
+// content uses word shingles of the main text so reordered boilerplate doesn't matter,
+// structure uses the tag path of every element in the body so pages built from the
+// same template end up close to each other regardless of their text.
+func fingerprintPage(doc *goquery.Document) entities.Fingerprint {
+	content, _ := extractMainContent(doc)
+
+	var words []string
+
+	for _, block := range textBlocks(content) {
+		words = append(words, splitWords(strings.ToLower(block))...)
+	}
+
+	var paths []string
+
+	doc.Find("body").Each(func(i int, s *goquery.Selection) {
+		for _, n := range s.Nodes {
+			paths = collectTagPaths(n, "body", paths)
+		}
+	})
+
+	return entities.Fingerprint{
+		Content:   formatHash(simHash(shingles(words, constants.ShingleSize))),
+		Structure: formatHash(simHash(paths)),
+	}
+}
+
+func collectTagPaths(n *html.Node, path string, paths []string) []string {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+
+		p := path + ">" + c.Data
+		paths = collectTagPaths(c, p, append(paths, p))
+	}
+
+	return paths
+}
+
+func shingles(words []string, size int) []string {
+	if len(words) <= size {
+		if len(words) == 0 {
+			return nil
+		}
+
+		return []string{strings.Join(words, " ")}
+	}
+
+	out := make([]string, 0, len(words)-size+1)
+
+	for i := 0; i+size <= len(words); i++ {
+		out = append(out, strings.Join(words[i:i+size], " "))
+	}
+
+	return out
+}
+
+// simHash every feature votes on each bit of the hash with its own 64 bit fnv hash.
+func simHash(features []string) uint64 {
+	if len(features) == 0 {
+		return 0
+	}
+
+	var votes [64]int
+
+	for _, f := range features {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(f))
+		sum := h.Sum64()
+
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				votes[i]++
+			} else {
+				votes[i]--
+			}
+		}
+	}
+
+	var hash uint64
+
+	for i, v := range votes {
+		if v > 0 {
+			hash |= 1 << uint(i)
+		}
+	}
+
+	return hash
+}
+
+func formatHash(h uint64) string {
+	return fmt.Sprintf("%016x", h)
+}
+
+func parseHash(s string) (uint64, bool) {
+	h, err := strconv.ParseUint(s, 16, 64)
+
+	return h, err == nil
+}
+
+// ClusterNearDuplicates groups the pages whose content or structure hashes are within
+// maxDistance bits of each other. only groups with more than one url are returned,
+// pages without any text are never clustered on content.
+func ClusterNearDuplicates(pages []entities.PageFingerprint, maxDistance int) []entities.DuplicateCluster {
+	content := make([]string, len(pages))
+	structure := make([]string, len(pages))
+
+	for i, p := range pages {
+		content[i] = p.Fingerprint.Content
+		structure[i] = p.Fingerprint.Structure
+	}
+
+	var clusters []entities.DuplicateCluster
+
+	clusters = append(clusters, clusterHashes(pages, content, maxDistance, "content")...)
+	clusters = append(clusters, clusterHashes(pages, structure, maxDistance, "structure")...)
+
+	return clusters
+}
+
+func clusterHashes(
+	pages []entities.PageFingerprint,
+	hashes []string,
+	maxDistance int,
+	kind string,
+) []entities.DuplicateCluster {
+	values := make([]uint64, len(hashes))
+	valid := make([]bool, len(hashes))
+
+	for i, s := range hashes {
+		values[i], valid[i] = parseHash(s)
+		// an empty page hashes to zero, that says nothing about duplication
+		valid[i] = valid[i] && values[i] != 0
+	}
+
+	// union find over every pair within the distance
+	parent := make([]int, len(pages))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+
+		return parent[i]
+	}
+
+	for i := range pages {
+		if !valid[i] {
+			continue
+		}
+
+		for j := i + 1; j < len(pages); j++ {
+			if valid[j] && bits.OnesCount64(values[i]^values[j]) <= maxDistance {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := map[int][]string{}
+
+	var order []int
+
+	for i, p := range pages {
+		if !valid[i] {
+			continue
+		}
+
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			order = append(order, root)
+		}
+
+		groups[root] = append(groups[root], p.URL)
+	}
+
+	var clusters []entities.DuplicateCluster
+
+	for _, root := range order {
+		if len(groups[root]) > 1 {
+			clusters = append(clusters, entities.DuplicateCluster{Kind: kind, URLs: groups[root]})
+		}
+	}
+
+	return clusters
+}
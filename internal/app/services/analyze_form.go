@@ -1,6 +1,13 @@
 package services
 
-import "github.com/PuerkitoBio/goquery"
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
 
 func detectForm(doc *goquery.Document) bool {
 	hasLoginForm := false
@@ -17,3 +24,47 @@ func detectForm(doc *goquery.Document) bool {
 
 	return hasLoginForm
 }
+
+// findForms lists every form, a form with a password input is a login form.
+func findForms(doc *goquery.Document, src *sourceMap) []entities.Form {
+	forms := []entities.Form{}
+
+	doc.Find("form").Each(func(i int, s *goquery.Selection) {
+		forms = append(forms, entities.Form{
+			Action:   s.AttrOr("action", ""),
+			Method:   strings.ToLower(s.AttrOr("method", "get")),
+			IsLogin:  s.Find("input[type='password']").Length() > 0,
+			Location: src.locate(s.Get(0)),
+		})
+	})
+
+	return forms
+}
+
+// formFindings reports login forms that are served or submitted over plain http.
+func formFindings(forms []entities.Form, pageURL string) []entities.Finding {
+	findings := []entities.Finding{}
+
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return findings
+	}
+
+	for _, f := range forms {
+		if !f.IsLogin {
+			continue
+		}
+
+		action, err := page.Parse(f.Action)
+		if err != nil {
+			continue
+		}
+
+		if page.Scheme == "http" || action.Scheme == "http" {
+			findings = append(findings, newFinding(constants.RuleInsecureLoginForm, f.Location,
+				"login form is submitted to %s over http", action.String()))
+		}
+	}
+
+	return findings
+}
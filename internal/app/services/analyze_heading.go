@@ -5,6 +5,7 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
 )
 
 func findHeadings(doc *goquery.Document) map[string]int {
@@ -17,3 +18,56 @@ func findHeadings(doc *goquery.Document) map[string]int {
 
 	return headings
 }
+
+// outlineHeadings lists the headings in document order with where they are in the source.
+func outlineHeadings(doc *goquery.Document, src *sourceMap) []entities.Heading {
+	outline := []entities.Heading{}
+
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(i int, s *goquery.Selection) {
+		outline = append(outline, entities.Heading{
+			Level:    int(goquery.NodeName(s)[1] - '0'),
+			Text:     normalizeSpace(s.Text()),
+			Location: src.locate(s.Get(0)),
+		})
+	})
+
+	return outline
+}
+
+// headingFindings checks the outline has a single h1 and never skips a level on the way down.
+func headingFindings(outline []entities.Heading) []entities.Finding {
+	findings := []entities.Finding{}
+
+	h1s := 0
+	prev := 0
+
+	for _, h := range outline {
+		if h.Level == 1 {
+			h1s++
+
+			if h1s > 1 {
+				findings = append(findings, newFinding(constants.RuleMultipleH1, h.Location,
+					"page has more than one h1"))
+			}
+		}
+
+		if prev > 0 && h.Level > prev+1 {
+			findings = append(findings, newFinding(constants.RuleSkippedHeading, h.Location,
+				"h%d follows h%d, skipping a level", h.Level, prev))
+		}
+
+		if h.Text == "" {
+			findings = append(findings, newFinding(constants.RuleEmptyHeading, h.Location,
+				"h%d has no text", h.Level))
+		}
+
+		prev = h.Level
+	}
+
+	if h1s == 0 {
+		findings = append(findings, newFinding(constants.RuleMissingH1, entities.Location{},
+			"page has no h1"))
+	}
+
+	return findings
+}
@@ -4,41 +4,63 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
 
 	"go.uber.org/zap"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/erainogo/html-analyzer/internal/app/robots"
 	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
 )
 
 type LinkStats struct {
 	Internal     int
 	External     int
 	Inaccessible int
+	Disallowed   int
+	Unchecked    int
+	Items        []entities.Link
 }
 
 type linkCheckResult struct {
+	index        int
+	href         string
+	location     entities.Location
 	isInternal   bool
 	isAccessible bool
+	isDisallowed bool
+	isUnchecked  bool
 }
 
 type linkJob struct {
-	href string
+	index    int
+	href     string
+	location entities.Location
 }
 
 // analyzeLinks this is the most time-consuming task in whole request.
 // because it's Network I/O Bound: each HEAD request goes out to the internet
 // so we can use worker pool concurrency pattern to check the status of the links
-// concurrent execution, we can improve the performance of the request
+// concurrent execution, we can improve the performance of the request.
+// with robotsTxt the links its robots.txt doesn't allow aren't requested.
 func analyzeLinks(
 	ctx context.Context,
 	hc *http.Client,
 	doc *goquery.Document,
-	baseHost string,
+	src *sourceMap,
+	pageURL string,
+	checkExternal bool,
+	userAgent string,
+	tlsHosts *tlsCollector,
+	robotsTxt *robots.Checker,
 	logger *zap.SugaredLogger,
 ) LinkStats {
+	baseHost := getHost(pageURL)
+	base := linkBase(pageURL)
+
 	jobs := make(chan linkJob)
 	results := make(chan linkCheckResult)
 
@@ -65,23 +87,41 @@ func analyzeLinks(
 
 					href := job.href
 
-					isFullURL := strings.HasPrefix(href, "http")
-
-					accessible := false
-					if isFullURL {
-						accessible = isLinkAccessible(ctx, href, hc)
-					}
-
 					// exclude non navigational links early.
 					if filterNonNavigationalLinks(href) {
 						continue
 					}
 
+					// relative links are checked where they point from the page
+					target := resolveLink(base, href)
+
+					isFullURL := strings.HasPrefix(target, "http")
 					isInternal := isInternalLink(href, baseHost)
 
+					accessible, disallowed, unchecked := false, false, false
+
+					switch {
+					case base == nil && isRelativeLink(href):
+						// a local page without a base url, there is nowhere to check it
+						unchecked = true
+					case !isFullURL:
+					case !isInternal && !checkExternal:
+						// unchecked external links count as accessible
+						accessible = true
+					case robotsTxt != nil && !robotsTxt.Allowed(ctx, target):
+						disallowed = true
+					default:
+						accessible = isLinkAccessible(ctx, target, hc, userAgent, tlsHosts)
+					}
+
 					result := linkCheckResult{
+						index:        job.index,
+						href:         href,
+						location:     job.location,
 						isInternal:   isInternal,
 						isAccessible: accessible,
+						isDisallowed: disallowed,
+						isUnchecked:  unchecked,
 					}
 
 					select {
@@ -100,7 +140,7 @@ func analyzeLinks(
 
 		doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
 			if href, ok := s.Attr("href"); ok {
-				jobs <- linkJob{href: href}
+				jobs <- linkJob{index: i, href: href, location: src.locate(s.Get(0))}
 			}
 		})
 	}()
@@ -112,34 +152,64 @@ func analyzeLinks(
 	}()
 
 	// Collect results and update stats
-	stats := LinkStats{}
+	stats := LinkStats{Items: []entities.Link{}}
+
+	var checked []linkCheckResult
 
 	for res := range results {
+		checked = append(checked, res)
+
 		if res.isInternal {
 			stats.Internal++
 		} else {
 			stats.External++
 		}
 
-		if !res.isAccessible {
+		switch {
+		case res.isDisallowed:
+			stats.Disallowed++
+		case res.isUnchecked:
+			stats.Unchecked++
+		case !res.isAccessible:
 			stats.Inaccessible++
 		}
 	}
 
+	// workers finish in any order, report the links in document order
+	sort.Slice(checked, func(i, j int) bool {
+		return checked[i].index < checked[j].index
+	})
+
+	for _, res := range checked {
+		stats.Items = append(stats.Items, entities.Link{
+			Href:       res.href,
+			Internal:   res.isInternal,
+			Accessible: res.isAccessible,
+			Disallowed: res.isDisallowed,
+			Unchecked:  res.isUnchecked,
+			Location:   res.location,
+		})
+	}
+
 	return stats
 }
 
-func isLinkAccessible(ctx context.Context, link string, hc *http.Client) bool {
+// isLinkAccessible the tls connections of the checks are passed to tlsHosts.
+func isLinkAccessible(
+	ctx context.Context, link string, hc *http.Client, userAgent string, tlsHosts *tlsCollector,
+) bool {
 	// ctx added to avoid request hanging
 	req, err := http.NewRequestWithContext(ctx, "HEAD", link, nil)
 	if err != nil {
 		return false
 	}
-	// some servers might block or rate limit, lets use the user agent for minimize that
-	req.Header.Set("User-Agent", constants.USERAGENT)
+	// some servers might block or rate limit, lets use the user agent for minimize that.
+	// it is the one robots.txt is checked for too
+	req.Header.Set("User-Agent", userAgent)
 	// asks the server for just the headers, not the entire response body
 	//this is much faster and cheaper
 	resp, err := hc.Do(req)
+	tlsHosts.observe(req, resp, err)
 
 	defer func() {
 		if resp != nil {
@@ -155,6 +225,8 @@ func isLinkAccessible(ctx context.Context, link string, hc *http.Client) bool {
 		req.Method = "GET"
 		// download the whole response using GET
 		resp, err = hc.Do(req)
+		tlsHosts.observe(req, resp, err)
+
 		if err != nil || resp.StatusCode >= constants.UNAUTHORIZEDCODE {
 			return false
 		}
@@ -163,6 +235,37 @@ func isLinkAccessible(ctx context.Context, link string, hc *http.Client) bool {
 	return true
 }
 
+// isRelativeLink whether href has no scheme, so it only means something against a base url.
+func isRelativeLink(href string) bool {
+	parsed, err := url.Parse(href)
+
+	return err == nil && parsed.Scheme == ""
+}
+
+// linkBase the page url relative links resolve against, nil when it isn't an http url.
+func linkBase(pageURL string) *url.URL {
+	base, err := url.Parse(pageURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil
+	}
+
+	return base
+}
+
+// resolveLink the absolute url of href, href itself when there is no base.
+func resolveLink(base *url.URL, href string) string {
+	if base == nil {
+		return href
+	}
+
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return href
+	}
+
+	return base.ResolveReference(ref).String()
+}
+
 func isInternalLink(href, baseHost string) bool {
 	parsed, err := url.Parse(href)
 	if err != nil {
@@ -196,3 +299,18 @@ func filterNonNavigationalLinks(href string) bool {
 		strings.HasPrefix(href, "chrome:") ||
 		strings.HasPrefix(href, "edge:")
 }
+
+// linkFindings reports every link that is counted as inaccessible, not the ones robots.txt
+// didn't allow to check.
+func linkFindings(links []entities.Link) []entities.Finding {
+	findings := []entities.Finding{}
+
+	for _, l := range links {
+		if !l.Accessible && !l.Disallowed && !l.Unchecked {
+			findings = append(findings, newFinding(constants.RuleBrokenLink, l.Location,
+				"link %s is not accessible", l.Href))
+		}
+	}
+
+	return findings
+}
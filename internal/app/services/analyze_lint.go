@@ -0,0 +1,320 @@
+package services
+
+import (
+	"bytes"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// doctype families the obsolete rules are evaluated against
+const (
+	doctypeHTML5        = "HTML5"
+	doctypeStrict       = "strict doctypes"
+	doctypeTransitional = "transitional doctypes"
+)
+
+var voidElements = setOf("area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta",
+	"param", "source", "track", "wbr", "keygen", "basefont", "frame", "isindex")
+
+// elements the parser closes implicitly, leaving them open is not an error
+var optionalEndElements = setOf("html", "head", "body", "p", "li", "dt", "dd", "rb", "rt", "rtc", "rp",
+	"optgroup", "option", "colgroup", "caption", "thead", "tbody", "tfoot", "tr", "td", "th")
+
+// formatting elements are reopened by the parser when they are closed in the wrong order
+var formattingElements = setOf("a", "b", "big", "code", "em", "font", "i", "nobr", "s", "small",
+	"strike", "strong", "tt", "u")
+
+var nonStandardElements = []string{"blink", "marquee", "bgsound", "nobr", "spacer", "multicol",
+	"nextid", "listing", "xmp", "plaintext", "noembed"}
+
+var obsoleteElements = map[string]map[string]bool{
+	doctypeHTML5: setOf(append([]string{"acronym", "applet", "basefont", "big", "center", "dir", "font",
+		"frame", "frameset", "noframes", "isindex", "strike", "tt", "menuitem", "keygen"}, nonStandardElements...)...),
+	doctypeStrict: setOf(append([]string{"applet", "basefont", "center", "dir", "font", "isindex", "menu",
+		"s", "strike", "u", "frame", "frameset", "noframes", "iframe"}, nonStandardElements...)...),
+	doctypeTransitional: setOf(nonStandardElements...),
+}
+
+// obsolete attributes and the elements they are obsolete on, "*" for every element
+var obsoleteAttributes = map[string]map[string][]string{
+	doctypeHTML5: {
+		"align":        {"*"},
+		"bgcolor":      {"*"},
+		"background":   {"*"},
+		"valign":       {"*"},
+		"border":       {"img", "object"},
+		"cellpadding":  {"table"},
+		"cellspacing":  {"table"},
+		"frame":        {"table"},
+		"rules":        {"table"},
+		"summary":      {"table"},
+		"width":        {"table", "td", "th", "col", "colgroup", "hr", "pre"},
+		"height":       {"table", "tr", "td", "th"},
+		"hspace":       {"img", "object"},
+		"vspace":       {"img", "object"},
+		"clear":        {"br"},
+		"nowrap":       {"td", "th"},
+		"link":         {"body"},
+		"vlink":        {"body"},
+		"alink":        {"body"},
+		"text":         {"body"},
+		"charset":      {"a", "link"},
+		"rev":          {"a", "link"},
+		"name":         {"a", "img"},
+		"language":     {"script"},
+		"event":        {"script"},
+		"scope":        {"td"},
+		"axis":         {"td", "th"},
+		"abbr":         {"td"},
+		"longdesc":     {"img", "iframe"},
+		"profile":      {"head"},
+		"version":      {"html"},
+		"scrolling":    {"iframe"},
+		"frameborder":  {"iframe"},
+		"marginheight": {"iframe"},
+		"marginwidth":  {"iframe"},
+		"archive":      {"object"},
+		"classid":      {"object"},
+		"codebase":     {"object"},
+		"codetype":     {"object"},
+		"declare":      {"object"},
+		"standby":      {"object"},
+		"compact":      {"ol", "ul", "dl", "menu", "dir"},
+		"type":         {"li", "ul"},
+		"size":         {"hr"},
+		"noshade":      {"hr"},
+	},
+	doctypeStrict: {
+		"align":      {"*"},
+		"bgcolor":    {"*"},
+		"background": {"body"},
+		"border":     {"img", "object"},
+		"width":      {"td", "th", "hr", "pre"},
+		"height":     {"td", "th"},
+		"hspace":     {"img", "object"},
+		"vspace":     {"img", "object"},
+		"clear":      {"br"},
+		"nowrap":     {"td", "th"},
+		"link":       {"body"},
+		"vlink":      {"body"},
+		"alink":      {"body"},
+		"text":       {"body"},
+		"language":   {"script"},
+		"compact":    {"ol", "ul", "dl", "menu", "dir"},
+		"type":       {"li", "ol", "ul"},
+		"start":      {"ol"},
+		"value":      {"li"},
+		"size":       {"hr"},
+		"noshade":    {"hr"},
+		"target":     {"a", "area", "base", "form", "link"},
+	},
+}
+
+type openElement struct {
+	tag    string
+	offset int
+}
+
+// linter keeps the state of a single lint pass over the token stream.
+type linter struct {
+	src     *sourceMap
+	doctype string
+	issues  []entities.Finding
+	stack   []openElement
+	ids     map[string]int // id -> offset of its first use
+	// formatting elements closed early by a misnested end tag, their own end tag is expected later
+	reopened map[string]int
+}
+
+// lintHTML reports the markup problems the parser silently repairs.
+// it runs over the same tokenizer as detectHTMLVersion and tracks the byte
+// offset of every token so each issue points at its line and column, and at
+// the element in the parsed document when the parser kept it.
+func lintHTML(htmlBytes []byte, src *sourceMap) []entities.Finding {
+	l := &linter{
+		src:      src,
+		doctype:  doctypeHTML5,
+		issues:   []entities.Finding{},
+		ids:      map[string]int{},
+		reopened: map[string]int{},
+	}
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(htmlBytes))
+	offset := 0
+
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+
+		// raw has to be read before the token, Token may reuse its buffer
+		start := offset
+		offset += len(tokenizer.Raw())
+
+		token := tokenizer.Token()
+
+		switch tt {
+		case html.DoctypeToken:
+			l.doctype = doctypeFamily(token.Data)
+		case html.StartTagToken:
+			l.startTag(token, start, false)
+		case html.SelfClosingTagToken:
+			l.startTag(token, start, true)
+		case html.EndTagToken:
+			l.endTag(token.Data, start)
+		}
+	}
+
+	for _, el := range l.stack {
+		if !optionalEndElements[el.tag] {
+			l.report(constants.LintUnclosedElement, el.offset, "<%s> is never closed", el.tag)
+		}
+	}
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		if l.issues[i].Line != l.issues[j].Line {
+			return l.issues[i].Line < l.issues[j].Line
+		}
+
+		return l.issues[i].Column < l.issues[j].Column
+	})
+
+	return l.issues
+}
+
+func doctypeFamily(data string) string {
+	data = strings.ToLower(data)
+
+	switch {
+	case !strings.Contains(data, "dtd"):
+		return doctypeHTML5
+	case strings.Contains(data, "strict"):
+		return doctypeStrict
+	default:
+		return doctypeTransitional
+	}
+}
+
+func (l *linter) startTag(token html.Token, offset int, selfClosing bool) {
+	tag := token.Data
+
+	if obsoleteElements[l.doctype][tag] {
+		l.report(constants.LintObsoleteElement, offset, "<%s> is obsolete in %s", tag, l.doctype)
+	}
+
+	if tag == "form" && l.isOpen("form") {
+		l.report(constants.LintNestedForm, offset, "<form> is nested inside another <form>")
+	}
+
+	l.checkAttributes(token, offset)
+
+	if voidElements[tag] || selfClosing {
+		return
+	}
+
+	l.stack = append(l.stack, openElement{tag: tag, offset: offset})
+}
+
+func (l *linter) checkAttributes(token html.Token, offset int) {
+	seen := map[string]bool{}
+
+	for _, attr := range token.Attr {
+		name := attr.Key
+
+		switch {
+		case strings.ContainsAny(name, "\"'<"):
+			l.report(constants.LintInvalidAttribute, offset, "invalid attribute name %q on <%s>", name, token.Data)
+		case seen[name]:
+			l.report(constants.LintInvalidAttribute, offset, "duplicate attribute %q on <%s>", name, token.Data)
+		}
+
+		seen[name] = true
+
+		if elements, ok := obsoleteAttributes[l.doctype][name]; ok &&
+			(elements[0] == "*" || slices.Contains(elements, token.Data)) {
+			l.report(constants.LintObsoleteAttribute, offset,
+				"%s attribute on <%s> is obsolete in %s", name, token.Data, l.doctype)
+		}
+
+		if name != "id" || attr.Namespace != "" {
+			continue
+		}
+
+		if first, ok := l.ids[attr.Val]; ok {
+			line, column := l.src.index.position(first)
+			l.report(constants.LintDuplicateID, offset,
+				"id %q is already used at line %d, column %d", attr.Val, line, column)
+		} else {
+			l.ids[attr.Val] = offset
+		}
+	}
+}
+
+func (l *linter) endTag(tag string, offset int) {
+	idx := -1
+
+	for i := len(l.stack) - 1; i >= 0; i-- {
+		if l.stack[i].tag == tag {
+			idx = i
+
+			break
+		}
+	}
+
+	if idx < 0 {
+		if l.reopened[tag] > 0 {
+			l.reopened[tag]--
+
+			return
+		}
+
+		l.report(constants.LintMisnestedElement, offset, "</%s> has no matching open element", tag)
+
+		return
+	}
+
+	for _, el := range l.stack[idx+1:] {
+		switch {
+		case optionalEndElements[el.tag]:
+		case formattingElements[el.tag]:
+			l.reopened[el.tag]++
+			l.report(constants.LintMisnestedElement, el.offset,
+				"<%s> is still open when </%s> closes <%s>", el.tag, tag, tag)
+		default:
+			l.report(constants.LintUnclosedElement, el.offset, "<%s> is not closed before </%s>", el.tag, tag)
+		}
+	}
+
+	l.stack = l.stack[:idx]
+}
+
+func (l *linter) isOpen(tag string) bool {
+	for _, el := range l.stack {
+		if el.tag == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *linter) report(rule string, offset int, format string, args ...any) {
+	l.issues = append(l.issues, newFinding(rule, l.src.locateOffset(offset), format, args...))
+}
+
+func setOf(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+
+	for _, v := range values {
+		set[v] = true
+	}
+
+	return set
+}
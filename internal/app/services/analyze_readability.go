@@ -0,0 +1,173 @@
+package services
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// sentence terminators followed by whitespace or the end of the text
+var sentenceEnd = regexp.MustCompile(`[.!?]+(\s+|$)`)
+
+// analyzeReadability computes the readability metrics for the main content of the page.
+// paragraphs are taken from the block elements, so a heading never runs into the
+// sentence that follows it.
+func analyzeReadability(doc *goquery.Document) *entities.ReadabilityAnalysis {
+	content, selector := extractMainContent(doc)
+
+	result := &entities.ReadabilityAnalysis{
+		MainContent:    selector,
+		LongSentences:  []entities.TextFinding{},
+		LongParagraphs: []entities.TextFinding{},
+	}
+
+	for _, block := range textBlocks(content) {
+		blockWords := 0
+
+		for _, sentence := range splitSentences(block) {
+			words := splitWords(sentence)
+			if len(words) == 0 {
+				continue
+			}
+
+			result.Sentences++
+			result.Words += len(words)
+			blockWords += len(words)
+
+			for _, w := range words {
+				result.Syllables += countSyllables(w)
+			}
+
+			if len(words) > constants.LongSentenceWords {
+				result.LongSentences = append(result.LongSentences,
+					entities.TextFinding{Text: sentence, Words: len(words)})
+			}
+		}
+
+		if blockWords == 0 {
+			continue
+		}
+
+		result.Paragraphs++
+
+		if blockWords > constants.LongParagraphWords {
+			result.LongParagraphs = append(result.LongParagraphs,
+				entities.TextFinding{Text: block, Words: blockWords})
+		}
+	}
+
+	if result.Words == 0 {
+		return result
+	}
+
+	wordsPerSentence := float64(result.Words) / float64(result.Sentences)
+	syllablesPerWord := float64(result.Syllables) / float64(result.Words)
+
+	result.AverageSentenceLength = round2(wordsPerSentence)
+	result.FleschReadingEase = round2(206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord)
+	result.FleschKincaidGrade = round2(0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59)
+
+	return result
+}
+
+func splitSentences(text string) []string {
+	var sentences []string
+
+	start := 0
+
+	for _, loc := range sentenceEnd.FindAllStringIndex(text, -1) {
+		if s := strings.TrimSpace(text[start:loc[1]]); s != "" {
+			sentences = append(sentences, s)
+		}
+
+		start = loc[1]
+	}
+
+	// trailing text without a terminator still counts as a sentence
+	if s := strings.TrimSpace(text[start:]); s != "" {
+		sentences = append(sentences, s)
+	}
+
+	return sentences
+}
+
+// splitWords keeps words containing at least one letter, numbers and symbols are not words.
+func splitWords(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’'
+	})
+
+	words := fields[:0]
+
+	for _, f := range fields {
+		if strings.IndexFunc(f, unicode.IsLetter) >= 0 {
+			words = append(words, f)
+		}
+	}
+
+	return words
+}
+
+// countSyllables is the usual english heuristic: count vowel groups,
+// drop a silent trailing e, and never go below one.
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+
+	count := 0
+	prevVowel := false
+
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !prevVowel {
+			count++
+		}
+
+		prevVowel = vowel
+	}
+
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+
+	if count == 0 {
+		return 1
+	}
+
+	return count
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// readabilityFindings reports the long sentences and paragraphs, quoting the start of the text.
+func readabilityFindings(r *entities.ReadabilityAnalysis) []entities.Finding {
+	findings := []entities.Finding{}
+
+	for _, s := range r.LongSentences {
+		findings = append(findings, newFinding(constants.RuleLongSentence, entities.Location{},
+			"sentence has %d words: %q", s.Words, excerpt(s.Text)))
+	}
+
+	for _, p := range r.LongParagraphs {
+		findings = append(findings, newFinding(constants.RuleLongParagraph, entities.Location{},
+			"paragraph has %d words: %q", p.Words, excerpt(p.Text)))
+	}
+
+	return findings
+}
+
+func excerpt(text string) string {
+	const length = 60
+
+	if r := []rune(text); len(r) > length {
+		return string(r[:length]) + "…"
+	}
+
+	return text
+}
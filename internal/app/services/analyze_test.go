@@ -2,10 +2,30 @@ package services
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"math/bits"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/erainogo/html-analyzer/internal/app/fetchers"
+	"github.com/erainogo/html-analyzer/internal/app/robots"
+	"github.com/erainogo/html-analyzer/pkg/constants"
 	"github.com/erainogo/html-analyzer/pkg/entities"
 	"github.com/stretchr/testify/assert"
 )
@@ -131,6 +151,873 @@ func TestAnalyzeLoginForm(t *testing.T) {
 	}
 }
 
+// Test for main content extraction
+func TestExtractMainContent(t *testing.T) {
+	tests := []struct {
+		name        string
+		htmlContent string
+		selector    string
+		expected    []string
+	}{
+		{
+			name:        "Article preferred",
+			htmlContent: "<html><body><nav><p>Menu</p></nav><article><h1>Title</h1><p>Body text.</p><aside>Ad</aside></article></body></html>",
+			selector:    "article",
+			expected:    []string{"Title", "Body text."},
+		},
+		{
+			name:        "Paragraph density",
+			htmlContent: "<html><body><div><p>Short.</p></div><div id='c'><p>The longer one.</p><p>Second paragraph.</p></div></body></html>",
+			selector:    "paragraph-density",
+			expected:    []string{"The longer one.", "Second paragraph."},
+		},
+		{
+			name:        "Body fallback",
+			htmlContent: "<html><body>Just text <script>var x;</script></body></html>",
+			selector:    "body",
+			expected:    []string{"Just text"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, _ := goquery.NewDocumentFromReader(strings.NewReader(tt.htmlContent))
+
+			content, selector := extractMainContent(doc)
+
+			assert.Equal(t, tt.selector, selector)
+			assert.Equal(t, tt.expected, textBlocks(content))
+		})
+	}
+}
+
+// Test for readability metrics
+func TestAnalyzeReadability(t *testing.T) {
+	long := strings.Repeat("word ", constants.LongSentenceWords+1) + "end."
+
+	tests := []struct {
+		name           string
+		htmlContent    string
+		words          int
+		sentences      int
+		longSentences  int
+		readingEase    float64
+		gradeLevel     float64
+		avgSentenceLen float64
+	}{
+		{
+			name:           "Simple sentences",
+			htmlContent:    "<html><body><main><p>The cat sat on the mat. The dog ran.</p></main></body></html>",
+			words:          9,
+			sentences:      2,
+			readingEase:    117.67,
+			gradeLevel:     -2.03,
+			avgSentenceLen: 4.5,
+		},
+		{
+			name:           "Long sentence",
+			htmlContent:    "<html><body><main><p>" + long + "</p></main></body></html>",
+			words:          constants.LongSentenceWords + 2,
+			sentences:      1,
+			longSentences:  1,
+			readingEase:    94.83,
+			gradeLevel:     6.74,
+			avgSentenceLen: 27,
+		},
+		{
+			name:        "No content",
+			htmlContent: "<html><body></body></html>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, _ := goquery.NewDocumentFromReader(strings.NewReader(tt.htmlContent))
+
+			result := analyzeReadability(doc)
+
+			assert.Equal(t, tt.words, result.Words)
+			assert.Equal(t, tt.sentences, result.Sentences)
+			assert.Len(t, result.LongSentences, tt.longSentences)
+			assert.Equal(t, tt.readingEase, result.FleschReadingEase)
+			assert.Equal(t, tt.gradeLevel, result.FleschKincaidGrade)
+			assert.Equal(t, tt.avgSentenceLen, result.AverageSentenceLength)
+		})
+	}
+}
+
+// Test for syllable counting
+func TestCountSyllables(t *testing.T) {
+	tests := map[string]int{
+		"cat":         1,
+		"make":        1,
+		"table":       2,
+		"readability": 5,
+		"rhythm":      1,
+	}
+
+	for word, expected := range tests {
+		assert.Equal(t, expected, countSyllables(word), word)
+	}
+}
+
+// Test for near duplicate fingerprints
+func TestFingerprintPage(t *testing.T) {
+	article := "<p>Our new product ships next week with faster sync and a redesigned editor for every team.</p>"
+
+	fingerprint := func(htmlContent string) entities.Fingerprint {
+		doc, _ := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+
+		return fingerprintPage(doc)
+	}
+
+	original := fingerprint("<html><body><div class='a'>" + article + "</div></body></html>")
+	copied := fingerprint("<html><body><section><nav>Menu</nav><div>" + article + "</div></section></body></html>")
+	other := fingerprint("<html><body><div class='a'><p>Completely different words about the weather in the mountains today.</p></div></body></html>")
+
+	distance := func(a, b string) int {
+		x, _ := parseHash(a)
+		y, _ := parseHash(b)
+
+		return bits.OnesCount64(x ^ y)
+	}
+
+	assert.Equal(t, 0, distance(original.Content, copied.Content))
+	assert.Greater(t, distance(original.Content, other.Content), constants.DuplicateMaxDistance)
+	assert.Equal(t, 0, distance(original.Structure, other.Structure))
+	assert.Equal(t, "0000000000000000", fingerprint("<html><body></body></html>").Content)
+}
+
+// Test for clustering near duplicate pages
+func TestClusterNearDuplicates(t *testing.T) {
+	pages := []entities.PageFingerprint{
+		{URL: "a", Fingerprint: entities.Fingerprint{Content: "00000000000000ff", Structure: "f000000000000000"}},
+		{URL: "b", Fingerprint: entities.Fingerprint{Content: "00000000000000fe", Structure: "0f00000000000000"}},
+		{URL: "c", Fingerprint: entities.Fingerprint{Content: "ffff000000000000", Structure: "f000000000000001"}},
+		{URL: "d", Fingerprint: entities.Fingerprint{Content: "0000000000000000", Structure: "00ff000000000000"}},
+		{URL: "e", Fingerprint: entities.Fingerprint{Content: "00000000000000fc", Structure: ""}},
+	}
+
+	clusters := ClusterNearDuplicates(pages, 2)
+
+	assert.Equal(t, []entities.DuplicateCluster{
+		{Kind: "content", URLs: []string{"a", "b", "e"}},
+		{Kind: "structure", URLs: []string{"a", "c"}},
+	}, clusters)
+}
+
+// Test for mapping parsed elements back to the source
+func TestParseWithPositions(t *testing.T) {
+	htmlContent := "<!DOCTYPE html>\n<html>\n<body>\n  <div id=\"main\">\n    <p>one</p><p>two <b>bold</b></p>\n  </div>\n" +
+		"  <form action=\"/login\" method=\"POST\"><input type=\"password\"></form>\n" +
+		"  <table><tr><td>cell</td></tr></table>\n  <div id=\"main\"><p>dup</p></div>\n</body>\n</html>"
+
+	doc, src, err := parseWithPositions([]byte(htmlContent))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		selector string
+		expected entities.Location
+	}{
+		{selector: "#main p:nth-of-type(2)", expected: entities.Location{Line: 5, Column: 15, Selector: "html > body > div:nth-of-type(1) > p:nth-of-type(2)"}},
+		{selector: "form", expected: entities.Location{Line: 7, Column: 3, Selector: "html > body > form"}},
+		{selector: "td", expected: entities.Location{Line: 8, Column: 14, Selector: "html > body > table > tbody > tr > td"}},
+		// implied by the parser, no position of its own
+		{selector: "tbody", expected: entities.Location{Selector: "html > body > table > tbody"}},
+		{selector: "b", expected: entities.Location{Line: 5, Column: 22, Selector: "html > body > div:nth-of-type(1) > p:nth-of-type(2) > b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			assert.Equal(t, tt.expected, src.locate(doc.Find(tt.selector).Get(0)))
+		})
+	}
+
+	// svg elements keep their camel case in the tree, content misplaced in a table is moved
+	// in front of it by the parser
+	doc, src, _ = parseWithPositions([]byte("<svg><linearGradient id=\"g\"></linearGradient>" +
+		"<foreignObject><p>in svg</p></foreignObject></svg>\n" +
+		"<table><tr><td>one</td></tr><p>moved</p><tr><td>two</td></tr></table>\n<p>after</p>"))
+
+	for _, tt := range []struct {
+		selector string
+		index    int
+		line     int
+		column   int
+	}{
+		{selector: "svg > *", index: 0, line: 1, column: 6},
+		{selector: "svg > *", index: 1, line: 1, column: 46},
+		{selector: "p", index: 0, line: 1, column: 61},
+		{selector: "p", index: 1, line: 2, column: 29},
+		{selector: "table", index: 0, line: 2, column: 1},
+		{selector: "td", index: 0, line: 2, column: 12},
+		{selector: "td", index: 1, line: 2, column: 45},
+		{selector: "p", index: 2, line: 3, column: 1},
+	} {
+		loc := src.locate(doc.Find(tt.selector).Get(tt.index))
+		assert.Equal(t, []int{tt.line, tt.column}, []int{loc.Line, loc.Column}, loc.Selector)
+	}
+
+	// a unique id anchors the selector
+	doc, src, _ = parseWithPositions([]byte("<div id=\"app\"><ul><li>a</li><li>b</li></ul></div>"))
+	assert.Equal(t, "#app > ul > li:nth-of-type(2)", src.locate(doc.Find("li").Get(1)).Selector)
+
+	forms := findForms(doc, src)
+	assert.Empty(t, forms)
+}
+
+// Test for html lint rules
+func TestLintHTML(t *testing.T) {
+	tests := []struct {
+		name        string
+		htmlContent string
+		expected    []entities.Finding
+	}{
+		{
+			name:        "Valid markup",
+			htmlContent: "<!DOCTYPE html>\n<html><body><ul><li>one<li>two</ul><p>text<br></body></html>",
+			expected:    []entities.Finding{},
+		},
+		{
+			name:        "Duplicate id",
+			htmlContent: "<div id=\"a\"></div>\n  <span id=\"a\"></span>",
+			expected: []entities.Finding{
+				newFinding(constants.LintDuplicateID, entities.Location{Line: 2, Column: 3, Selector: "html > body > span"}, "id \"a\" is already used at line 1, column 1"),
+			},
+		},
+		{
+			name:        "Unclosed and misnested",
+			htmlContent: "<div><span>text</div>\n<b><i>x</b></i>\n<section>",
+			expected: []entities.Finding{
+				newFinding(constants.LintUnclosedElement, entities.Location{Line: 1, Column: 6, Selector: "html > body > div > span"}, "<span> is not closed before </div>"),
+				newFinding(constants.LintMisnestedElement, entities.Location{Line: 2, Column: 4, Selector: "html > body > b > i"}, "<i> is still open when </b> closes <b>"),
+				newFinding(constants.LintUnclosedElement, entities.Location{Line: 3, Column: 1, Selector: "html > body > section"}, "<section> is never closed"),
+			},
+		},
+		{
+			name:        "Stray end tag",
+			htmlContent: "<div></div></span>",
+			expected: []entities.Finding{
+				newFinding(constants.LintMisnestedElement, entities.Location{Line: 1, Column: 12}, "</span> has no matching open element"),
+			},
+		},
+		{
+			name:        "Nested form and invalid attributes",
+			htmlContent: "<form><form a=1 a=2 b\"=3></form></form>",
+			expected: []entities.Finding{
+				newFinding(constants.LintNestedForm, entities.Location{Line: 1, Column: 7}, "<form> is nested inside another <form>"),
+				newFinding(constants.LintInvalidAttribute, entities.Location{Line: 1, Column: 7}, "duplicate attribute \"a\" on <form>"),
+				newFinding(constants.LintInvalidAttribute, entities.Location{Line: 1, Column: 7}, "invalid attribute name \"b\\\"\" on <form>"),
+			},
+		},
+		{
+			name:        "Obsolete in HTML5",
+			htmlContent: "<!DOCTYPE html><center><table cellpadding=2></table></center>",
+			expected: []entities.Finding{
+				newFinding(constants.LintObsoleteElement, entities.Location{Line: 1, Column: 16, Selector: "html > body > center"}, "<center> is obsolete in HTML5"),
+				newFinding(constants.LintObsoleteAttribute, entities.Location{Line: 1, Column: 24, Selector: "html > body > center > table"}, "cellpadding attribute on <table> is obsolete in HTML5"),
+			},
+		},
+		{
+			name: "Allowed in transitional doctype",
+			htmlContent: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01 Transitional//EN\">" +
+				"<center><table cellpadding=2></table></center>",
+			expected: []entities.Finding{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			htmlBytes := []byte(tt.htmlContent)
+			_, src, _ := parseWithPositions(htmlBytes)
+
+			assert.Equal(t, tt.expected, lintHTML(htmlBytes, src))
+		})
+	}
+}
+
+// Test for the findings of the structural analyzers
+func TestAnalyzerFindings(t *testing.T) {
+	tests := []struct {
+		name        string
+		htmlContent string
+		pageURL     string
+		expected    []string
+	}{
+		{
+			name:        "Clean page",
+			htmlContent: "<html><head><title>Home</title></head><body><h1>Home</h1><h2>About</h2></body></html>",
+			pageURL:     "https://example.com",
+			expected:    nil,
+		},
+		{
+			name:        "Heading problems",
+			htmlContent: "<html><head><title> </title></head><body><h2>Intro</h2><h4></h4><h1>A</h1><h1>B</h1></body></html>",
+			pageURL:     "https://example.com",
+			expected: []string{
+				constants.RuleMissingTitle,
+				constants.RuleSkippedHeading,
+				constants.RuleEmptyHeading,
+				constants.RuleMultipleH1,
+			},
+		},
+		{
+			name:        "Login form over http",
+			htmlContent: "<html><head><title>Login</title></head><body><h1>Login</h1><form action='http://example.com/login'><input type='password'></form></body></html>",
+			pageURL:     "https://example.com",
+			expected:    []string{constants.RuleInsecureLoginForm},
+		},
+		{
+			name:        "Login form on http page",
+			htmlContent: "<html><head><title>Login</title></head><body><h1>Login</h1><form action='/login'><input type='password'></form></body></html>",
+			pageURL:     "http://example.com",
+			expected:    []string{constants.RuleInsecureLoginForm},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, src, _ := parseWithPositions([]byte(tt.htmlContent))
+
+			var findings []entities.Finding
+
+			findings = append(findings, titleFindings(doc, src)...)
+			findings = append(findings, headingFindings(outlineHeadings(doc, src))...)
+			findings = append(findings, formFindings(findForms(doc, src), tt.pageURL)...)
+
+			var ruleIDs []string
+			for _, f := range findings {
+				ruleIDs = append(ruleIDs, f.RuleID)
+			}
+
+			assert.Equal(t, tt.expected, ruleIDs)
+		})
+	}
+}
+
+// Test for accessibility findings
+func TestAccessibilityFindings(t *testing.T) {
+	tests := []struct {
+		name        string
+		htmlContent string
+		expected    []string
+	}{
+		{
+			name: "Accessible page",
+			htmlContent: "<html lang='en'><body><img src='a.png' alt=''><a href='/'>Home</a>" +
+				"<a href='/x'><img src='x.png' alt='X'></a><label>Name <input name='n'></label>" +
+				"<label for='e'>Email</label><input id='e'><input type='submit'><textarea aria-label='Note'></textarea></body></html>",
+			expected: nil,
+		},
+		{
+			name:        "Inaccessible page",
+			htmlContent: "<html><body><img src='a.png'><a href='/'></a><input id='e'><select></select></body></html>",
+			expected: []string{
+				constants.RuleHTMLMissingLang,
+				constants.RuleImageMissingAlt,
+				constants.RuleControlMissingLbl,
+				constants.RuleControlMissingLbl,
+				constants.RuleLinkMissingText,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, src, _ := parseWithPositions([]byte(tt.htmlContent))
+
+			var ruleIDs []string
+			for _, f := range accessibilityFindings(doc, src) {
+				ruleIDs = append(ruleIDs, f.RuleID)
+			}
+
+			assert.Equal(t, tt.expected, ruleIDs)
+		})
+	}
+}
+
+// Test for the page score
+func TestScorePage(t *testing.T) {
+	findings := []entities.Finding{
+		newFinding(constants.RuleBrokenLink, entities.Location{}, "a"),
+		newFinding(constants.RuleBrokenLink, entities.Location{}, "b"),
+		newFinding(constants.RuleMissingH1, entities.Location{}, "c"),
+		newFinding(constants.LintUnclosedElement, entities.Location{}, "not scored"),
+	}
+
+	score := scorePage(findings, nil)
+	assert.Equal(t, entities.Score{
+		Overall: 94,
+		Categories: map[string]int{
+			"links": 80, "headings": 95, "seo": 100, "accessibility": 100, "security": 100,
+		},
+	}, score)
+
+	// only links count
+	score = scorePage(findings, map[string]float64{"headings": 0, "seo": 0, "accessibility": 0, "security": 0})
+	assert.Equal(t, 80, score.Overall)
+
+	// a category never goes below zero
+	for i := 0; i < 20; i++ {
+		findings = append(findings, newFinding(constants.RuleImageMissingAlt, entities.Location{}, "img"))
+	}
+
+	assert.Equal(t, 0, scorePage(findings, nil).Categories["accessibility"])
+}
+
+// Test for custom extraction rules
+func TestExtract(t *testing.T) {
+	htmlContent := "<html><head><meta name='author' content='Jane'></head><body>" +
+		"<span class='price'>USD 12.99</span><div itemprop='sku' content='SKU-1'></div>" +
+		"<ul class='tags'><li><a href='/t/go'>go</a></li><li><a href='/t/html'>html</a></li></ul></body></html>"
+
+	doc, _, _ := parseWithPositions([]byte(htmlContent))
+
+	extractors, err := compileExtractionRules([]entities.ExtractionRule{
+		{Name: "price", CSS: ".price", Regex: `([0-9]+\.[0-9]+)`},
+		{Name: "currency", CSS: ".price", Regex: `[A-Z]{3}`},
+		{Name: "sku", XPath: `//*[@itemprop="sku"]/@content`},
+		{Name: "author", CSS: "meta[name='author']", Attribute: "content"},
+		{Name: "tags", CSS: ".tags a", List: true},
+		{Name: "tagLinks", XPath: "//ul[@class='tags']//a", Attribute: "href", List: true},
+		{Name: "missing", CSS: ".nothing"},
+		{Name: "missingList", CSS: ".nothing", List: true},
+		{Name: "tagCount", XPath: "count(//ul[@class='tags']//a)"},
+		{Name: "firstTag", XPath: "string(//ul[@class='tags']//a/@href)", Regex: `/t/(\w+)`},
+		{Name: "hasAuthor", XPath: "boolean(//meta[@name='author'])"},
+		{Name: "sum", XPath: "1+1", List: true},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"price":       "12.99",
+		"currency":    "USD",
+		"sku":         "SKU-1",
+		"author":      "Jane",
+		"tags":        []string{"go", "html"},
+		"tagLinks":    []string{"/t/go", "/t/html"},
+		"missing":     "",
+		"missingList": []string{},
+		"tagCount":    "2",
+		"firstTag":    "go",
+		"hasAuthor":   "true",
+		"sum":         []string{"2"},
+	}, extract(doc, extractors))
+}
+
+// Test for extraction rule validation
+func TestCompileExtractionRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []entities.ExtractionRule
+	}{
+		{name: "No name", rules: []entities.ExtractionRule{{CSS: "a"}}},
+		{name: "Duplicate name", rules: []entities.ExtractionRule{{Name: "a", CSS: "a"}, {Name: "a", CSS: "b"}}},
+		{name: "No selector", rules: []entities.ExtractionRule{{Name: "a"}}},
+		{name: "Both selectors", rules: []entities.ExtractionRule{{Name: "a", CSS: "a", XPath: "//a"}}},
+		{name: "Invalid css", rules: []entities.ExtractionRule{{Name: "a", CSS: "a[["}}},
+		{name: "Invalid xpath", rules: []entities.ExtractionRule{{Name: "a", XPath: "//a[@"}}},
+		{name: "Invalid regex", rules: []entities.ExtractionRule{{Name: "a", CSS: "a", Regex: "("}}},
+		{name: "Attribute of a scalar xpath", rules: []entities.ExtractionRule{{Name: "a", XPath: "count(//a)", Attribute: "href"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileExtractionRules(tt.rules)
+
+			assert.ErrorIs(t, err, entities.ErrInvalidExtractionRule)
+		})
+	}
+}
+
+// Test for loading extraction rule files
+func TestLoadExtractionRules(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		_ = os.WriteFile(path, []byte(content), 0o600)
+
+		return path
+	}
+
+	rules, err := LoadExtractionRules(write("rules.yaml", "- name: author\n  css: meta[name=author]\n  attribute: content\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.ExtractionRule{{Name: "author", CSS: "meta[name=author]", Attribute: "content"}}, rules)
+
+	rules, err = LoadExtractionRules(write("rules.json", `[{"name": "tags", "css": ".tags a", "list": true}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.ExtractionRule{{Name: "tags", CSS: ".tags a", List: true}}, rules)
+
+	// misspelled keys aren't ignored
+	_, err = LoadExtractionRules(write("typo.yaml", "- name: author\n  css: meta[name=author]\n  attribut: content\n"))
+	assert.ErrorIs(t, err, entities.ErrInvalidExtractionRule)
+
+	_, err = LoadExtractionRules(write("typo.json", `[{"name": "tags", "css": ".tags a", "lists": true}]`))
+	assert.ErrorIs(t, err, entities.ErrInvalidExtractionRule)
+}
+
+// Test for the findings summary
+func TestSummarizeFindings(t *testing.T) {
+	findings := []entities.Finding{
+		newFinding(constants.LintDuplicateID, entities.Location{}, "a"),
+		newFinding(constants.RuleMissingH1, entities.Location{}, "b"),
+		newFinding(constants.RuleLongSentence, entities.Location{}, "c"),
+		newFinding(constants.RuleBrokenLink, entities.Location{}, "d"),
+	}
+
+	assert.Equal(t, entities.FindingsSummary{Errors: 2, Warnings: 1, Info: 1, Total: 4}, summarizeFindings(findings))
+}
+
+// Test for loading policy files
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		_ = os.WriteFile(path, []byte(content), 0o600)
+
+		return path
+	}
+
+	policy, err := LoadPolicy(write("policy.yaml", "rules:\n  missing-h1:\n    severity: error\nthresholds:\n  h1Count: 1\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, entities.SeverityError, policy.FailOn)
+	assert.Equal(t, entities.SeverityError, policy.Rules[constants.RuleMissingH1].Severity)
+	assert.Equal(t, 1, *policy.Thresholds.H1Count)
+
+	policy, err = LoadPolicy(write("policy.json", `{"failOn": "warning", "thresholds": {"titleMaxLength": 60}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, entities.SeverityWarning, policy.FailOn)
+	assert.Equal(t, 60, *policy.Thresholds.TitleMaxLength)
+
+	_, err = LoadPolicy(write("unknown.yaml", "rules:\n  no-such-rule:\n    enabled: false\n"))
+	assert.EqualError(t, err, "invalid policy "+filepath.Join(dir, "unknown.yaml")+": unknown rule \"no-such-rule\"")
+
+	_, err = LoadPolicy(write("severity.yaml", "failOn: fatal\n"))
+	assert.Error(t, err)
+
+	// misspelled keys aren't ignored
+	_, err = LoadPolicy(write("typo.yaml", "thresholds:\n  h1count: 1\n"))
+	assert.ErrorContains(t, err, "h1count")
+
+	_, err = LoadPolicy(write("typo.json", `{"failOn": "warning", "treshholds": {"titleMaxLength": 60}}`))
+	assert.ErrorContains(t, err, "treshholds")
+
+	_, err = LoadPolicy(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+// Test for policy thresholds, overrides and verdict
+func TestPolicyEvaluation(t *testing.T) {
+	one, zero, ten := 1, 0, 10
+	disabled := false
+
+	policy := &entities.Policy{
+		FailOn: entities.SeverityError,
+		Rules: map[string]entities.RulePolicy{
+			constants.RuleMissingH1:    {Enabled: &disabled},
+			constants.RuleEmptyHeading: {Severity: entities.SeverityError},
+		},
+		Thresholds: entities.Thresholds{
+			MaxInaccessibleLinks: &zero,
+			H1Count:              &one,
+			TitleMinLength:       &ten,
+		},
+	}
+
+	result := &entities.AnalysisResult{
+		Title:    "Short",
+		Headings: map[string]int{"h1": 2},
+		Links:    entities.LinkAnalysis{Inaccessible: 1},
+	}
+
+	findings := []entities.Finding{
+		newFinding(constants.RuleMissingH1, entities.Location{}, "page has no h1"),
+		newFinding(constants.RuleEmptyHeading, entities.Location{}, "h2 has no text"),
+		newFinding(constants.RuleLongSentence, entities.Location{}, "long"),
+	}
+
+	findings = append(findings, thresholdFindings(policy.Thresholds, result)...)
+	findings = applyPolicy(policy, findings)
+
+	var ruleIDs []string
+	for _, f := range findings {
+		ruleIDs = append(ruleIDs, f.RuleID+":"+string(f.Severity))
+	}
+
+	assert.Equal(t, []string{
+		"empty-heading:error",
+		"long-sentence:info",
+		"max-inaccessible-links:error",
+		"h1-count:error",
+		"title-length:error",
+	}, ruleIDs)
+
+	assert.Equal(t, &entities.Verdict{
+		Passed:   false,
+		Failures: []string{"empty-heading", "max-inaccessible-links", "h1-count", "title-length"},
+	}, evaluatePolicy(policy, findings))
+
+	assert.Equal(t, &entities.Verdict{Passed: true, Failures: []string{}},
+		evaluatePolicy(policy, findings[1:2]))
+}
+
+// newTestCertificate a certificate for example.com signed by parent, self-signed without one.
+func newTestCertificate(
+	t *testing.T, key crypto.Signer, notAfter time.Time, parent *x509.Certificate, parentKey crypto.Signer,
+) *x509.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "example.com"},
+		DNSNames:              []string{"example.com", "www.example.com"},
+		NotBefore:             notAfter.AddDate(-1, 0, 0),
+		NotAfter:              notAfter,
+		BasicConstraintsValid: true,
+	}
+
+	if parent == nil {
+		parent, parentKey = template, key
+	} else {
+		template.Issuer = parent.Subject
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	return cert
+}
+
+// Test for the certificate and connection findings
+func TestTLSFindings(t *testing.T) {
+	now := time.Now()
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	weakKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+
+	ca := newTestCertificate(t, caKey, now.AddDate(5, 0, 0), nil, nil)
+
+	tests := []struct {
+		name     string
+		host     string
+		cert     *x509.Certificate
+		version  uint16
+		cipher   uint16
+		expected []string
+	}{
+		{
+			name: "Valid", host: "example.com", version: tls.VersionTLS13, cipher: tls.TLS_AES_128_GCM_SHA256,
+			cert: newTestCertificate(t, ecKey, now.AddDate(0, 6, 0), ca, caKey),
+		},
+		{
+			name: "Expiring", host: "www.example.com", version: tls.VersionTLS13, cipher: tls.TLS_AES_128_GCM_SHA256,
+			cert:     newTestCertificate(t, ecKey, now.AddDate(0, 0, 10), ca, caKey),
+			expected: []string{constants.RuleTLSExpiring},
+		},
+		{
+			name: "Expired and self-signed", host: "example.com", version: tls.VersionTLS12,
+			cipher:   tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			cert:     newTestCertificate(t, ecKey, now.AddDate(0, 0, -1), nil, nil),
+			expected: []string{constants.RuleTLSSelfSigned, constants.RuleTLSExpired},
+		},
+		{
+			name: "Hostname mismatch", host: "other.com", version: tls.VersionTLS13, cipher: tls.TLS_AES_128_GCM_SHA256,
+			cert:     newTestCertificate(t, ecKey, now.AddDate(1, 0, 0), ca, caKey),
+			expected: []string{constants.RuleTLSHostnameMismatch},
+		},
+		{
+			name: "Weak configuration", host: "example.com", version: tls.VersionTLS10,
+			cipher:   tls.TLS_RSA_WITH_RC4_128_SHA,
+			cert:     newTestCertificate(t, weakKey, now.AddDate(1, 0, 0), ca, caKey),
+			expected: []string{constants.RuleTLSWeakProtocol, constants.RuleTLSWeakCipher, constants.RuleTLSWeakKey},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &tls.ConnectionState{
+				Version:          tt.version,
+				CipherSuite:      tt.cipher,
+				PeerCertificates: []*x509.Certificate{tt.cert},
+			}
+
+			info, findings := analyzeTLSHost(tt.host, tlsHost{state: state}, now)
+
+			ruleIDs := []string{}
+			for _, f := range findings {
+				ruleIDs = append(ruleIDs, f.RuleID)
+				assert.Equal(t, constants.CategorySecurity, f.Category)
+			}
+
+			assert.ElementsMatch(t, tt.expected, ruleIDs)
+			assert.Equal(t, tt.host, info.Host)
+			assert.Equal(t, []string{"example.com", "www.example.com"}, info.Chain[0].SANs)
+			assert.Equal(t, tls.VersionName(tt.version), info.Version)
+		})
+	}
+}
+
+// Test the tls of the page and its link hosts are reported from the existing connections
+func (suite *AnalyzeTestSuite) TestParseWithTLS() {
+	link := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer link.Close()
+
+	page := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><body><a href="` + link.URL + `/other">other</a></body></html>`))
+	}))
+	defer page.Close()
+
+	ctx := context.Background()
+
+	// the page is fetched trusting its certificate, the links with the default roots
+	resp, err := fetchers.NewHTTPFetcher(page.Client()).Fetch(ctx, page.URL)
+	suite.NoError(err)
+
+	result, err := suite.service.Parse(ctx, resp.Body, resp.URL, resp.Metadata)
+	suite.NoError(err)
+
+	// the result has a copy of the fetch metadata, the caller's is left as is
+	suite.asserts.Equal(resp.Metadata.FinalURL, result.Fetch.FinalURL)
+	suite.asserts.Nil(resp.Metadata.TLS)
+	suite.asserts.NotNil(result.Fetch.TLS)
+	suite.asserts.Equal(strings.TrimPrefix(page.URL, "https://"), result.Fetch.TLS.Host)
+	suite.asserts.Equal("TLS 1.3", result.Fetch.TLS.Version)
+	suite.asserts.True(result.Fetch.TLS.Chain[0].SelfSigned)
+
+	// the link check failed on the certificate, which is still summarized
+	suite.asserts.Equal(1, result.Links.Inaccessible)
+	suite.asserts.Len(result.Links.TLS, 1)
+	suite.asserts.Contains(result.Links.TLS[0].Error, "certificate")
+	suite.asserts.Equal(result.Fetch.TLS.Chain[0].Subject, result.Links.TLS[0].Chain[0].Subject)
+
+	selfSigned := 0
+
+	for _, f := range result.Findings {
+		if f.RuleID == constants.RuleTLSSelfSigned {
+			selfSigned++
+		}
+	}
+
+	// both servers share the self-signed certificate, it is reported once per host
+	suite.asserts.Equal(2, selfSigned)
+}
+
+func (suite *AnalyzeTestSuite) TestParseWithPolicy() {
+	one := 1
+
+	service := NewAnalyzeService(context.Background(), &http.Client{}, WithPolicy(&entities.Policy{
+		FailOn:     entities.SeverityWarning,
+		Thresholds: entities.Thresholds{H1Count: &one},
+	}))
+
+	htmlContent := "<html lang=\"en\"><head><title>Policy</title></head><body><h1>A</h1><h3>B</h3></body></html>"
+
+	result, err := service.Parse(context.Background(), []byte(htmlContent), "http://localhost/", nil)
+
+	suite.NoError(err)
+	suite.asserts.Equal(&entities.Verdict{
+		Passed:   false,
+		Failures: []string{constants.RuleSkippedHeading},
+	}, result.Verdict)
+}
+
+func (suite *AnalyzeTestSuite) TestParseResolvesRelativeLinks() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	htmlContent := `<html><body><a href="/ok">ok</a><a href="missing">missing</a><a href="#top">top</a></body></html>`
+
+	result, err := suite.service.Parse(context.Background(), []byte(htmlContent), srv.URL+"/docs/", nil)
+
+	suite.NoError(err)
+	suite.asserts.Equal(2, result.Links.Internal)
+	suite.asserts.Equal(1, result.Links.Inaccessible)
+	suite.asserts.Len(result.Links.Items, 2)
+	suite.asserts.True(result.Links.Items[0].Accessible)
+	suite.asserts.Equal("missing", result.Links.Items[1].Href)
+	suite.asserts.False(result.Links.Items[1].Accessible)
+}
+
+func (suite *AnalyzeTestSuite) TestParseLeavesRelativeLinksOfLocalPagesUnchecked() {
+	htmlContent := `<html><body><a href="about.html">about</a><a href="ftp://example.com/f">file</a></body></html>`
+
+	result, err := suite.service.Parse(context.Background(), []byte(htmlContent), "docs/index.html", nil)
+
+	suite.NoError(err)
+	suite.asserts.Equal(1, result.Links.Unchecked)
+	suite.asserts.Equal(1, result.Links.Inaccessible)
+	suite.asserts.True(result.Links.Items[0].Unchecked)
+	suite.asserts.False(result.Links.Items[0].Accessible)
+
+	for _, f := range result.Findings {
+		suite.asserts.NotContains(f.Message, "about.html")
+	}
+}
+
+func (suite *AnalyzeTestSuite) TestParseChecksLinksWithUserAgent() {
+	var agents sync.Map
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents.Store(r.Header.Get("User-Agent"), true)
+	}))
+	defer srv.Close()
+
+	service := NewAnalyzeService(context.Background(), srv.Client(), WithUserAgent("html-analyzer/1.0"))
+
+	_, err := service.Parse(context.Background(), []byte(`<html><body><a href="/ok">ok</a></body></html>`), srv.URL+"/", nil)
+
+	suite.NoError(err)
+
+	_, ok := agents.Load("html-analyzer/1.0")
+	suite.asserts.True(ok)
+}
+
+func (suite *AnalyzeTestSuite) TestParseSkipsLinksDisallowedByRobots() {
+	var private atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		case "/private":
+			private.Add(1)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	service := NewAnalyzeService(context.Background(), srv.Client(),
+		WithRobots(robots.New(srv.Client(), "html-analyzer/1.0", robots.WithEnabled(true))))
+
+	htmlContent := `<html><body><a href="/ok">ok</a><a href="/private">private</a></body></html>`
+
+	result, err := service.Parse(context.Background(), []byte(htmlContent), srv.URL+"/", nil)
+
+	suite.NoError(err)
+	suite.asserts.Equal(0, result.Links.Inaccessible)
+	suite.asserts.Equal(1, result.Links.Disallowed)
+	suite.asserts.True(result.Links.Items[1].Disallowed)
+	suite.asserts.Equal(int32(0), private.Load())
+
+	// the request can turn the checks off for its run
+	result, err = service.Parse(entities.ContextWithRobots(context.Background(), false), []byte(htmlContent), srv.URL+"/", nil)
+
+	suite.NoError(err)
+	suite.asserts.Equal(1, result.Links.Inaccessible)
+	suite.asserts.Equal(0, result.Links.Disallowed)
+}
+
 func (suite *AnalyzeTestSuite) TestParseWithUnknowHtmlVersionAndHeaders() {
 	mockResult := entities.AnalysisResult{
 		HTMLVersion: "Unknown",
@@ -143,12 +1030,35 @@ func (suite *AnalyzeTestSuite) TestParseWithUnknowHtmlVersionAndHeaders() {
 			"h5": 0,
 			"h6": 0,
 		},
+		Outline: []entities.Heading{
+			{Level: 1, Text: "Heading 1", Location: entities.Location{Line: 1, Column: 13, Selector: "html > body > h1"}},
+			{Level: 2, Text: "Heading 2", Location: entities.Location{Line: 1, Column: 31, Selector: "html > body > h2"}},
+			{Level: 3, Text: "Heading 3", Location: entities.Location{Line: 1, Column: 49, Selector: "html > body > h3"}},
+		},
 		Links: entities.LinkAnalysis{
 			Internal:     0,
 			External:     0,
 			Inaccessible: 0,
+			Items:        []entities.Link{},
 		},
 		HasLoginForm: false,
+		Forms:        []entities.Form{},
+		Fingerprint: entities.Fingerprint{
+			Content:   "137c50cd67d8e959",
+			Structure: "531e6ea0ce23e0fa",
+		},
+		Findings: []entities.Finding{
+			newFinding(constants.RuleMissingTitle, entities.Location{}, "page has no title"),
+			newFinding(constants.RuleHTMLMissingLang,
+				entities.Location{Line: 1, Column: 1, Selector: "html"}, "<html> has no lang attribute"),
+		},
+		FindingsSummary: entities.FindingsSummary{Errors: 1, Warnings: 1, Total: 2},
+		Score: entities.Score{
+			Overall: 97,
+			Categories: map[string]int{
+				"links": 100, "headings": 100, "seo": 90, "accessibility": 95, "security": 100,
+			},
+		},
 	}
 
 	ctx := context.Background()
@@ -156,7 +1066,7 @@ func (suite *AnalyzeTestSuite) TestParseWithUnknowHtmlVersionAndHeaders() {
 	htmlContent := "<html><body><h1>Heading 1</h1><h2>Heading 2</h2><h3>Heading 3</h3></body></html>"
 	htmlBytes := []byte(htmlContent)
 
-	result, _ := suite.service.Parse(ctx, htmlBytes, "http://localhost/")
+	result, _ := suite.service.Parse(ctx, htmlBytes, "http://localhost/", nil)
 
 	suite.asserts.Equal(&mockResult, result)
 }
@@ -173,12 +1083,56 @@ func (suite *AnalyzeTestSuite) TestParseWithHtmlVersionAndTitle() {
 			"h5": 0,
 			"h6": 0,
 		},
+		Outline: []entities.Heading{},
 		Links: entities.LinkAnalysis{
 			Internal:     2,
 			External:     1,
 			Inaccessible: 2,
+			Items: []entities.Link{
+				{
+					Href:       "https://example.com/internal",
+					Internal:   true,
+					Accessible: false,
+					Location:   entities.Location{Line: 7, Column: 5, Selector: "html > body > a:nth-of-type(1)"},
+				},
+				{
+					Href:       "https://external.com/external",
+					Internal:   false,
+					Accessible: true,
+					Location:   entities.Location{Line: 8, Column: 5, Selector: "html > body > a:nth-of-type(2)"},
+				},
+				{
+					Href:       "https://example.com/broken",
+					Internal:   true,
+					Accessible: false,
+					Location:   entities.Location{Line: 9, Column: 5, Selector: "html > body > a:nth-of-type(3)"},
+				},
+			},
 		},
 		HasLoginForm: false,
+		Forms:        []entities.Form{},
+		Fingerprint: entities.Fingerprint{
+			Content:   "11c3608070ac5752",
+			Structure: "874247c9a37d5fd0",
+		},
+		Findings: []entities.Finding{
+			newFinding(constants.RuleMissingH1, entities.Location{}, "page has no h1"),
+			newFinding(constants.RuleBrokenLink,
+				entities.Location{Line: 7, Column: 5, Selector: "html > body > a:nth-of-type(1)"},
+				"link https://example.com/internal is not accessible"),
+			newFinding(constants.RuleBrokenLink,
+				entities.Location{Line: 9, Column: 5, Selector: "html > body > a:nth-of-type(3)"},
+				"link https://example.com/broken is not accessible"),
+			newFinding(constants.RuleHTMLMissingLang,
+				entities.Location{Line: 2, Column: 1, Selector: "html"}, "<html> has no lang attribute"),
+		},
+		FindingsSummary: entities.FindingsSummary{Errors: 2, Warnings: 2, Total: 4},
+		Score: entities.Score{
+			Overall: 93,
+			Categories: map[string]int{
+				"links": 80, "headings": 95, "seo": 100, "accessibility": 95, "security": 100,
+			},
+		},
 	}
 
 	ctx := context.Background()
@@ -186,15 +1140,43 @@ func (suite *AnalyzeTestSuite) TestParseWithHtmlVersionAndTitle() {
 	htmlContent := "<!DOCTYPE html>\n<html>\n  <head>\n    <title>Test Page</title>\n  </head>\n  <body>\n    <a href=\"https://example.com/internal\">Internal Link</a>\n    <a href=\"https://external.com/external\">External Link</a>\n    <a href=\"https://example.com/broken\">Broken Link</a>\n  </body>\n</html>"
 	htmlBytes := []byte(htmlContent)
 
-	result, _ := suite.service.Parse(ctx, htmlBytes, "https://example.com")
+	// the link checks replay recorded responses, the counts don't depend on the network
+	fixtures, err := fetchers.NewFixtureFetcher(filepath.Join("testdata", "links.json"), 0)
+	suite.asserts.NoError(err)
+
+	service := NewAnalyzeService(ctx, &http.Client{}, WithReplay(fixtures))
+
+	result, _ := service.Parse(ctx, htmlBytes, "https://example.com", nil)
 
 	suite.asserts.Equal(&mockResult, result)
 }
 
+func (suite *AnalyzeTestSuite) TestParseWithExtractionRules() {
+	ctx := context.Background()
+	htmlBytes := []byte("<html><body><h1 class='name'>Widget</h1></body></html>")
+
+	result, err := suite.service.Parse(ctx, htmlBytes, "http://localhost/", nil,
+		entities.ExtractionRule{Name: "name", CSS: ".name"})
+
+	suite.NoError(err)
+	suite.asserts.Equal(map[string]any{"name": "Widget"}, result.Extracted)
+
+	result, err = suite.service.Parse(ctx, htmlBytes, "http://localhost/", nil,
+		entities.ExtractionRule{Name: "name"})
+
+	suite.asserts.Nil(result)
+	suite.asserts.ErrorIs(err, entities.ErrInvalidExtractionRule)
+
+	// the same check, without a page
+	suite.NoError(suite.service.ValidateExtractionRules(entities.ExtractionRule{Name: "name", CSS: ".name"}))
+	suite.asserts.ErrorIs(
+		suite.service.ValidateExtractionRules(entities.ExtractionRule{Name: "name"}), entities.ErrInvalidExtractionRule)
+}
+
 func (suite *AnalyzeTestSuite) TestParseNilHTMLBytes() {
 	ctx := context.Background()
 
-	result, err := suite.service.Parse(ctx, nil, "http://localhost/")
+	result, err := suite.service.Parse(ctx, nil, "http://localhost/", nil)
 
 	suite.Error(err)
 	suite.asserts.Nil(result)
@@ -207,7 +1189,7 @@ func (suite *AnalyzeTestSuite) TestParseContextDone() {
 
 	html := []byte("<html><body><h1>Hello</h1></body></html>")
 
-	result, err := suite.service.Parse(ctx, html, "http://localhost/")
+	result, err := suite.service.Parse(ctx, html, "http://localhost/", nil)
 
 	suite.Error(err)
 	suite.asserts.Nil(result)
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// titleFindings reports a page without a title or with an empty one.
+func titleFindings(doc *goquery.Document, src *sourceMap) []entities.Finding {
+	title := doc.Find("title").First()
+
+	if title.Length() == 0 {
+		return []entities.Finding{
+			newFinding(constants.RuleMissingTitle, entities.Location{}, "page has no title"),
+		}
+	}
+
+	if strings.TrimSpace(title.Text()) == "" {
+		return []entities.Finding{
+			newFinding(constants.RuleMissingTitle, src.locate(title.Get(0)), "page title is empty"),
+		}
+	}
+
+	return []entities.Finding{}
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// smallest keys that aren't weak, in bits
+var minKeySize = map[string]int{
+	"RSA":   2048,
+	"ECDSA": 256,
+}
+
+// signature algorithms that can be forged
+var weakSignatures = map[x509.SignatureAlgorithm]bool{
+	x509.MD2WithRSA:    true,
+	x509.MD5WithRSA:    true,
+	x509.SHA1WithRSA:   true,
+	x509.DSAWithSHA1:   true,
+	x509.ECDSAWithSHA1: true,
+}
+
+// summarizeTLS the connection and chain of a successful handshake with host.
+func summarizeTLS(host string, state *tls.ConnectionState, now time.Time) *entities.TLSInfo {
+	info := &entities.TLSInfo{
+		Host:        host,
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		Chain:       make([]entities.CertificateSummary, 0, len(state.PeerCertificates)),
+	}
+
+	for _, cert := range state.PeerCertificates {
+		info.Chain = append(info.Chain, summarizeCertificate(cert, now))
+	}
+
+	return info
+}
+
+// summarizeTLSError the certificate a failed handshake with host was rejected for.
+func summarizeTLSError(host string, cert *x509.Certificate, err error, now time.Time) *entities.TLSInfo {
+	msg := err.Error()
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		msg = urlErr.Err.Error()
+	}
+
+	return &entities.TLSInfo{
+		Host:  host,
+		Chain: []entities.CertificateSummary{summarizeCertificate(cert, now)},
+		Error: msg,
+	}
+}
+
+// rejectedCertificate the certificate a handshake error is about, nil for other errors.
+func rejectedCertificate(err error) *x509.Certificate {
+	var (
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		authorityErr x509.UnknownAuthorityError
+		verifyErr    *tls.CertificateVerificationError
+	)
+
+	switch {
+	case errors.As(err, &hostnameErr):
+		return hostnameErr.Certificate
+	case errors.As(err, &invalidErr):
+		return invalidErr.Cert
+	case errors.As(err, &authorityErr):
+		return authorityErr.Cert
+	case errors.As(err, &verifyErr) && len(verifyErr.UnverifiedCertificates) > 0:
+		return verifyErr.UnverifiedCertificates[0]
+	}
+
+	return nil
+}
+
+func summarizeCertificate(cert *x509.Certificate, now time.Time) entities.CertificateSummary {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	keyType, keySize := publicKeyInfo(cert.PublicKey)
+
+	return entities.CertificateSummary{
+		Subject:            cert.Subject.String(),
+		SANs:               sans,
+		Issuer:             cert.Issuer.String(),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		DaysRemaining:      int(cert.NotAfter.Sub(now).Hours() / 24),
+		KeyType:            keyType,
+		KeySize:            keySize,
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		SelfSigned:         isSelfSigned(cert),
+	}
+}
+
+func publicKeyInfo(key any) (string, int) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return "RSA", k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	}
+
+	return "unknown", 0
+}
+
+// isSelfSigned the certificate is signed by its own key, CA or not.
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawSubject, cert.RawIssuer) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// tlsFindings the problems of the certificate chain of a host, certs are its parsed certificates.
+func tlsFindings(info *entities.TLSInfo, now time.Time, certs []*x509.Certificate) []entities.Finding {
+	var findings []entities.Finding
+
+	if len(info.Chain) > 0 && info.Chain[0].SelfSigned {
+		findings = append(findings, newFinding(constants.RuleTLSSelfSigned, entities.Location{},
+			"certificate of %s is self-signed", info.Host))
+	}
+
+	if len(certs) > 0 && certs[0].VerifyHostname(hostname(info.Host)) != nil {
+		findings = append(findings, newFinding(constants.RuleTLSHostnameMismatch, entities.Location{},
+			"certificate of %s is not valid for the host name", info.Host))
+	}
+
+	for i, cert := range info.Chain {
+		name := "certificate"
+		if i > 0 {
+			name = "chain certificate " + cert.Subject
+		}
+
+		switch {
+		case now.After(cert.NotAfter):
+			findings = append(findings, newFinding(constants.RuleTLSExpired, entities.Location{},
+				"%s of %s expired on %s", name, info.Host, cert.NotAfter.Format(time.DateOnly)))
+		case cert.DaysRemaining < constants.CertificateExpiryWarningDays:
+			findings = append(findings, newFinding(constants.RuleTLSExpiring, entities.Location{},
+				"%s of %s expires in %d days", name, info.Host, cert.DaysRemaining))
+		}
+
+		if minSize, ok := minKeySize[cert.KeyType]; ok && cert.KeySize < minSize {
+			findings = append(findings, newFinding(constants.RuleTLSWeakKey, entities.Location{},
+				"%s of %s has a %d bit %s key", name, info.Host, cert.KeySize, cert.KeyType))
+		}
+
+		// the signature of a self-signed root isn't checked, it is trusted as is
+		if i < len(certs) && weakSignatures[certs[i].SignatureAlgorithm] && !(i > 0 && cert.SelfSigned) {
+			findings = append(findings, newFinding(constants.RuleTLSWeakSignature, entities.Location{},
+				"%s of %s is signed with %s", name, info.Host, cert.SignatureAlgorithm))
+		}
+	}
+
+	return findings
+}
+
+// connectionFindings the problems of the negotiated protocol and cipher.
+func connectionFindings(host string, state *tls.ConnectionState) []entities.Finding {
+	var findings []entities.Finding
+
+	if state.Version < tls.VersionTLS12 {
+		findings = append(findings, newFinding(constants.RuleTLSWeakProtocol, entities.Location{},
+			"connection to %s uses %s", host, tls.VersionName(state.Version)))
+	}
+
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.ID == state.CipherSuite {
+			findings = append(findings, newFinding(constants.RuleTLSWeakCipher, entities.Location{},
+				"connection to %s uses the insecure cipher %s", host, suite.Name))
+		}
+	}
+
+	return findings
+}
+
+// tlsHost what was seen of one host: a handshake, or the certificate that failed it.
+type tlsHost struct {
+	state    *tls.ConnectionState
+	rejected *x509.Certificate
+	err      error
+}
+
+// tlsCollector collects the tls connections of the link checks, one per host and port, from the
+// responses the checks get anyway.
+type tlsCollector struct {
+	mu    sync.Mutex
+	hosts map[string]tlsHost
+}
+
+func newTLSCollector() *tlsCollector {
+	return &tlsCollector{hosts: map[string]tlsHost{}}
+}
+
+// observe the response or error of a request, the first one of a host wins.
+func (c *tlsCollector) observe(req *http.Request, resp *http.Response, err error) {
+	var h tlsHost
+
+	host := req.URL.Host
+
+	switch {
+	case resp != nil && resp.TLS != nil:
+		// the response of the final url, after redirects
+		host = resp.Request.URL.Host
+		h.state = resp.TLS
+	case err != nil:
+		h.rejected = rejectedCertificate(err)
+		if h.rejected == nil {
+			return
+		}
+
+		h.err = err
+
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			if u, perr := url.Parse(urlErr.URL); perr == nil {
+				host = u.Host
+			}
+		}
+	default:
+		return
+	}
+
+	host = strings.ToLower(host)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.hosts[host]; !ok {
+		c.hosts[host] = h
+	}
+}
+
+// analyze the summaries and findings of the hosts, ordered by host. skip is a host already
+// reported, e.g. the page's own.
+func (c *tlsCollector) analyze(now time.Time, skip string) ([]entities.TLSInfo, []entities.Finding) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	names := make([]string, 0, len(c.hosts))
+	for host := range c.hosts {
+		if host != strings.ToLower(skip) {
+			names = append(names, host)
+		}
+	}
+
+	sort.Strings(names)
+
+	var (
+		infos    []entities.TLSInfo
+		findings []entities.Finding
+	)
+
+	for _, host := range names {
+		info, hostFindings := analyzeTLSHost(host, c.hosts[host], now)
+
+		infos = append(infos, *info)
+		findings = append(findings, hostFindings...)
+	}
+
+	return infos, findings
+}
+
+// analyzeTLSHost the summary and findings of what was seen of a host.
+func analyzeTLSHost(host string, h tlsHost, now time.Time) (*entities.TLSInfo, []entities.Finding) {
+	if h.state != nil {
+		info := summarizeTLS(host, h.state, now)
+		findings := connectionFindings(host, h.state)
+
+		return info, append(findings, tlsFindings(info, now, h.state.PeerCertificates)...)
+	}
+
+	info := summarizeTLSError(host, h.rejected, h.err, now)
+	findings := tlsFindings(info, now, []*x509.Certificate{h.rejected})
+
+	var authorityErr x509.UnknownAuthorityError
+	if errors.As(h.err, &authorityErr) && !info.Chain[0].SelfSigned {
+		findings = append(findings, newFinding(constants.RuleTLSUntrusted, entities.Location{},
+			"certificate of %s is issued by an untrusted authority", host))
+	}
+
+	return info, findings
+}
+
+// hostname the host without its port.
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+
+	return host
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+type extractor struct {
+	rule  entities.ExtractionRule
+	css   cascadia.Selector
+	xpath *xpath.Expr
+	// scalar the xpath returns a number, string or boolean instead of nodes (count(//a))
+	scalar bool
+	regex  *regexp.Regexp
+}
+
+// LoadExtractionRules reads a list of rules from a json or yaml file and validates them.
+func LoadExtractionRules(path string) ([]entities.ExtractionRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read extraction rules: %w", err)
+	}
+
+	var rules []entities.ExtractionRule
+
+	if err := decodeConfigFile(path, data, &rules); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", entities.ErrInvalidExtractionRule, path, err)
+	}
+
+	if _, err := compileExtractionRules(rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// ValidateExtractionRules checks the rules without analyzing a page, so a request with bad rules
+// fails before its page is fetched.
+func (u *AnalyzeService) ValidateExtractionRules(rules ...entities.ExtractionRule) error {
+	_, err := compileExtractionRules(rules)
+
+	return err
+}
+
+// compileExtractionRules checks every rule up front so a bad selector fails the request, not the page.
+func compileExtractionRules(rules []entities.ExtractionRule) ([]extractor, error) {
+	extractors := make([]extractor, 0, len(rules))
+	names := map[string]bool{}
+
+	invalid := func(r entities.ExtractionRule, format string, args ...any) error {
+		return fmt.Errorf("%w %q: %s", entities.ErrInvalidExtractionRule, r.Name, fmt.Sprintf(format, args...))
+	}
+
+	for _, r := range rules {
+		switch {
+		case r.Name == "":
+			return nil, invalid(r, "name is required")
+		case names[r.Name]:
+			return nil, invalid(r, "name is used more than once")
+		case (r.CSS == "") == (r.XPath == ""):
+			return nil, invalid(r, "exactly one of css or xpath is required")
+		}
+
+		names[r.Name] = true
+		e := extractor{rule: r}
+
+		var err error
+
+		if r.CSS != "" {
+			if e.css, err = cascadia.Compile(r.CSS); err != nil {
+				return nil, invalid(r, "css: %v", err)
+			}
+		} else if e.xpath, err = xpath.Compile(r.XPath); err != nil {
+			return nil, invalid(r, "xpath: %v", err)
+		} else {
+			// an empty document tells the node sets from the scalar expressions
+			_, nodes := e.xpath.Evaluate(htmlquery.CreateXPathNavigator(&html.Node{Type: html.DocumentNode})).(*xpath.NodeIterator)
+			e.scalar = !nodes
+		}
+
+		if e.scalar && r.Attribute != "" {
+			return nil, invalid(r, "attribute needs an xpath selecting elements, not a value")
+		}
+
+		if r.Regex != "" {
+			if e.regex, err = regexp.Compile(r.Regex); err != nil {
+				return nil, invalid(r, "regex: %v", err)
+			}
+		}
+
+		extractors = append(extractors, e)
+	}
+
+	return extractors, nil
+}
+
+// extract runs the rules against the document, single rules get a string and list rules a slice.
+func extract(doc *goquery.Document, extractors []extractor) map[string]any {
+	extracted := make(map[string]any, len(extractors))
+
+	for _, e := range extractors {
+		values := []string{}
+
+		if e.scalar {
+			if v, ok := e.refine(e.evaluate(doc)); ok {
+				values = append(values, v)
+			}
+		}
+
+		for _, n := range e.match(doc) {
+			if v, ok := e.value(n); ok {
+				values = append(values, v)
+			}
+		}
+
+		switch {
+		case e.rule.List:
+			extracted[e.rule.Name] = values
+		case len(values) > 0:
+			extracted[e.rule.Name] = values[0]
+		default:
+			extracted[e.rule.Name] = ""
+		}
+	}
+
+	return extracted
+}
+
+func (e extractor) match(doc *goquery.Document) []*html.Node {
+	switch {
+	case e.css != nil:
+		return doc.FindMatcher(e.css).Nodes
+	case e.scalar:
+		return nil
+	}
+
+	return htmlquery.QuerySelectorAll(doc.Get(0), e.xpath)
+}
+
+// evaluate the value of a scalar xpath, numbers without trailing zeros.
+func (e extractor) evaluate(doc *goquery.Document) string {
+	switch v := e.xpath.Evaluate(htmlquery.CreateXPathNavigator(doc.Get(0))).(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return normalizeSpace(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (e extractor) value(n *html.Node) (string, bool) {
+	// xpath attribute selections (//a/@href) come back as nodes holding the value as text
+	v := normalizeSpace(htmlquery.InnerText(n))
+
+	if e.rule.Attribute != "" {
+		var ok bool
+		if v, ok = lookupAttr(n, e.rule.Attribute); !ok {
+			return "", false
+		}
+	}
+
+	return e.refine(v)
+}
+
+// refine narrows the value down to the regex match, its first capture group when it has one.
+func (e extractor) refine(v string) (string, bool) {
+	if e.regex == nil {
+		return v, true
+	}
+
+	m := e.regex.FindStringSubmatch(v)
+
+	switch {
+	case m == nil:
+		return "", false
+	case len(m) > 1:
+		return m[1], true
+	default:
+		return m[0], true
+	}
+}
+
+func lookupAttr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+type rule struct {
+	severity entities.Severity
+	category string
+	helpURL  string
+}
+
+const mdn = "https://developer.mozilla.org/en-US/docs/"
+
+// rules every rule id an analyzer can report, with its default severity.
+var rules = map[string]rule{
+	constants.LintDuplicateID: {
+		entities.SeverityError, constants.CategoryMarkup, mdn + "Web/HTML/Global_attributes/id"},
+	constants.LintUnclosedElement: {
+		entities.SeverityWarning, constants.CategoryMarkup, mdn + "Glossary/Tag"},
+	constants.LintMisnestedElement: {
+		entities.SeverityWarning, constants.CategoryMarkup, mdn + "Learn/HTML/Introduction_to_HTML/Getting_started#nesting_elements"},
+	constants.LintNestedForm: {
+		entities.SeverityError, constants.CategoryMarkup, mdn + "Web/HTML/Element/form"},
+	constants.LintInvalidAttribute: {
+		entities.SeverityError, constants.CategoryMarkup, mdn + "Web/HTML/Attributes"},
+	constants.LintObsoleteElement: {
+		entities.SeverityWarning, constants.CategoryMarkup, mdn + "Web/HTML/Element#obsolete_and_deprecated_elements"},
+	constants.LintObsoleteAttribute: {
+		entities.SeverityWarning, constants.CategoryMarkup, mdn + "Web/HTML/Attributes"},
+	constants.RuleBrokenLink: {
+		entities.SeverityError, constants.CategoryLinks, mdn + "Web/HTTP/Status"},
+	constants.RuleMissingTitle: {
+		entities.SeverityError, constants.CategorySEO, mdn + "Web/HTML/Element/title"},
+	constants.RuleMissingH1: {
+		entities.SeverityWarning, constants.CategoryHeadings, mdn + "Web/HTML/Element/Heading_Elements"},
+	constants.RuleMultipleH1: {
+		entities.SeverityWarning, constants.CategoryHeadings, mdn + "Web/HTML/Element/Heading_Elements#avoid_using_multiple_h1_elements_on_one_page"},
+	constants.RuleSkippedHeading: {
+		entities.SeverityWarning, constants.CategoryHeadings, mdn + "Web/HTML/Element/Heading_Elements#navigation"},
+	constants.RuleEmptyHeading: {
+		entities.SeverityWarning, constants.CategoryHeadings, mdn + "Web/HTML/Element/Heading_Elements"},
+	constants.RuleInsecureLoginForm: {
+		entities.SeverityError, constants.CategorySecurity, mdn + "Web/Security/Insecure_passwords"},
+	constants.RuleLongSentence: {
+		entities.SeverityInfo, constants.CategoryContent, ""},
+	constants.RuleLongParagraph: {
+		entities.SeverityInfo, constants.CategoryContent, ""},
+	constants.RuleImageMissingAlt: {
+		entities.SeverityError, constants.CategoryAccessibility, mdn + "Web/HTML/Element/img#alt"},
+	constants.RuleHTMLMissingLang: {
+		entities.SeverityWarning, constants.CategoryAccessibility, mdn + "Web/HTML/Global_attributes/lang"},
+	constants.RuleControlMissingLbl: {
+		entities.SeverityWarning, constants.CategoryAccessibility, mdn + "Web/HTML/Element/label"},
+	constants.RuleLinkMissingText: {
+		entities.SeverityWarning, constants.CategoryAccessibility, mdn + "Web/HTML/Element/a#accessibility"},
+	constants.RuleTLSExpired: {
+		entities.SeverityError, constants.CategorySecurity, mdn + "Web/Security/Transport_Layer_Security"},
+	constants.RuleTLSExpiring: {
+		entities.SeverityWarning, constants.CategorySecurity, mdn + "Web/Security/Transport_Layer_Security"},
+	constants.RuleTLSSelfSigned: {
+		entities.SeverityError, constants.CategorySecurity, mdn + "Web/Security/Transport_Layer_Security"},
+	constants.RuleTLSUntrusted: {
+		entities.SeverityError, constants.CategorySecurity, mdn + "Web/Security/Transport_Layer_Security"},
+	constants.RuleTLSHostnameMismatch: {
+		entities.SeverityError, constants.CategorySecurity, mdn + "Web/Security/Transport_Layer_Security"},
+	constants.RuleTLSWeakProtocol: {
+		entities.SeverityError, constants.CategorySecurity, mdn + "Web/Security/Transport_Layer_Security"},
+	constants.RuleTLSWeakCipher: {
+		entities.SeverityWarning, constants.CategorySecurity, mdn + "Web/Security/Transport_Layer_Security#cipher_suites"},
+	constants.RuleTLSWeakKey: {
+		entities.SeverityWarning, constants.CategorySecurity, mdn + "Web/Security/Transport_Layer_Security"},
+	constants.RuleTLSWeakSignature: {
+		entities.SeverityWarning, constants.CategorySecurity, mdn + "Web/Security/Transport_Layer_Security"},
+	constants.RuleMaxInaccessibleLinks: {
+		entities.SeverityError, constants.CategoryLinks, ""},
+	constants.RuleH1Count: {
+		entities.SeverityError, constants.CategoryHeadings, mdn + "Web/HTML/Element/Heading_Elements"},
+	constants.RuleTitleLength: {
+		entities.SeverityError, constants.CategorySEO, mdn + "Web/HTML/Element/title#page_titles_and_seo"},
+}
+
+// severityRank orders the severities so a policy can fail on anything at or above one.
+var severityRank = map[entities.Severity]int{
+	entities.SeverityInfo:    1,
+	entities.SeverityWarning: 2,
+	entities.SeverityError:   3,
+}
+
+// newFinding builds a finding with the severity, category and help of its rule.
+func newFinding(ruleID string, loc entities.Location, format string, args ...any) entities.Finding {
+	r := rules[ruleID]
+
+	return entities.Finding{
+		RuleID:   ruleID,
+		Severity: r.severity,
+		Category: r.category,
+		Message:  fmt.Sprintf(format, args...),
+		HelpURL:  r.helpURL,
+		Location: loc,
+	}
+}
+
+func summarizeFindings(findings []entities.Finding) entities.FindingsSummary {
+	summary := entities.FindingsSummary{Total: len(findings)}
+
+	for _, f := range findings {
+		switch f.Severity {
+		case entities.SeverityError:
+			summary.Errors++
+		case entities.SeverityWarning:
+			summary.Warnings++
+		case entities.SeverityInfo:
+			summary.Info++
+		}
+	}
+
+	return summary
+}
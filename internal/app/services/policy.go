@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// LoadPolicy reads a policy file, json when the extension says so and yaml otherwise.
+func LoadPolicy(path string) (*entities.Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read policy: %w", err)
+	}
+
+	policy := &entities.Policy{}
+
+	if err := decodeConfigFile(path, data, policy); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+
+	if err := validatePolicy(policy); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+
+	return policy, nil
+}
+
+// decodeConfigFile decodes json when the extension says so and yaml otherwise. Unknown keys
+// are an error, a misspelled one would otherwise be ignored without a word.
+func decodeConfigFile(path string, data []byte, v any) error {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(v); err != nil {
+			return err
+		}
+
+		if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+			return errors.New("unexpected data after the top level value")
+		}
+
+		return nil
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	// an empty yaml file is an empty document
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+func validatePolicy(policy *entities.Policy) error {
+	if policy.FailOn == "" {
+		policy.FailOn = entities.SeverityError
+	}
+
+	if _, ok := severityRank[policy.FailOn]; !ok {
+		return fmt.Errorf("unknown failOn severity %q", policy.FailOn)
+	}
+
+	for id, r := range policy.Rules {
+		if _, ok := rules[id]; !ok {
+			return fmt.Errorf("unknown rule %q", id)
+		}
+
+		if _, ok := severityRank[r.Severity]; r.Severity != "" && !ok {
+			return fmt.Errorf("unknown severity %q for rule %q", r.Severity, id)
+		}
+	}
+
+	for category, w := range policy.Weights {
+		if _, ok := constants.DefaultScoreWeights[category]; !ok {
+			return fmt.Errorf("unknown score category %q", category)
+		}
+
+		if w < 0 {
+			return fmt.Errorf("negative weight for score category %q", category)
+		}
+	}
+
+	return nil
+}
+
+// thresholdFindings checks the result against the thresholds of the policy.
+func thresholdFindings(t entities.Thresholds, result *entities.AnalysisResult) []entities.Finding {
+	findings := []entities.Finding{}
+
+	if t.MaxInaccessibleLinks != nil && result.Links.Inaccessible > *t.MaxInaccessibleLinks {
+		findings = append(findings, newFinding(constants.RuleMaxInaccessibleLinks, entities.Location{},
+			"%d inaccessible links, at most %d allowed", result.Links.Inaccessible, *t.MaxInaccessibleLinks))
+	}
+
+	if h1 := result.Headings[constants.H1]; t.H1Count != nil && h1 != *t.H1Count {
+		findings = append(findings, newFinding(constants.RuleH1Count, entities.Location{},
+			"page has %d h1, expected %d", h1, *t.H1Count))
+	}
+
+	length := utf8.RuneCountInString(strings.TrimSpace(result.Title))
+
+	if t.TitleMinLength != nil && length < *t.TitleMinLength {
+		findings = append(findings, newFinding(constants.RuleTitleLength, entities.Location{},
+			"title has %d characters, at least %d expected", length, *t.TitleMinLength))
+	}
+
+	if t.TitleMaxLength != nil && length > *t.TitleMaxLength {
+		findings = append(findings, newFinding(constants.RuleTitleLength, entities.Location{},
+			"title has %d characters, at most %d expected", length, *t.TitleMaxLength))
+	}
+
+	return findings
+}
+
+// applyPolicy drops the findings of disabled rules and applies the severity overrides.
+func applyPolicy(policy *entities.Policy, findings []entities.Finding) []entities.Finding {
+	applied := []entities.Finding{}
+
+	for _, f := range findings {
+		r, ok := policy.Rules[f.RuleID]
+
+		if ok && r.Enabled != nil && !*r.Enabled {
+			continue
+		}
+
+		if ok && r.Severity != "" {
+			f.Severity = r.Severity
+		}
+
+		applied = append(applied, f)
+	}
+
+	return applied
+}
+
+// evaluatePolicy fails the page when any finding is at or above the failOn severity.
+func evaluatePolicy(policy *entities.Policy, findings []entities.Finding) *entities.Verdict {
+	verdict := &entities.Verdict{Passed: true, Failures: []string{}}
+
+	for _, f := range findings {
+		if severityRank[f.Severity] < severityRank[policy.FailOn] {
+			continue
+		}
+
+		verdict.Passed = false
+
+		if !slices.Contains(verdict.Failures, f.RuleID) {
+			verdict.Failures = append(verdict.Failures, f.RuleID)
+		}
+	}
+
+	return verdict
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// ids we can use as-is in a css selector, anything else falls back to the tag path
+var cssIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// elements the parser creates without a tag in the source
+var impliedElements = setOf("html", "head", "body", "tbody", "colgroup", "tr")
+
+// lineIndex maps byte offsets in the original html to 1 based line and column numbers.
+// columns are counted in characters so they match what an editor shows.
+type lineIndex struct {
+	src    []byte
+	starts []int // offset of the first byte of every line
+}
+
+func newLineIndex(src []byte) *lineIndex {
+	starts := []int{0}
+
+	for i, b := range src {
+		if b == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+
+	return &lineIndex{src: src, starts: starts}
+}
+
+func (l *lineIndex) position(offset int) (line, column int) {
+	if offset > len(l.src) {
+		offset = len(l.src)
+	}
+
+	// last line starting at or before the offset
+	i := sort.SearchInts(l.starts, offset+1) - 1
+
+	return i + 1, utf8.RuneCount(l.src[l.starts[i]:offset]) + 1
+}
+
+type startTag struct {
+	tag    string
+	offset int
+}
+
+// sourceMap links the parsed element nodes back to where their start tag is in the original bytes.
+type sourceMap struct {
+	index   *lineIndex
+	offsets map[*html.Node]int
+	nodes   map[int]*html.Node
+	ids     map[string]int // id -> number of elements using it
+}
+
+// parseWithPositions parses the html the same way goquery does and records the
+// source offset of every element. the parser repairs the markup, so elements it
+// implies (html, body, tbody) or reopens have no offset of their own.
+func parseWithPositions(htmlBytes []byte) (*goquery.Document, *sourceMap, error) {
+	root, err := html.Parse(bytes.NewReader(htmlBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m := &sourceMap{
+		index:   newLineIndex(htmlBytes),
+		offsets: map[*html.Node]int{},
+		nodes:   map[int]*html.Node{},
+		ids:     map[string]int{},
+	}
+
+	tags := &tagMatcher{tags: scanStartTags(htmlBytes)}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if id := attrValue(n, "id"); id != "" {
+				m.ids[id]++
+			}
+
+			if offset, ok := tags.match(n); ok {
+				m.record(n, offset)
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	walk(root)
+
+	return goquery.NewDocumentFromNode(root), m, nil
+}
+
+// tagMatcher pairs the elements, in document order, with the start tags, in source order.
+type tagMatcher struct {
+	tags []startTag
+	next int
+	// pending tags skipped over for content the parser moved in front of a table (foster
+	// parenting), the table and what came before that content are still to be matched
+	pending []int
+}
+
+// match the next start tag of the element's name. tags the parser dropped (a nested form, a
+// second body) are skipped, elements the parser made up are left without a position. The
+// tokenizer lowercases names, svg and mathml elements keep their case in the tree (foreignObject).
+func (t *tagMatcher) match(n *html.Node) (int, bool) {
+	made := impliedElements[n.Data] || formattingElements[n.Data]
+
+	for i, idx := range t.pending {
+		if !strings.EqualFold(t.tags[idx].tag, n.Data) {
+			if made {
+				break
+			}
+
+			continue
+		}
+
+		// the ones before it were dropped by the parser
+		t.pending = t.pending[i+1:]
+
+		return t.tags[idx].offset, true
+	}
+
+	if t.next < len(t.tags) && strings.EqualFold(t.tags[t.next].tag, n.Data) {
+		t.next++
+
+		return t.tags[t.next-1].offset, true
+	}
+
+	if made {
+		return 0, false
+	}
+
+	for i := t.next; i < len(t.tags); i++ {
+		if !strings.EqualFold(t.tags[i].tag, n.Data) {
+			continue
+		}
+
+		if len(t.pending) > 0 || t.tags[t.next].tag == "table" {
+			for idx := t.next; idx < i; idx++ {
+				t.pending = append(t.pending, idx)
+			}
+		}
+
+		t.next = i + 1
+
+		return t.tags[i].offset, true
+	}
+
+	return 0, false
+}
+
+func (m *sourceMap) record(n *html.Node, offset int) {
+	m.offsets[n] = offset
+	m.nodes[offset] = n
+}
+
+// scanStartTags returns every start tag in source order with its byte offset.
+func scanStartTags(htmlBytes []byte) []startTag {
+	var tags []startTag
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(htmlBytes))
+	offset := 0
+
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			return tags
+		}
+
+		start := offset
+		offset += len(tokenizer.Raw())
+
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			name, _ := tokenizer.TagName()
+			tags = append(tags, startTag{tag: string(name), offset: start})
+		}
+	}
+}
+
+// locate returns the source position and a unique selector for the element.
+func (m *sourceMap) locate(n *html.Node) entities.Location {
+	loc := entities.Location{Selector: m.selector(n)}
+
+	if offset, ok := m.offsets[n]; ok {
+		loc.Line, loc.Column = m.index.position(offset)
+	}
+
+	return loc
+}
+
+// locateOffset returns the position of the offset, with the selector of the element starting there if any.
+func (m *sourceMap) locateOffset(offset int) entities.Location {
+	if n, ok := m.nodes[offset]; ok {
+		return m.locate(n)
+	}
+
+	loc := entities.Location{}
+	loc.Line, loc.Column = m.index.position(offset)
+
+	return loc
+}
+
+// selector builds a css path that matches only this element, anchored
+// on the closest ancestor with a unique id when there is one.
+func (m *sourceMap) selector(n *html.Node) string {
+	var parts []string
+
+	for ; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		if id := attrValue(n, "id"); m.ids[id] == 1 && cssIdentifier.MatchString(id) {
+			parts = append(parts, "#"+id)
+
+			break
+		}
+
+		part := n.Data
+
+		if count, pos := siblingsOfType(n); count > 1 {
+			part = fmt.Sprintf("%s:nth-of-type(%d)", n.Data, pos)
+		}
+
+		parts = append(parts, part)
+	}
+
+	// collected from the element up, the selector reads from the root down
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+
+	return strings.Join(parts, " > ")
+}
+
+// siblingsOfType returns the number of siblings sharing the element's tag and its 1 based position among them.
+func siblingsOfType(n *html.Node) (count, pos int) {
+	if n.Parent == nil {
+		return 1, 1
+	}
+
+	for c := n.Parent.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != n.Data {
+			continue
+		}
+
+		count++
+
+		if c == n {
+			pos = count
+		}
+	}
+
+	return count, pos
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key && a.Namespace == "" {
+			return a.Val
+		}
+	}
+
+	return ""
+}
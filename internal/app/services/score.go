@@ -0,0 +1,49 @@
+package services
+
+import (
+	"math"
+
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+var scorePenalty = map[entities.Severity]int{
+	entities.SeverityError:   constants.ScorePenaltyError,
+	entities.SeverityWarning: constants.ScorePenaltyWarning,
+	entities.SeverityInfo:    constants.ScorePenaltyInfo,
+}
+
+// scorePage every scored category starts at 100 and loses points for each of its findings,
+// the overall score is the weighted average of the categories. weights not given keep their default.
+func scorePage(findings []entities.Finding, weights map[string]float64) entities.Score {
+	categories := make(map[string]int, len(constants.ScoredCategories))
+
+	for _, c := range constants.ScoredCategories {
+		categories[c] = 100
+	}
+
+	for _, f := range findings {
+		if score, ok := categories[f.Category]; ok {
+			categories[f.Category] = max(0, score-scorePenalty[f.Severity])
+		}
+	}
+
+	var total, sum float64
+
+	for _, c := range constants.ScoredCategories {
+		w := constants.DefaultScoreWeights[c]
+		if override, ok := weights[c]; ok {
+			w = override
+		}
+
+		total += w
+		sum += w * float64(categories[c])
+	}
+
+	overall := 100
+	if total > 0 {
+		overall = int(math.Round(sum / total))
+	}
+
+	return entities.Score{Overall: overall, Categories: categories}
+}
@@ -0,0 +1,272 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// elements linking to other files of the site
+const linkSelector = "a[href], area[href], link[href], img[src], script[src], iframe[src], source[src]"
+
+// pages that are entry points, nothing has to link to them
+var entryPages = map[string]bool{
+	"index.html": true,
+	"404.html":   true,
+}
+
+// Site a static site build in root, served at base.
+type Site struct {
+	root string
+	base *url.URL
+}
+
+func New(root string, base *url.URL) *Site {
+	b := *base
+	if !strings.HasSuffix(b.Path, "/") {
+		b.Path += "/"
+	}
+
+	return &Site{root: root, base: &b}
+}
+
+// Resolve the file a url of the site is served from, relative to the root. Directories serve
+// their index.html and pretty urls without the .html extension are tried too.
+func (s *Site) Resolve(u *url.URL) (string, bool) {
+	if !strings.EqualFold(u.Host, s.base.Host) {
+		return "", false
+	}
+
+	p := u.Path
+	if p+"/" == s.base.Path {
+		p = s.base.Path
+	}
+
+	if !strings.HasPrefix(p, s.base.Path) {
+		return "", false
+	}
+
+	rel := strings.TrimPrefix(p, s.base.Path)
+
+	candidates := []string{rel + "index.html"}
+	if rel != "" && !strings.HasSuffix(rel, "/") {
+		candidates = []string{rel, rel + ".html", rel + "/index.html"}
+	}
+
+	for _, c := range candidates {
+		// cleaning from the root keeps ".." inside the site
+		clean := strings.TrimPrefix(path.Clean("/"+c), "/")
+
+		info, err := os.Stat(filepath.Join(s.root, filepath.FromSlash(clean)))
+		if err == nil && info.Mode().IsRegular() {
+			return clean, true
+		}
+	}
+
+	return "", false
+}
+
+// URL the url a file of the site is served at.
+func (s *Site) URL(rel string) *url.URL {
+	return s.base.ResolveReference(&url.URL{Path: rel})
+}
+
+// Transport serves the site from disk, so link checks never reach the network.
+func (s *Site) Transport() http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if !strings.EqualFold(req.URL.Host, s.base.Host) {
+			return nil, fmt.Errorf("%s is not part of the site", req.URL.Redacted())
+		}
+
+		status := http.StatusNotFound
+		header := http.Header{}
+
+		var body []byte
+
+		if rel, ok := s.Resolve(req.URL); ok {
+			data, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(rel)))
+			if err != nil {
+				return nil, err
+			}
+
+			status = http.StatusOK
+			header.Set("Content-Type", mime.TypeByExtension(path.Ext(rel)))
+
+			if req.Method != http.MethodHead {
+				body = data
+			}
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			StatusCode:    status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// page the links and anchors of one html file.
+type page struct {
+	links   []string
+	anchors map[string]bool
+}
+
+// Check the links between the pages: links to files that don't exist, fragments that
+// aren't anchors of their page, and pages no other page links to.
+func (s *Site) Check(pages []string) (*entities.SiteReport, error) {
+	parsed := make(map[string]*page, len(pages))
+
+	for _, rel := range pages {
+		p, err := s.parse(rel)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed[rel] = p
+	}
+
+	report := &entities.SiteReport{Pages: len(pages), Issues: []entities.SiteIssue{}}
+	inbound := map[string]bool{}
+
+	for _, rel := range pages {
+		pageURL := s.URL(rel)
+
+		for _, href := range parsed[rel].links {
+			ref, err := url.Parse(strings.TrimSpace(href))
+			if err != nil {
+				report.Issues = append(report.Issues, entities.SiteIssue{
+					Kind: constants.SiteBrokenLink, Page: rel, Target: href,
+				})
+
+				continue
+			}
+
+			target := pageURL.ResolveReference(ref)
+
+			// other sites, mailto: and the like aren't checked
+			if (target.Scheme != "http" && target.Scheme != "https") || !strings.EqualFold(target.Host, s.base.Host) {
+				continue
+			}
+
+			file, ok := s.Resolve(target)
+			if !ok {
+				report.Issues = append(report.Issues, entities.SiteIssue{
+					Kind: constants.SiteBrokenLink, Page: rel, Target: href,
+				})
+
+				continue
+			}
+
+			if file != rel {
+				inbound[file] = true
+			}
+
+			if !s.hasAnchor(parsed, file, target.Fragment) {
+				report.Issues = append(report.Issues, entities.SiteIssue{
+					Kind: constants.SiteMissingAnchor, Page: rel, Target: href,
+				})
+			}
+		}
+	}
+
+	for _, rel := range pages {
+		if !inbound[rel] && !entryPages[rel] {
+			report.Issues = append(report.Issues, entities.SiteIssue{Kind: constants.SiteOrphanPage, Page: rel})
+		}
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Page < report.Issues[j].Page
+	})
+
+	return report, nil
+}
+
+// hasAnchor an empty fragment and #top always exist, other pages are parsed on demand.
+func (s *Site) hasAnchor(parsed map[string]*page, file, fragment string) bool {
+	if fragment == "" || fragment == "top" {
+		return true
+	}
+
+	p, ok := parsed[file]
+	if !ok {
+		if !isHTML(file) {
+			return true
+		}
+
+		var err error
+
+		if p, err = s.parse(file); err != nil {
+			return false
+		}
+
+		parsed[file] = p
+	}
+
+	return p.anchors[fragment]
+}
+
+func (s *Site) parse(rel string) (*page, error) {
+	data, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", rel, err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", rel, err)
+	}
+
+	p := &page{anchors: map[string]bool{}}
+
+	doc.Find("[id], a[name]").Each(func(_ int, sel *goquery.Selection) {
+		if id, ok := sel.Attr("id"); ok {
+			p.anchors[id] = true
+		}
+
+		if name, ok := sel.Attr("name"); ok && goquery.NodeName(sel) == "a" {
+			p.anchors[name] = true
+		}
+	})
+
+	doc.Find(linkSelector).Each(func(_ int, sel *goquery.Selection) {
+		if href, ok := sel.Attr("href"); ok {
+			p.links = append(p.links, href)
+		} else {
+			p.links = append(p.links, sel.AttrOr("src", ""))
+		}
+	})
+
+	return p, nil
+}
+
+func isHTML(file string) bool {
+	ext := strings.ToLower(path.Ext(file))
+
+	return ext == ".html" || ext == ".htm" || ext == ".xhtml"
+}
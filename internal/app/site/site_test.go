@@ -0,0 +1,129 @@
+package site
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// newTestSite writes the files of a site to a temp dir, served at https://example.com/docs/
+func newTestSite(t *testing.T, files map[string]string) *Site {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		assert.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+
+	base, _ := url.Parse("https://example.com/docs")
+
+	return New(root, base)
+}
+
+// Test urls resolve to index files, pretty urls and plain files, never outside the site
+func TestResolve(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"index.html":       "<html></html>",
+		"about.html":       "<html></html>",
+		"guide/index.html": "<html></html>",
+		"style.css":        "body {}",
+	})
+
+	tests := []struct {
+		url  string
+		file string
+		ok   bool
+	}{
+		{url: "https://example.com/docs", file: "index.html", ok: true},
+		{url: "https://example.com/docs/", file: "index.html", ok: true},
+		{url: "https://example.com/docs/about", file: "about.html", ok: true},
+		{url: "https://example.com/docs/about.html", file: "about.html", ok: true},
+		{url: "https://example.com/docs/guide", file: "guide/index.html", ok: true},
+		{url: "https://example.com/docs/guide/", file: "guide/index.html", ok: true},
+		{url: "https://EXAMPLE.com/docs/style.css", file: "style.css", ok: true},
+		{url: "https://example.com/docs/missing"},
+		{url: "https://example.com/other/about.html"},
+		{url: "https://other.com/docs/about.html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+
+			file, ok := s.Resolve(u)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.file, file)
+		})
+	}
+}
+
+// Test broken links, missing anchors and orphan pages are reported
+func TestCheck(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"index.html": `<html><body id="main">
+			<a href="about">About</a>
+			<a href="guide/#install">Install</a>
+			<a href="guide/#missing">Missing</a>
+			<a href="#top">Top</a>
+			<a href="/docs/gone.html">Gone</a>
+			<a href="https://other.com/page">Other</a>
+			<a href="mailto:team@example.com">Mail</a>
+			<img src="logo.png">
+			</body></html>`,
+		"about.html":       `<html><a href="index.html#main">Home</a></html>`,
+		"guide/index.html": `<html><h2 id="install">Install</h2><a href="../about.html">About</a></html>`,
+		"orphan.html":      `<html><a href="about">About</a></html>`,
+		"404.html":         `<html></html>`,
+	})
+
+	report, err := s.Check([]string{"index.html", "about.html", "guide/index.html", "orphan.html", "404.html"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 5, report.Pages)
+	assert.ElementsMatch(t, []entities.SiteIssue{
+		{Kind: constants.SiteMissingAnchor, Page: "index.html", Target: "guide/#missing"},
+		{Kind: constants.SiteBrokenLink, Page: "index.html", Target: "/docs/gone.html"},
+		{Kind: constants.SiteBrokenLink, Page: "index.html", Target: "logo.png"},
+		{Kind: constants.SiteOrphanPage, Page: "orphan.html"},
+	}, report.Issues)
+}
+
+// Test the transport serves the site from disk and nothing else
+func TestTransport(t *testing.T) {
+	s := newTestSite(t, map[string]string{
+		"about.html": "<html>about</html>",
+	})
+
+	hc := &http.Client{Transport: s.Transport()}
+
+	resp, err := hc.Get("https://example.com/docs/about")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "<html>about</html>", string(body))
+
+	_ = resp.Body.Close()
+
+	resp, err = hc.Head("https://example.com/docs/missing")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_ = resp.Body.Close()
+
+	_, err = hc.Get("https://other.com/")
+	assert.Error(t, err)
+}
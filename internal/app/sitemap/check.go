@@ -0,0 +1,238 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/erainogo/html-analyzer/internal/app/robots"
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// Check fetches the urls of the report, without following redirects, and adds the issues of those
+// that shouldn't be in a sitemap: urls that don't answer 200, redirect, are marked noindex or name
+// another url as canonical. When robots.txt is honored for the run, the urls it disallows are
+// reported instead of fetched and its Crawl-delay is waited between the fetches of a host.
+func (r *Reader) Check(ctx context.Context, report *entities.SitemapReport) {
+	hc := *r.hc
+	hc.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	issues := make([][]entities.SitemapIssue, len(report.URLs))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < constants.SitemapWorkerCount; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for idx := range jobs {
+				issues[idx] = r.check(ctx, &hc, report.URLs[idx])
+			}
+		}()
+	}
+
+loop:
+	for i := range report.URLs {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	// in the order of the sitemaps
+	for _, found := range issues {
+		report.Issues = append(report.Issues, found...)
+	}
+}
+
+// check a listed url.
+func (r *Reader) check(ctx context.Context, hc *http.Client, u entities.SitemapURL) []entities.SitemapIssue {
+	issue := func(kind, detail string) []entities.SitemapIssue {
+		return []entities.SitemapIssue{{Kind: kind, Sitemap: u.Sitemap, URL: u.Loc, Detail: detail}}
+	}
+
+	if r.robots.Enabled(ctx) {
+		if !r.robots.Allowed(ctx, u.Loc) {
+			return issue(constants.SitemapDisallowed, "")
+		}
+
+		if err := r.robots.Wait(ctx, u.Loc); err != nil {
+			return nil
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.Loc, nil)
+	if err != nil {
+		return issue(constants.SitemapBadStatus, err.Error())
+	}
+
+	req.Header.Set("User-Agent", r.userAgent)
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return issue(constants.SitemapBadStatus, err.Error())
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= http.StatusMultipleChoices && resp.StatusCode < http.StatusBadRequest:
+		target := resp.Header.Get("Location")
+		if loc, err := resp.Location(); err == nil {
+			target = loc.String()
+		}
+
+		return issue(constants.SitemapRedirect, fmt.Sprintf("%d %s", resp.StatusCode, target))
+	case resp.StatusCode != http.StatusOK:
+		return issue(constants.SitemapBadStatus, fmt.Sprint(resp.StatusCode))
+	}
+
+	var found []entities.SitemapIssue
+
+	noindex := hasNoindex(robots.ProductTokens(r.userAgent), resp.Header.Values("X-Robots-Tag")...)
+	canonical := ""
+
+	if isHTML(resp.Header.Get("Content-Type")) {
+		doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, constants.SitemapMaxPageSize))
+		if err == nil {
+			noindex = noindex || r.metaNoindex(doc)
+			canonical = canonicalOf(doc, resp)
+		}
+	}
+
+	if noindex {
+		found = append(found, issue(constants.SitemapNoindex, "")...)
+	}
+
+	if canonical != "" && !sameURL(canonical, u.Loc) {
+		found = append(found, issue(constants.SitemapCanonicalized, canonical)...)
+	}
+
+	return found
+}
+
+// metaNoindex whether the robots meta tags for every crawler, or for the analyzer's user agent,
+// say noindex.
+func (r *Reader) metaNoindex(doc *goquery.Document) bool {
+	tokens := robots.ProductTokens(r.userAgent)
+	noindex := false
+
+	doc.Find("meta[name]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		name := strings.ToLower(strings.TrimSpace(s.AttrOr("name", "")))
+		if name != "robots" && !tokens[name] {
+			return true
+		}
+
+		noindex = hasNoindex(tokens, s.AttrOr("content", ""))
+
+		return !noindex
+	})
+
+	return noindex
+}
+
+// canonicalOf the absolute url of the canonical link of the page, empty without one.
+func canonicalOf(doc *goquery.Document, resp *http.Response) string {
+	href, ok := doc.Find(`link[rel~="canonical"][href]`).First().Attr("href")
+	if !ok {
+		return ""
+	}
+
+	ref, err := resp.Request.URL.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+
+	return ref.String()
+}
+
+// valuedDirectives the robots directives that take a value after a colon, so the name before it
+// isn't a crawler's.
+var valuedDirectives = map[string]bool{
+	"unavailable_after": true,
+	"max-snippet":       true,
+	"max-image-preview": true,
+	"max-video-preview": true,
+}
+
+// hasNoindex whether robots directives, as in a meta tag or X-Robots-Tag header, include noindex
+// for every crawler or for one of the product tokens. "crawler: noindex, nofollow" scopes the
+// directives of the value from there on to the named crawler.
+func hasNoindex(tokens map[string]bool, values ...string) bool {
+	for _, v := range values {
+		scope := ""
+
+		for _, directive := range strings.Split(v, ",") {
+			if name, rest, ok := strings.Cut(directive, ":"); ok {
+				name = strings.ToLower(strings.TrimSpace(name))
+				if !valuedDirectives[name] {
+					scope, directive = name, rest
+				}
+			}
+
+			if scope != "" && !tokens[scope] {
+				continue
+			}
+
+			switch strings.ToLower(strings.TrimSpace(directive)) {
+			case "noindex", "none":
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isHTML whether the content type is html, pages without one are read as html too.
+func isHTML(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
+
+// sameURL whether the urls are the same page: without fragments, with case insensitive hosts and
+// an empty path the same as /.
+func sameURL(a, b string) bool {
+	ua, okA := httpURL(a)
+	ub, okB := httpURL(b)
+
+	if !okA || !okB {
+		return a == b
+	}
+
+	for _, u := range []*url.URL{ua, ub} {
+		u.Fragment = ""
+		u.Host = strings.ToLower(u.Host)
+
+		if u.Path == "" {
+			u.Path = "/"
+		}
+	}
+
+	return ua.String() == ub.String()
+}
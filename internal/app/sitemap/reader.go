@@ -0,0 +1,299 @@
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/erainogo/html-analyzer/internal/app/robots"
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Reader discovers, reads and validates the sitemaps of a site and checks the urls they list.
+type Reader struct {
+	hc        *http.Client
+	userAgent string
+	logger    *zap.SugaredLogger
+	robots    *robots.Checker
+}
+
+type Option func(*Reader)
+
+func WithLogger(logger *zap.SugaredLogger) Option {
+	return func(r *Reader) {
+		r.logger = logger
+	}
+}
+
+// WithRobots discovers the sitemaps from the robots.txt cached by the checker of the run.
+func WithRobots(checker *robots.Checker) Option {
+	return func(r *Reader) {
+		r.robots = checker
+	}
+}
+
+func New(hc *http.Client, userAgent string, opts ...Option) *Reader {
+	r := &Reader{
+		hc:        hc,
+		userAgent: userAgent,
+		logger:    zap.NewNop().Sugar(),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.robots == nil {
+		r.robots = robots.New(hc, userAgent)
+	}
+
+	return r
+}
+
+// run the state of one Read.
+type run struct {
+	report *entities.SitemapReport
+	// host the listed urls must be on
+	host  string
+	files map[string]bool
+	urls  map[string]bool
+}
+
+func (rn *run) issue(kind, sitemap, u, detail string) {
+	rn.report.Issues = append(rn.report.Issues, entities.SitemapIssue{
+		Kind: kind, Sitemap: sitemap, URL: u, Detail: detail,
+	})
+}
+
+// Discover the sitemaps of the site: those of the Sitemap lines of its robots.txt, /sitemap.xml
+// when there are none.
+func (r *Reader) Discover(ctx context.Context, siteURL string) ([]string, error) {
+	u, ok := httpURL(siteURL)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not an http url", entities.ErrInvalidSitemap, siteURL)
+	}
+
+	rb, err := r.robots.Robots(ctx, u.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entities.ErrInvalidSitemap, err)
+	}
+
+	var sitemaps []string
+
+	for _, s := range rb.Sitemaps {
+		if ref, err := u.Parse(s); err == nil {
+			sitemaps = append(sitemaps, ref.String())
+		}
+	}
+
+	if len(sitemaps) == 0 {
+		sitemaps = []string{u.ResolveReference(&url.URL{Path: "/sitemap.xml"}).String()}
+	}
+
+	return sitemaps, nil
+}
+
+// Read the sitemaps of rawURL, the urls they list and the problems found in them. A url with a
+// path is the sitemap itself, the sitemaps of a site url are discovered. The listed urls must be
+// on the host of rawURL, sitemaps listed in robots.txt may be on other hosts.
+func (r *Reader) Read(ctx context.Context, rawURL string) (*entities.SitemapReport, error) {
+	u, ok := httpURL(rawURL)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not an http url", entities.ErrInvalidSitemap, rawURL)
+	}
+
+	locations := []string{u.String()}
+
+	if u.Path == "" || u.Path == "/" {
+		var err error
+		if locations, err = r.Discover(ctx, u.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	rn := &run{
+		report: &entities.SitemapReport{
+			Sitemaps: []entities.SitemapFile{},
+			URLs:     []entities.SitemapURL{},
+			Issues:   []entities.SitemapIssue{},
+		},
+		host:  strings.ToLower(u.Host),
+		files: map[string]bool{},
+		urls:  map[string]bool{},
+	}
+
+	for _, loc := range locations {
+		r.read(ctx, rn, loc, false)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return rn.report, err
+	}
+
+	if len(rn.report.Sitemaps) == 0 {
+		return rn.report, entities.ErrNoSitemap
+	}
+
+	return rn.report, nil
+}
+
+// read a sitemap and validates it, the sitemaps of an index are read too. inIndex the sitemap is
+// listed in an index, which can't list other indexes.
+func (r *Reader) read(ctx context.Context, rn *run, loc string, inIndex bool) {
+	if rn.files[loc] || ctx.Err() != nil {
+		return
+	}
+
+	rn.files[loc] = true
+
+	r.logger.Infow("reading sitemap", "url", loc)
+
+	data, compressed, err := r.fetch(ctx, loc)
+
+	switch {
+	case ctx.Err() != nil:
+		return
+	case errors.Is(err, entities.ErrBodyTooLarge):
+		rn.issue(constants.SitemapTooLarge, loc, "",
+			fmt.Sprintf("more than %d MiB uncompressed", constants.SitemapMaxSize>>20))
+
+		return
+	case err != nil:
+		rn.issue(constants.SitemapUnreachable, loc, "", err.Error())
+
+		return
+	}
+
+	sm, err := Parse(data)
+	if err != nil {
+		rn.issue(constants.SitemapInvalid, loc, "", err.Error())
+
+		return
+	}
+
+	rn.report.Sitemaps = append(rn.report.Sitemaps, entities.SitemapFile{
+		URL:        loc,
+		Index:      sm.Index,
+		Entries:    len(sm.Entries),
+		Size:       int64(len(data)),
+		Compressed: compressed,
+	})
+
+	if sm.Index && inIndex {
+		rn.issue(constants.SitemapNestedIndex, loc, "", "a sitemap index can't list other sitemap indexes")
+
+		return
+	}
+
+	entries := sm.Entries
+	if len(entries) > constants.SitemapMaxEntries {
+		rn.issue(constants.SitemapTooManyURLs, loc, "",
+			fmt.Sprintf("%d entries, only the first %d are read", len(entries), constants.SitemapMaxEntries))
+
+		entries = entries[:constants.SitemapMaxEntries]
+	}
+
+	for _, e := range entries {
+		if err := checkLoc(e.Loc); err != nil {
+			rn.issue(constants.SitemapInvalidURL, loc, e.Loc, err.Error())
+
+			continue
+		}
+
+		if !ValidLastMod(e.LastMod) {
+			rn.issue(constants.SitemapInvalidDate, loc, e.Loc, e.LastMod)
+		}
+
+		if sm.Index {
+			r.read(ctx, rn, e.Loc, true)
+
+			continue
+		}
+
+		if u, _ := httpURL(e.Loc); strings.ToLower(u.Host) != rn.host {
+			rn.issue(constants.SitemapOffSiteURL, loc, e.Loc, "not on "+rn.host)
+
+			continue
+		}
+
+		if rn.urls[e.Loc] {
+			rn.issue(constants.SitemapDuplicateURL, loc, e.Loc, "")
+
+			continue
+		}
+
+		rn.urls[e.Loc] = true
+		rn.report.URLs = append(rn.report.URLs, entities.SitemapURL{Loc: e.Loc, LastMod: e.LastMod, Sitemap: loc})
+	}
+}
+
+// fetch the uncompressed content of a sitemap, and whether it was gzipped.
+func (r *Reader) fetch(ctx context.Context, loc string) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	req.Header.Set("User-Agent", r.userAgent)
+
+	resp, err := r.hc.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	body := bufio.NewReader(resp.Body)
+
+	var (
+		reader     io.Reader = body
+		compressed bool
+	)
+
+	// .gz sitemaps are served as files, not with a gzip content encoding the client undoes
+	if magic, _ := body.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, true, err
+		}
+		defer gz.Close()
+
+		reader, compressed = gz, true
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, constants.SitemapMaxSize+1))
+	if err != nil {
+		return nil, compressed, err
+	}
+
+	if len(data) > constants.SitemapMaxSize {
+		return nil, compressed, entities.ErrBodyTooLarge
+	}
+
+	return data, compressed, nil
+}
+
+// httpURL the url when it is an absolute http url.
+func httpURL(rawURL string) (*url.URL, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, false
+	}
+
+	return u, true
+}
@@ -0,0 +1,120 @@
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+// Sitemap the entries of a sitemap file, the pages of a urlset or the sitemaps of an index.
+type Sitemap struct {
+	Index   bool
+	Entries []Entry
+}
+
+type Entry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// document a urlset or sitemapindex, in the sitemap namespace or none.
+type document struct {
+	XMLName  xml.Name
+	URLs     []Entry `xml:"url"`
+	Sitemaps []Entry `xml:"sitemap"`
+}
+
+// lastModLayouts the W3C datetime forms lastmod may take.
+var lastModLayouts = []string{
+	"2006-01-02",
+	"2006-01-02T15:04Z07:00",
+	time.RFC3339,
+	time.RFC3339Nano,
+}
+
+// Parse the entries of an xml sitemap or sitemap index, or of a text sitemap with one url per line.
+func Parse(data []byte) (*Sitemap, error) {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: empty sitemap", entities.ErrInvalidSitemap)
+	}
+
+	if data[0] != '<' {
+		return parseText(data), nil
+	}
+
+	var doc document
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", entities.ErrInvalidSitemap, err)
+	}
+
+	switch doc.XMLName.Local {
+	case "urlset":
+		return &Sitemap{Entries: trimEntries(doc.URLs)}, nil
+	case "sitemapindex":
+		return &Sitemap{Index: true, Entries: trimEntries(doc.Sitemaps)}, nil
+	}
+
+	return nil, fmt.Errorf("%w: root element %q is neither urlset nor sitemapindex",
+		entities.ErrInvalidSitemap, doc.XMLName.Local)
+}
+
+// parseText the urls of a text sitemap, blank lines are skipped.
+func parseText(data []byte) *Sitemap {
+	sm := &Sitemap{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			sm.Entries = append(sm.Entries, Entry{Loc: line})
+		}
+	}
+
+	return sm
+}
+
+func trimEntries(entries []Entry) []Entry {
+	for i := range entries {
+		entries[i].Loc = strings.TrimSpace(entries[i].Loc)
+		entries[i].LastMod = strings.TrimSpace(entries[i].LastMod)
+	}
+
+	return entries
+}
+
+// ValidLastMod whether lastmod is empty or a W3C datetime.
+func ValidLastMod(lastMod string) bool {
+	if lastMod == "" {
+		return true
+	}
+
+	for _, layout := range lastModLayouts {
+		if _, err := time.Parse(layout, lastMod); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkLoc the problem of a listed url: not an absolute http url or longer than the protocol allows.
+func checkLoc(loc string) error {
+	if len(loc) > constants.SitemapMaxURLLength {
+		return fmt.Errorf("longer than %d characters", constants.SitemapMaxURLLength)
+	}
+
+	if _, ok := httpURL(loc); !ok {
+		return errors.New("not an absolute http url")
+	}
+
+	return nil
+}
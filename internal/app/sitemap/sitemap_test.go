@@ -0,0 +1,317 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/erainogo/html-analyzer/internal/app/robots"
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAgent = "html-analyzer/1.0"
+
+// Test urlsets, indexes and text sitemaps are parsed
+func TestParse(t *testing.T) {
+	sm, err := Parse([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> https://example.com/ </loc><lastmod>2024-05-01</lastmod></url>
+  <url><loc>https://example.com/about</loc></url>
+</urlset>`))
+
+	assert.NoError(t, err)
+	assert.False(t, sm.Index)
+	assert.Equal(t, []Entry{
+		{Loc: "https://example.com/", LastMod: "2024-05-01"},
+		{Loc: "https://example.com/about"},
+	}, sm.Entries)
+
+	sm, err = Parse([]byte(`<sitemapindex><sitemap><loc>https://example.com/a.xml.gz</loc></sitemap></sitemapindex>`))
+
+	assert.NoError(t, err)
+	assert.True(t, sm.Index)
+	assert.Equal(t, []Entry{{Loc: "https://example.com/a.xml.gz"}}, sm.Entries)
+
+	sm, err = Parse([]byte("\xef\xbb\xbfhttps://example.com/\n\nhttps://example.com/b\n"))
+
+	assert.NoError(t, err)
+	assert.Len(t, sm.Entries, 2)
+
+	for _, data := range []string{"", "<html></html>", "<urlset><url>"} {
+		_, err = Parse([]byte(data))
+		assert.ErrorIs(t, err, entities.ErrInvalidSitemap, data)
+	}
+}
+
+func TestValidLastMod(t *testing.T) {
+	for _, v := range []string{"", "2024-05-01", "2024-05-01T10:20+02:00", "2024-05-01T10:20:30Z", "2024-05-01T10:20:30.5Z"} {
+		assert.True(t, ValidLastMod(v), v)
+	}
+
+	for _, v := range []string{"yesterday", "01/05/2024", "2024-13-01"} {
+		assert.False(t, ValidLastMod(v), v)
+	}
+}
+
+func gzipped(t *testing.T, data string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(data))
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+// newTestSite serves a site whose robots.txt lists a sitemap index of a gzipped sitemap and a
+// plain one, with pages that redirect, are missing, noindex or canonicalized elsewhere.
+func newTestSite(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		base := server.URL
+
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprintf(w, "User-agent: *\nDisallow:\n\nSitemap: %s/sitemap_index.xml\n", base)
+		case "/sitemap_index.xml":
+			fmt.Fprintf(w, `<sitemapindex>
+<sitemap><loc>%[1]s/pages.xml.gz</loc></sitemap>
+<sitemap><loc>%[1]s/more.xml</loc></sitemap>
+<sitemap><loc>%[1]s/nested.xml</loc></sitemap>
+<sitemap><loc>%[1]s/missing.xml</loc></sitemap>
+</sitemapindex>`, base)
+		case "/pages.xml.gz":
+			w.Header().Set("Content-Type", "application/gzip")
+			_, _ = w.Write(gzipped(t, fmt.Sprintf(`<urlset>
+<url><loc>%[1]s/</loc><lastmod>2024-05-01</lastmod></url>
+<url><loc>%[1]s/moved</loc></url>
+<url><loc>%[1]s/gone</loc></url>
+<url><loc>%[1]s/hidden</loc><lastmod>last week</lastmod></url>
+</urlset>`, base)))
+		case "/more.xml":
+			fmt.Fprintf(w, `<urlset>
+<url><loc>%[1]s/copy</loc></url>
+<url><loc>%[1]s/header-noindex</loc></url>
+<url><loc>%[1]s/</loc></url>
+<url><loc>https://elsewhere.example/</loc></url>
+<url><loc>/relative</loc></url>
+</urlset>`, base)
+		case "/nested.xml":
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%s/more.xml</loc></sitemap></sitemapindex>`, base)
+		case "/":
+			_, _ = w.Write([]byte(`<html><head><link rel="canonical" href="/#top"></head></html>`))
+		case "/moved":
+			http.Redirect(w, r, "/", http.StatusMovedPermanently)
+		case "/hidden":
+			_, _ = w.Write([]byte(`<html><head><meta name="robots" content="noindex, follow"></head></html>`))
+		case "/copy":
+			_, _ = w.Write([]byte(`<html><head><link rel="canonical" href="/"></head></html>`))
+		case "/header-noindex":
+			w.Header().Set("X-Robots-Tag", "none")
+			_, _ = w.Write([]byte(`<html></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// Test the sitemaps of a site are discovered, read, validated and their urls checked
+func TestRead(t *testing.T) {
+	server := newTestSite(t)
+	base := server.URL
+	r := New(server.Client(), testAgent)
+
+	report, err := r.Read(context.Background(), base)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []entities.SitemapFile{
+		{URL: base + "/sitemap_index.xml", Index: true, Entries: 4, Size: report.Sitemaps[0].Size},
+		{URL: base + "/pages.xml.gz", Entries: 4, Size: report.Sitemaps[1].Size, Compressed: true},
+		{URL: base + "/more.xml", Entries: 5, Size: report.Sitemaps[2].Size},
+		{URL: base + "/nested.xml", Index: true, Entries: 1, Size: report.Sitemaps[3].Size},
+	}, report.Sitemaps)
+
+	var locs []string
+	for _, u := range report.URLs {
+		locs = append(locs, strings.TrimPrefix(u.Loc, base))
+	}
+
+	assert.Equal(t, []string{"/", "/moved", "/gone", "/hidden", "/copy", "/header-noindex"}, locs)
+
+	kinds := func() []string {
+		var k []string
+		for _, i := range report.Issues {
+			k = append(k, i.Kind+" "+strings.TrimPrefix(i.URL, base))
+		}
+
+		return k
+	}
+
+	assert.Equal(t, []string{
+		constants.SitemapInvalidDate + " /hidden",
+		constants.SitemapDuplicateURL + " /",
+		constants.SitemapOffSiteURL + " https://elsewhere.example/",
+		constants.SitemapInvalidURL + " /relative",
+		constants.SitemapNestedIndex + " ",
+		constants.SitemapUnreachable + " ",
+	}, kinds())
+
+	report.Issues = nil
+	r.Check(context.Background(), report)
+
+	assert.Equal(t, []string{
+		constants.SitemapRedirect + " /moved",
+		constants.SitemapBadStatus + " /gone",
+		constants.SitemapNoindex + " /hidden",
+		constants.SitemapCanonicalized + " /copy",
+		constants.SitemapNoindex + " /header-noindex",
+	}, kinds())
+
+	assert.Equal(t, "301 "+base+"/", report.Issues[0].Detail)
+	assert.Equal(t, base+"/", report.Issues[3].Detail)
+}
+
+// Test the urls robots.txt disallows are reported without being fetched when it is honored
+func TestCheckRobots(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		fetched []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+
+			return
+		}
+
+		mu.Lock()
+		fetched = append(fetched, r.URL.Path)
+		mu.Unlock()
+
+		_, _ = w.Write([]byte(`<html></html>`))
+	}))
+	defer server.Close()
+
+	check := func(enabled bool) []entities.SitemapIssue {
+		fetched = nil
+
+		r := New(server.Client(), testAgent,
+			WithRobots(robots.New(server.Client(), testAgent, robots.WithEnabled(enabled))))
+
+		report := &entities.SitemapReport{URLs: []entities.SitemapURL{
+			{Loc: server.URL + "/"}, {Loc: server.URL + "/private/page"},
+		}}
+		r.Check(context.Background(), report)
+
+		return report.Issues
+	}
+
+	// off, every url is fetched
+	assert.Empty(t, check(false))
+	assert.ElementsMatch(t, []string{"/", "/private/page"}, fetched)
+
+	assert.Equal(t, []entities.SitemapIssue{
+		{Kind: constants.SitemapDisallowed, URL: server.URL + "/private/page"},
+	}, check(true))
+	assert.Equal(t, []string{"/"}, fetched)
+}
+
+// Test noindex directives scoped to another crawler are left out
+func TestHasNoindex(t *testing.T) {
+	tokens := map[string]bool{"html-analyzer": true}
+
+	tests := []struct {
+		name    string
+		values  []string
+		noindex bool
+	}{
+		{name: "Unscoped", values: []string{"noindex"}, noindex: true},
+		{name: "None", values: []string{"nofollow", "none"}, noindex: true},
+		{name: "Our crawler", values: []string{"html-analyzer: noindex"}, noindex: true},
+		{name: "Other crawler", values: []string{"googlebot: noindex"}},
+		{name: "Other crawler directives", values: []string{"otherbot: nofollow, noindex"}},
+		{name: "Other crawler then unscoped", values: []string{"otherbot: noindex", "noindex"}, noindex: true},
+		{name: "Valued directive", values: []string{"unavailable_after: 25 Jun 2010 15:00:00 PST, noindex"}, noindex: true},
+		{name: "Index", values: []string{"index, follow"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.noindex, hasNoindex(tokens, tt.values...))
+		})
+	}
+}
+
+// Test a sitemap url is read as is and /sitemap.xml is tried without robots.txt sitemaps
+func TestReadSitemapURL(t *testing.T) {
+	server := newTestSite(t)
+	r := New(server.Client(), testAgent)
+
+	report, err := r.Read(context.Background(), server.URL+"/more.xml")
+	assert.NoError(t, err)
+	assert.Len(t, report.Sitemaps, 1)
+	assert.Len(t, report.URLs, 3)
+
+	empty := httptest.NewServer(http.NotFoundHandler())
+	defer empty.Close()
+
+	report, err = New(empty.Client(), testAgent).Read(context.Background(), empty.URL)
+	assert.ErrorIs(t, err, entities.ErrNoSitemap)
+	assert.Equal(t, empty.URL+"/sitemap.xml", report.Issues[0].Sitemap)
+
+	_, err = r.Read(context.Background(), "ftp://example.com/sitemap.xml")
+	assert.ErrorIs(t, err, entities.ErrInvalidSitemap)
+}
+
+// Test the protocol limits on the entries and size of a sitemap
+func TestReadLimits(t *testing.T) {
+	var many strings.Builder
+	for i := 0; i <= constants.SitemapMaxEntries; i++ {
+		fmt.Fprintf(&many, "http://HOST/%d\n", i)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/many.txt":
+			_, _ = w.Write([]byte(strings.ReplaceAll(many.String(), "HOST", r.Host)))
+		case "/large.xml.gz":
+			_, _ = w.Write(gzipped(t, "<urlset>"+strings.Repeat(" ", constants.SitemapMaxSize)+"</urlset>"))
+		}
+	}))
+	defer server.Close()
+
+	r := New(server.Client(), testAgent)
+
+	report, err := r.Read(context.Background(), server.URL+"/many.txt")
+	assert.NoError(t, err)
+	assert.Len(t, report.URLs, constants.SitemapMaxEntries)
+	assert.Equal(t, constants.SitemapTooManyURLs, report.Issues[0].Kind)
+
+	report, err = r.Read(context.Background(), server.URL+"/large.xml.gz")
+	assert.ErrorIs(t, err, entities.ErrNoSitemap)
+	assert.Equal(t, constants.SitemapTooLarge, report.Issues[0].Kind)
+}
+
+func TestSameURL(t *testing.T) {
+	assert.True(t, sameURL("https://Example.com", "https://example.com/#top"))
+	assert.False(t, sameURL("https://example.com/a", "https://example.com/b"))
+	assert.False(t, sameURL("http://example.com/", "https://example.com/"))
+}
@@ -0,0 +1,180 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Transport settings for the outbound network, the zero value is the default transport.
+type Transport struct {
+	proxy     string
+	noProxy   []string
+	caBundles []string
+	certFile  string
+	keyFile   string
+}
+
+type Option func(*Transport)
+
+// WithProxy sends the requests through an http, https or socks5 proxy, the environment's
+// HTTP_PROXY and HTTPS_PROXY are used when it's empty.
+func WithProxy(proxy string) Option {
+	return func(t *Transport) {
+		t.proxy = proxy
+	}
+}
+
+// WithNoProxy hosts, domains, ips and cidrs reached without the proxy.
+func WithNoProxy(hosts []string) Option {
+	return func(t *Transport) {
+		t.noProxy = hosts
+	}
+}
+
+// WithCABundles trusts the pem bundles on top of the system roots.
+func WithCABundles(paths []string) Option {
+	return func(t *Transport) {
+		t.caBundles = paths
+	}
+}
+
+// WithClientCert presents the certificate to servers that ask for one.
+func WithClientCert(certFile, keyFile string) Option {
+	return func(t *Transport) {
+		t.certFile = certFile
+		t.keyFile = keyFile
+	}
+}
+
+// New builds the transport from the default one.
+func New(opts ...Option) (*http.Transport, error) {
+	t := &Transport{}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	proxy, err := t.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	transport.Proxy = proxy
+
+	tlsConfig, err := t.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return transport, nil
+}
+
+// ProxyURL the configured proxy, nil when the environment decides.
+func ProxyURL(proxy string) (*url.URL, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", proxy, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: scheme must be http, https, socks5 or socks5h", proxy)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing host", proxy)
+	}
+
+	return u, nil
+}
+
+func (t *Transport) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	u, err := ProxyURL(t.proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	if u == nil && len(t.noProxy) == 0 {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	cfg := httpproxy.FromEnvironment()
+
+	if u != nil {
+		cfg.HTTPProxy = u.String()
+		cfg.HTTPSProxy = u.String()
+	}
+
+	if len(t.noProxy) > 0 {
+		cfg.NoProxy = strings.Join(t.noProxy, ",")
+	}
+
+	proxyFor := cfg.ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFor(req.URL)
+	}, nil
+}
+
+// tlsConfig nil when nothing differs from the default.
+func (t *Transport) tlsConfig() (*tls.Config, error) {
+	if len(t.caBundles) == 0 && t.certFile == "" && t.keyFile == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if len(t.caBundles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		for _, path := range t.caBundles {
+			pem, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read ca bundle: %w", err)
+			}
+
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in ca bundle %s", path)
+			}
+		}
+
+		cfg.RootCAs = pool
+	}
+
+	if t.certFile != "" || t.keyFile != "" {
+		if t.certFile == "" || t.keyFile == "" {
+			return nil, errors.New("client certificate needs both a certificate and a key file")
+		}
+
+		cert, err := tls.LoadX509KeyPair(t.certFile, t.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w", err)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
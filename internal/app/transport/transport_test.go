@@ -0,0 +1,87 @@
+package transport
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test requests go through the proxy unless the host is excluded
+func TestProxy(t *testing.T) {
+	var proxied atomic.Int32
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+
+	tr, err := New(WithProxy(proxy.URL), WithNoProxy([]string{"internal.example"}))
+	assert.NoError(t, err)
+
+	hc := &http.Client{Transport: tr}
+
+	resp, err := hc.Get("http://example.com/page")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, int32(1), proxied.Load())
+
+	req, _ := http.NewRequest(http.MethodGet, "http://internal.example/page", nil)
+	u, err := tr.Proxy(req)
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+
+	for _, invalid := range []string{"ftp://proxy:21", "http://", "://bad"} {
+		_, err = New(WithProxy(invalid))
+		assert.Error(t, err, invalid)
+	}
+
+	_, err = New(WithProxy("socks5://127.0.0.1:1080"))
+	assert.NoError(t, err)
+}
+
+// Test an extra ca bundle is trusted and a client certificate needs its key
+func TestTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "ca.pem")
+
+	err := os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: srv.Certificate().Raw,
+	}), 0o644)
+	assert.NoError(t, err)
+
+	// without the bundle the test server's certificate isn't trusted
+	tr, err := New()
+	assert.NoError(t, err)
+
+	_, err = (&http.Client{Transport: tr}).Get(srv.URL)
+	assert.Error(t, err)
+
+	tr, err = New(WithCABundles([]string{bundle}))
+	assert.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+
+	empty := filepath.Join(dir, "empty.pem")
+	assert.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0o644))
+
+	_, err = New(WithCABundles([]string{empty}))
+	assert.Error(t, err)
+
+	_, err = New(WithClientCert(bundle, ""))
+	assert.Error(t, err)
+}
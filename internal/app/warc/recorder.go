@@ -0,0 +1,116 @@
+package warc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httputil"
+)
+
+// request headers kept out of the archive
+var secretHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// Recorder writes every request and response that goes through it to a WARC file.
+type Recorder struct {
+	w             *Writer
+	next          http.RoundTripper
+	maxRecordSize int64
+}
+
+// NewRecorder records the exchanges of next, a nil next is the default transport. Responses with
+// bodies bigger than maxRecordSize aren't recorded, zero or less records them all.
+func NewRecorder(w *Writer, next http.RoundTripper, maxRecordSize int64) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Recorder{w: w, next: next, maxRecordSize: maxRecordSize}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := io.Reader(resp.Body)
+	if r.maxRecordSize > 0 {
+		reader = io.LimitReader(resp.Body, r.maxRecordSize+1)
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		_ = resp.Body.Close()
+
+		return nil, err
+	}
+
+	if r.maxRecordSize > 0 && int64(len(body)) > r.maxRecordSize {
+		// too big to record, pass it on with what's already read in front
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+
+		return resp, nil
+	}
+
+	_ = resp.Body.Close()
+
+	// a failed write loses the record, not the analysis
+	_ = r.record(req, resp, body)
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return resp, nil
+}
+
+func (r *Recorder) CloseIdleConnections() {
+	if c, ok := r.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// record writes the response and its request, concurrent to each other.
+func (r *Recorder) record(req *http.Request, resp *http.Response, body []byte) error {
+	// the block is the response as sent, the body is known so it gets a plain length
+	out := *resp
+	out.Header = resp.Header.Clone()
+	out.TransferEncoding = nil
+	out.ContentLength = int64(len(body))
+	out.Body = io.NopCloser(bytes.NewReader(body))
+
+	var block bytes.Buffer
+	if err := out.Write(&block); err != nil {
+		return err
+	}
+
+	response := NewRecord(TypeResponse, block.Bytes())
+	response.Header.Set("WARC-Target-URI", req.URL.String())
+	response.Header.Set("Content-Type", "application/http; msgtype=response")
+
+	if err := r.w.Write(response); err != nil {
+		return err
+	}
+
+	// dumping runs a fake round trip, the client traces of the real request mustn't see it
+	redacted := req.Clone(context.Background())
+	for _, name := range secretHeaders {
+		redacted.Header.Del(name)
+	}
+
+	requestBlock, err := httputil.DumpRequestOut(redacted, false)
+	if err != nil {
+		return err
+	}
+
+	request := NewRecord(TypeRequest, requestBlock)
+	request.Header.Set("WARC-Target-URI", req.URL.String())
+	request.Header.Set("WARC-Concurrent-To", response.Header.Get("WARC-Record-ID"))
+	request.Header.Set("Content-Type", "application/http; msgtype=request")
+
+	return r.w.Write(request)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
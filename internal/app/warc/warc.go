@@ -0,0 +1,206 @@
+package warc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"io"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const version = "WARC/1.1"
+
+// record types the analyzer reads and writes
+const (
+	TypeInfo     = "warcinfo"
+	TypeRequest  = "request"
+	TypeResponse = "response"
+)
+
+// Record one WARC record, Header holds the named fields besides Content-Length.
+type Record struct {
+	Header textproto.MIMEHeader
+	Block  []byte
+}
+
+func (r *Record) Type() string {
+	return r.Header.Get("WARC-Type")
+}
+
+func (r *Record) TargetURI() string {
+	// some writers wrap the uri in angle brackets, as WARC 1.0 did
+	return strings.Trim(r.Header.Get("WARC-Target-URI"), "<>")
+}
+
+// Reader reads the records of a plain or gzipped WARC file.
+type Reader struct {
+	br *bufio.Reader
+	tp *textproto.Reader
+}
+
+// NewReader detects gzip from the magic bytes, a gzipped file is one member per record.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip: %w", err)
+		}
+
+		br = bufio.NewReader(gz)
+	}
+
+	return &Reader{br: br, tp: textproto.NewReader(br)}, nil
+}
+
+// Next the next record, io.EOF after the last one.
+func (r *Reader) Next() (*Record, error) {
+	var line string
+
+	// records are separated by blank lines
+	for line == "" {
+		l, err := r.br.ReadString('\n')
+		if err == io.EOF && strings.TrimSpace(l) == "" {
+			return nil, io.EOF
+		}
+
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		line = strings.TrimSpace(l)
+	}
+
+	if !strings.HasPrefix(line, "WARC/") {
+		return nil, fmt.Errorf("invalid record: expected a WARC version, got %q", line)
+	}
+
+	header, err := r.tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("invalid record header: %w", err)
+	}
+
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid record length %q", header.Get("Content-Length"))
+	}
+
+	block := make([]byte, length)
+	if _, err := io.ReadFull(r.br, block); err != nil {
+		return nil, fmt.Errorf("truncated record: %w", err)
+	}
+
+	header.Del("Content-Length")
+
+	return &Record{Header: header, Block: block}, nil
+}
+
+// Writer writes records, each one in its own gzip member when compressing.
+// It's safe for concurrent use.
+type Writer struct {
+	mu       sync.Mutex
+	w        io.Writer
+	compress bool
+}
+
+func NewWriter(w io.Writer, compress bool) *Writer {
+	return &Writer{w: w, compress: compress}
+}
+
+// NewRecord a record of the type with a new id and the current date.
+func NewRecord(recordType string, block []byte) *Record {
+	header := textproto.MIMEHeader{}
+	header.Set("WARC-Type", recordType)
+	header.Set("WARC-Record-ID", NewRecordID())
+	header.Set("WARC-Date", time.Now().UTC().Format(time.RFC3339))
+
+	return &Record{Header: header, Block: block}
+}
+
+// NewRecordID a urn:uuid record id.
+func NewRecordID() string {
+	var b [16]byte
+
+	_, _ = rand.Read(b[:])
+
+	// version 4, variant 10
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Write writes the record with its length and block digest.
+func (w *Writer) Write(r *Record) error {
+	var buf bytes.Buffer
+
+	digest := sha1.Sum(r.Block)
+
+	buf.WriteString(version + "\r\n")
+
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range r.Header[name] {
+			fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+		}
+	}
+
+	fmt.Fprintf(&buf, "WARC-Block-Digest: sha1:%s\r\n", base32.StdEncoding.EncodeToString(digest[:]))
+	fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n", len(r.Block))
+	buf.Write(r.Block)
+	buf.WriteString("\r\n\r\n")
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.compress {
+		_, err := w.w.Write(buf.Bytes())
+
+		return err
+	}
+
+	gz := gzip.NewWriter(w.w)
+
+	if _, err := gz.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+// WriteInfo writes the warcinfo record that opens a file.
+func (w *Writer) WriteInfo(fields map[string]string) error {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var block bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&block, "%s: %s\r\n", k, fields[k])
+	}
+
+	r := NewRecord(TypeInfo, block.Bytes())
+	r.Header.Set("Content-Type", "application/warc-fields")
+
+	return w.Write(r)
+}
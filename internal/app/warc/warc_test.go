@@ -0,0 +1,90 @@
+package warc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test records written plain and gzipped read back the same
+func TestWriterReader(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		var buf bytes.Buffer
+
+		w := NewWriter(&buf, compress)
+
+		assert.NoError(t, w.WriteInfo(map[string]string{"software": "test"}))
+
+		r := NewRecord(TypeResponse, []byte("HTTP/1.1 200 OK\r\n\r\nbody"))
+		r.Header.Set("WARC-Target-URI", "https://example.com/")
+		assert.NoError(t, w.Write(r))
+
+		reader, err := NewReader(&buf)
+		assert.NoError(t, err)
+
+		info, err := reader.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, TypeInfo, info.Type())
+		assert.Contains(t, string(info.Block), "software: test")
+
+		got, err := reader.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, TypeResponse, got.Type())
+		assert.Equal(t, "https://example.com/", got.TargetURI())
+		assert.Equal(t, r.Block, got.Block)
+		assert.True(t, strings.HasPrefix(got.Header.Get("WARC-Block-Digest"), "sha1:"))
+
+		_, err = reader.Next()
+		assert.True(t, errors.Is(err, io.EOF))
+	}
+
+	reader, err := NewReader(strings.NewReader("not a warc\r\n"))
+	assert.NoError(t, err)
+
+	_, err = reader.Next()
+	assert.Error(t, err)
+}
+
+// Test the recorder archives the response and a request without secrets
+func TestRecorder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<p>recorded</p>"))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+
+	hc := &http.Client{Transport: NewRecorder(NewWriter(&buf, true), nil, 1024)}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/page", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	resp, err := hc.Do(req)
+	assert.NoError(t, err)
+
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, "<p>recorded</p>", string(body))
+
+	reader, err := NewReader(&buf)
+	assert.NoError(t, err)
+
+	response, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, TypeResponse, response.Type())
+	assert.Equal(t, srv.URL+"/page", response.TargetURI())
+	assert.Contains(t, string(response.Block), "<p>recorded</p>")
+
+	request, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, TypeRequest, request.Type())
+	assert.Equal(t, response.Header.Get("WARC-Record-ID"), request.Header.Get("WARC-Concurrent-To"))
+	assert.NotContains(t, string(request.Block), "secret")
+}
@@ -3,13 +3,21 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	flag "github.com/spf13/pflag"
+
+	"github.com/erainogo/html-analyzer/pkg/constants"
 )
 
 const (
 	ReadTimeOut    int = 30
 	WriteTimeOut   int = 30
+	FetchTimeOut   int = 30
+	MaxRedirects   int = 10
+	MaxBodySize    int = 10
+	CacheMaxAge    int = 3600
+	RobotsMaxAge   int = 86400
 	bootupWaitTime int = 5
 )
 
@@ -20,6 +28,69 @@ type Configuration struct {
 	WriteTimeOut   *int
 	ReadTimeOut    *int
 	FEURL          *string
+	Readability    *bool
+
+	DuplicatesReport  *string
+	DuplicateDistance *int
+
+	PolicyFile  *string
+	ExtractFile *string
+
+	Fetcher      *string
+	FetchSource  *string
+	FetchTimeOut *int
+	MaxRedirects *int
+	UserAgent    *string
+	FetchHeaders *[]string
+	MaxBodySize  *int
+
+	FetchMetadata *bool
+
+	CredentialsFile *string
+
+	BaseURL *string
+	Include *[]string
+	Exclude *[]string
+
+	SiteCheck  *bool
+	SiteReport *string
+
+	CrawlDepth    *int
+	CrawlMaxPages *int
+	CrawlInclude  *[]string
+	CrawlExclude  *[]string
+	CrawlSummary  *string
+	CrawlState    *string
+	CrawlStateDir *string
+	CrawlMaxJobs  *int
+
+	Robots       *bool
+	RobotsMaxAge *int
+
+	Sitemap *bool
+
+	HARFile  *string
+	WARCFile *string
+	WARCOut  *string
+
+	RecordFile *string
+	ReplayFile *string
+
+	CacheDir    *string
+	CacheMaxAge *int
+	Offline     *bool
+
+	Proxy      *string
+	NoProxy    *[]string
+	CABundles  *[]string
+	ClientCert *string
+	ClientKey  *string
+
+	SSRFGuard      *bool
+	AllowCIDRs     *[]string
+	DenyCIDRs      *[]string
+	AllowedSchemes *[]string
+	AllowedPorts   *[]string
 }
 
 var (
@@ -55,6 +126,246 @@ var (
 		"http://localhost:5173", // change your front end url
 		"fe url",
 	)
+
+	readability = flag.Bool(
+		"readability",
+		false,
+		"compute readability metrics for the main content")
+
+	duplicatesReport = flag.String(
+		"duplicates-report",
+		"",
+		"cli: write the near duplicate url clusters to this csv file")
+
+	duplicateDistance = flag.Int(
+		"duplicate-distance",
+		constants.DuplicateMaxDistance,
+		"max differing fingerprint bits for two pages to be near duplicates")
+
+	policyFile = flag.String(
+		"policy",
+		"",
+		"yaml or json policy file with the rules, thresholds and pass/fail criteria")
+
+	extractFile = flag.String(
+		"extract",
+		"",
+		"cli: yaml or json file with the extraction rules, one csv column per rule")
+
+	fetcher = flag.String(
+		"fetcher",
+		"http",
+		"how pages are fetched: http, file or fixture")
+
+	fetchSource = flag.String(
+		"fetch-source",
+		"",
+		"root directory for the file fetcher, fixture file for the fixture fetcher")
+
+	fetchTimeOut = flag.Int(
+		"fetch-timeout",
+		FetchTimeOut,
+		"seconds to wait for a page or link")
+
+	maxRedirects = flag.Int(
+		"max-redirects",
+		MaxRedirects,
+		"redirects to follow when fetching a page, 0 to follow none")
+
+	userAgent = flag.String(
+		"user-agent",
+		constants.USERAGENT,
+		"user agent for fetching pages")
+
+	fetchHeaders = flag.StringArray(
+		"header",
+		nil,
+		"extra \"Name: value\" header for fetching pages, repeatable")
+
+	maxBodySize = flag.Int(
+		"max-body-size",
+		MaxBodySize,
+		"largest page to analyze in MiB once decompressed, 0 for no limit")
+
+	fetchMetadata = flag.Bool(
+		"fetch-metadata",
+		false,
+		"cli: add the protocol, remote ip, sizes and timing of each page fetch to the csv")
+
+	credentialsFile = flag.String(
+		"credentials",
+		"",
+		"yaml or json file with the credentials per domain, for the pages and their link checks")
+
+	baseURL = flag.String(
+		"base-url",
+		"",
+		"cli: url local files and stdin are served at, for classifying and resolving their links")
+
+	include = flag.StringSlice(
+		"include",
+		[]string{"*.html", "*.htm"},
+		"cli: file name globs analyzed in an input directory")
+
+	exclude = flag.StringSlice(
+		"exclude",
+		nil,
+		"cli: file name or relative path globs skipped in an input directory")
+
+	siteCheck = flag.Bool(
+		"site",
+		false,
+		"cli: check the input directory as a static site served at --base-url, without network access")
+
+	siteReport = flag.String(
+		"site-report",
+		"",
+		"cli: csv file to write the broken links, missing anchors and orphan pages of --site to")
+
+	crawlDepth = flag.Int(
+		"crawl-depth",
+		constants.CrawlMaxDepth,
+		"crawl: levels of internal links followed from the seed, the most an api crawl may ask for")
+
+	crawlMaxPages = flag.Int(
+		"crawl-max-pages",
+		constants.CrawlMaxPages,
+		"crawl: most pages analyzed per crawl, the most an api crawl may ask for")
+
+	crawlInclude = flag.StringArray(
+		"crawl-include",
+		nil,
+		"crawl: regular expression of the urls to crawl, repeatable, every internal url when empty")
+
+	crawlExclude = flag.StringArray(
+		"crawl-exclude",
+		nil,
+		"crawl: regular expression of the urls not to crawl, repeatable")
+
+	crawlSummary = flag.String(
+		"crawl-summary",
+		"",
+		"cli: write the site level summary of a crawl to this json file")
+
+	crawlState = flag.String(
+		"crawl-state",
+		"",
+		"cli: file to keep the queue and progress of the crawl in, rerun with it to resume a stopped crawl")
+
+	crawlMaxJobs = flag.Int(
+		"crawl-max-jobs",
+		constants.CrawlMaxJobs,
+		"server: most crawl jobs running at once, further ones are refused until one stops")
+
+	crawlStateDir = flag.String(
+		"crawl-state-dir",
+		"",
+		"server: directory to keep the crawl jobs in, so they can be paused and survive restarts")
+
+	robotsTxt = flag.Bool(
+		"robots",
+		false,
+		"honor the robots.txt of the hosts for the user agent when crawling and checking links")
+
+	robotsMaxAge = flag.Int(
+		"robots-max-age",
+		RobotsMaxAge,
+		"seconds a fetched robots.txt is used before it is fetched again")
+
+	sitemapInput = flag.Bool(
+		"sitemap",
+		false,
+		"cli: the input is a site or sitemap url, the pages its sitemaps list are analyzed")
+
+	harFile = flag.String(
+		"har",
+		"",
+		"cli: analyze the html pages of a HAR file instead of an input csv, links use its recorded responses")
+
+	warcFile = flag.String(
+		"warc",
+		"",
+		"cli: analyze the html responses of a plain or gzipped WARC file instead of an input csv")
+
+	warcOut = flag.String(
+		"warc-out",
+		"",
+		"cli: record the fetched pages and link checks to a WARC file, gzipped when it ends in .gz")
+
+	recordFile = flag.String(
+		"record",
+		"",
+		"cli: record every response of the run, link checks included, to a json fixture file")
+
+	replayFile = flag.String(
+		"replay",
+		"",
+		"cli: answer every request of the run from a fixture file made by --record, without the network")
+
+	cacheDir = flag.String(
+		"cache-dir",
+		"",
+		"cli: directory of the http cache for pages and link checks, no cache when empty")
+
+	cacheMaxAge = flag.Int(
+		"cache-max-age",
+		CacheMaxAge,
+		"cli: seconds a cached response without its own max-age or Expires stays fresh")
+
+	offline = flag.Bool(
+		"offline",
+		false,
+		"cli: serve pages and link checks only from the cache")
+
+	proxy = flag.String(
+		"proxy",
+		"",
+		"http, https or socks5 proxy url for outbound requests, HTTP_PROXY and HTTPS_PROXY when empty")
+
+	noProxy = flag.StringSlice(
+		"no-proxy",
+		nil,
+		"hosts, domains and cidrs reached without the proxy")
+
+	caBundles = flag.StringSlice(
+		"ca-bundle",
+		nil,
+		"pem files with extra root certificates to trust")
+
+	clientCert = flag.String(
+		"client-cert",
+		"",
+		"pem client certificate for servers that ask for one")
+
+	clientKey = flag.String(
+		"client-key",
+		"",
+		"pem key of the client certificate")
+
+	ssrfGuard = flag.Bool(
+		"ssrf-guard",
+		true,
+		"server: refuse to fetch private, loopback and link local addresses")
+
+	allowCIDRs = flag.StringSlice(
+		"allow-cidr",
+		nil,
+		"server: ranges the ssrf guard lets through, even if denied")
+
+	denyCIDRs = flag.StringSlice(
+		"deny-cidr",
+		nil,
+		"server: ranges the ssrf guard blocks on top of the private ones")
+
+	allowedSchemes = flag.StringSlice(
+		"allowed-schemes",
+		[]string{"http", "https"},
+		"server: url schemes the ssrf guard allows")
+
+	allowedPorts = flag.StringSlice(
+		"allowed-ports",
+		[]string{"80", "443"},
+		"server: ports the ssrf guard allows, empty for any")
 )
 
 func updateStringEnvVariable(defValue *string, key string) *string {
@@ -81,6 +392,37 @@ func updateIntEnvVariable(defValue *int, key string) *int {
 	return &iVal
 }
 
+func updateBoolEnvVariable(defValue *bool, key string) *bool {
+	sVal := os.Getenv(key)
+	if sVal == "" {
+		return defValue
+	}
+
+	bVal, err := strconv.ParseBool(sVal)
+	if err != nil {
+		return defValue
+	}
+
+	return &bVal
+}
+
+func updateStringSliceEnvVariable(defValue *[]string, key string) *[]string {
+	sVal, ok := os.LookupEnv(key)
+	if !ok {
+		return defValue
+	}
+
+	values := []string{}
+
+	for _, v := range strings.Split(sVal, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return &values
+}
+
 func init() {
 	flag.Parse()
 
@@ -89,6 +431,53 @@ func init() {
 	writeTimeOut = updateIntEnvVariable(writeTimeOut, "WRITE_TIMEOUT")
 	readTimeOut = updateIntEnvVariable(readTimeOut, "READ_TIMEOUT")
 	feUrl = updateStringEnvVariable(feUrl, "FEURL")
+	readability = updateBoolEnvVariable(readability, "READABILITY")
+	duplicatesReport = updateStringEnvVariable(duplicatesReport, "DUPLICATES_REPORT")
+	duplicateDistance = updateIntEnvVariable(duplicateDistance, "DUPLICATE_DISTANCE")
+	policyFile = updateStringEnvVariable(policyFile, "POLICY_FILE")
+	extractFile = updateStringEnvVariable(extractFile, "EXTRACT_RULES")
+	fetcher = updateStringEnvVariable(fetcher, "FETCHER")
+	fetchSource = updateStringEnvVariable(fetchSource, "FETCH_SOURCE")
+	fetchTimeOut = updateIntEnvVariable(fetchTimeOut, "FETCH_TIMEOUT")
+	maxRedirects = updateIntEnvVariable(maxRedirects, "MAX_REDIRECTS")
+	userAgent = updateStringEnvVariable(userAgent, "USER_AGENT")
+	maxBodySize = updateIntEnvVariable(maxBodySize, "MAX_BODY_SIZE")
+	fetchMetadata = updateBoolEnvVariable(fetchMetadata, "FETCH_METADATA")
+	credentialsFile = updateStringEnvVariable(credentialsFile, "CREDENTIALS_FILE")
+	baseURL = updateStringEnvVariable(baseURL, "BASE_URL")
+	include = updateStringSliceEnvVariable(include, "INCLUDE")
+	exclude = updateStringSliceEnvVariable(exclude, "EXCLUDE")
+	siteCheck = updateBoolEnvVariable(siteCheck, "SITE_CHECK")
+	siteReport = updateStringEnvVariable(siteReport, "SITE_REPORT")
+	crawlDepth = updateIntEnvVariable(crawlDepth, "CRAWL_DEPTH")
+	crawlMaxPages = updateIntEnvVariable(crawlMaxPages, "CRAWL_MAX_PAGES")
+	crawlInclude = updateStringSliceEnvVariable(crawlInclude, "CRAWL_INCLUDE")
+	crawlExclude = updateStringSliceEnvVariable(crawlExclude, "CRAWL_EXCLUDE")
+	crawlSummary = updateStringEnvVariable(crawlSummary, "CRAWL_SUMMARY")
+	crawlState = updateStringEnvVariable(crawlState, "CRAWL_STATE")
+	crawlStateDir = updateStringEnvVariable(crawlStateDir, "CRAWL_STATE_DIR")
+	crawlMaxJobs = updateIntEnvVariable(crawlMaxJobs, "CRAWL_MAX_JOBS")
+	robotsTxt = updateBoolEnvVariable(robotsTxt, "ROBOTS")
+	robotsMaxAge = updateIntEnvVariable(robotsMaxAge, "ROBOTS_MAX_AGE")
+	sitemapInput = updateBoolEnvVariable(sitemapInput, "SITEMAP")
+	harFile = updateStringEnvVariable(harFile, "HAR_FILE")
+	warcFile = updateStringEnvVariable(warcFile, "WARC_FILE")
+	warcOut = updateStringEnvVariable(warcOut, "WARC_OUT")
+	recordFile = updateStringEnvVariable(recordFile, "RECORD_FILE")
+	replayFile = updateStringEnvVariable(replayFile, "REPLAY_FILE")
+	cacheDir = updateStringEnvVariable(cacheDir, "CACHE_DIR")
+	cacheMaxAge = updateIntEnvVariable(cacheMaxAge, "CACHE_MAX_AGE")
+	offline = updateBoolEnvVariable(offline, "OFFLINE")
+	proxy = updateStringEnvVariable(proxy, "PROXY_URL")
+	noProxy = updateStringSliceEnvVariable(noProxy, "NO_PROXY_HOSTS")
+	caBundles = updateStringSliceEnvVariable(caBundles, "CA_BUNDLES")
+	clientCert = updateStringEnvVariable(clientCert, "CLIENT_CERT")
+	clientKey = updateStringEnvVariable(clientKey, "CLIENT_KEY")
+	ssrfGuard = updateBoolEnvVariable(ssrfGuard, "SSRF_GUARD")
+	allowCIDRs = updateStringSliceEnvVariable(allowCIDRs, "ALLOW_CIDRS")
+	denyCIDRs = updateStringSliceEnvVariable(denyCIDRs, "DENY_CIDRS")
+	allowedSchemes = updateStringSliceEnvVariable(allowedSchemes, "ALLOWED_SCHEMES")
+	allowedPorts = updateStringSliceEnvVariable(allowedPorts, "ALLOWED_PORTS")
 
 	Config = &Configuration{
 		Prefix:         prefix,
@@ -97,5 +486,68 @@ func init() {
 		ReadTimeOut:    readTimeOut,
 		BootUpWaitTime: bootupWaittime,
 		FEURL:          feUrl,
+		Readability:    readability,
+
+		DuplicatesReport:  duplicatesReport,
+		DuplicateDistance: duplicateDistance,
+
+		PolicyFile:  policyFile,
+		ExtractFile: extractFile,
+
+		Fetcher:      fetcher,
+		FetchSource:  fetchSource,
+		FetchTimeOut: fetchTimeOut,
+		MaxRedirects: maxRedirects,
+		UserAgent:    userAgent,
+		FetchHeaders: fetchHeaders,
+		MaxBodySize:  maxBodySize,
+
+		FetchMetadata: fetchMetadata,
+
+		CredentialsFile: credentialsFile,
+
+		BaseURL: baseURL,
+		Include: include,
+		Exclude: exclude,
+
+		SiteCheck:  siteCheck,
+		SiteReport: siteReport,
+
+		CrawlDepth:    crawlDepth,
+		CrawlMaxPages: crawlMaxPages,
+		CrawlInclude:  crawlInclude,
+		CrawlExclude:  crawlExclude,
+		CrawlSummary:  crawlSummary,
+		CrawlState:    crawlState,
+		CrawlStateDir: crawlStateDir,
+		CrawlMaxJobs:  crawlMaxJobs,
+
+		Robots:       robotsTxt,
+		RobotsMaxAge: robotsMaxAge,
+
+		Sitemap: sitemapInput,
+
+		HARFile:  harFile,
+		WARCFile: warcFile,
+		WARCOut:  warcOut,
+
+		RecordFile: recordFile,
+		ReplayFile: replayFile,
+
+		CacheDir:    cacheDir,
+		CacheMaxAge: cacheMaxAge,
+		Offline:     offline,
+
+		Proxy:      proxy,
+		NoProxy:    noProxy,
+		CABundles:  caBundles,
+		ClientCert: clientCert,
+		ClientKey:  clientKey,
+
+		SSRFGuard:      ssrfGuard,
+		AllowCIDRs:     allowCIDRs,
+		DenyCIDRs:      denyCIDRs,
+		AllowedSchemes: allowedSchemes,
+		AllowedPorts:   allowedPorts,
 	}
 }
@@ -7,5 +7,10 @@ import (
 )
 
 type AnalyzeService interface {
-	Parse(context.Context, []byte, string) (*entities.AnalysisResult, error)
+	// Parse analyzes the page, fetch is how it was fetched, nil when that isn't known
+	Parse(
+		ctx context.Context, html []byte, url string, fetch *entities.FetchMetadata, rules ...entities.ExtractionRule,
+	) (*entities.AnalysisResult, error)
+	// ValidateExtractionRules checks the rules Parse would be given, before the page is fetched
+	ValidateExtractionRules(rules ...entities.ExtractionRule) error
 }
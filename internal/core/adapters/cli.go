@@ -2,8 +2,11 @@ package adapters
 
 import (
 	"context"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
 )
 
 type CliServer interface {
-	Handler(ctx context.Context, url string) ([]string, error)
+	Handler(ctx context.Context, url string) ([]string, *entities.AnalysisResult, error)
+	Row(url string, result *entities.AnalysisResult) []string
 }
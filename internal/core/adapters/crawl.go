@@ -0,0 +1,13 @@
+package adapters
+
+import (
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+type CrawlJobs interface {
+	Start(req entities.CrawlRequest) (*entities.CrawlJob, error)
+	Get(id string) (*entities.CrawlJob, bool)
+	Cancel(id string) bool
+	Pause(id string) (*entities.CrawlJob, error)
+	Resume(id string) (*entities.CrawlJob, error)
+}
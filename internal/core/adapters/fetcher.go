@@ -0,0 +1,11 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (*entities.FetchResponse, error)
+}
@@ -0,0 +1,10 @@
+package adapters
+
+import (
+	"net/http"
+)
+
+// TransportWrapper puts itself in front of a client's transport, to record or replay its responses.
+type TransportWrapper interface {
+	Transport(next http.RoundTripper) http.RoundTripper
+}
@@ -3,19 +3,24 @@ package handlers
 import (
 	"context"
 	"fmt"
-	"io"
-	"net/http"
+	"strings"
 
 	"go.uber.org/zap"
 
 	"github.com/erainogo/html-analyzer/internal/core/adapters"
 	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
 )
 
 type CliServer struct {
 	ctx     context.Context
 	service adapters.AnalyzeService
+	fetcher adapters.Fetcher
 	logger  *zap.SugaredLogger
+	rules   []entities.ExtractionRule
+
+	fetchMetadata bool
+	robots        bool
 }
 
 type CliServerOption func(*CliServer)
@@ -26,12 +31,36 @@ func CliWithLogger(logger *zap.SugaredLogger) CliServerOption {
 	}
 }
 
+// CliWithExtractionRules extracts the rules from every page, one column per rule.
+func CliWithExtractionRules(rules []entities.ExtractionRule) CliServerOption {
+	return func(s *CliServer) {
+		s.rules = rules
+	}
+}
+
+// CliWithFetchMetadata adds the protocol, sizes and timing of the page fetch to the row, empty
+// for pages that weren't fetched over http.
+func CliWithFetchMetadata(enabled bool) CliServerOption {
+	return func(s *CliServer) {
+		s.fetchMetadata = enabled
+	}
+}
+
+// CliWithRobots adds the count of the links robots.txt didn't allow to be checked to the row.
+func CliWithRobots(enabled bool) CliServerOption {
+	return func(s *CliServer) {
+		s.robots = enabled
+	}
+}
+
 func NewCliServer(ctx context.Context,
 	service adapters.AnalyzeService,
+	fetcher adapters.Fetcher,
 	opts ...CliServerOption) adapters.CliServer {
 	c := &CliServer{
 		ctx:     ctx,
 		service: service,
+		fetcher: fetcher,
 	}
 
 	for _, opt := range opts {
@@ -41,32 +70,25 @@ func NewCliServer(ctx context.Context,
 	return c
 }
 
-func (h *CliServer) Handler(ctx context.Context, url string) ([]string, error) {
-	resp, err := getResponse(url)
+// Handler analyzes the url and returns the csv row along with the full result.
+func (h *CliServer) Handler(ctx context.Context, url string) ([]string, *entities.AnalysisResult, error) {
+	resp, err := h.fetcher.Fetch(ctx, url)
 	if err != nil {
-		h.logger.Errorw("Failed to fetch URL: "+err.Error(), http.StatusBadGateway)
+		h.logger.Errorw("Failed to fetch URL", "url", entities.RedactURL(url), "error", err)
 
-		return nil, err
+		return nil, nil, err
 	}
 
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			h.logger.Errorw("unable to close body", "error", err)
-		}
-	}()
-
-	contentBytes, err := io.ReadAll(resp.Body)
+	result, err := h.service.Parse(ctx, resp.Body, resp.URL, resp.Metadata, h.rules...)
 	if err != nil {
-		h.logger.Errorw("Failed to read response body", http.StatusInternalServerError)
-
-		return nil, err
+		return nil, nil, err
 	}
 
-	result, err := h.service.Parse(ctx, contentBytes, url)
-	if err != nil {
-		return nil, err
-	}
+	return h.Row(url, result), result, nil
+}
 
+// Row the csv row of the result of a page.
+func (h *CliServer) Row(url string, result *entities.AnalysisResult) []string {
 	details := []string{
 		url,
 		result.HTMLVersion,
@@ -84,7 +106,46 @@ func (h *CliServer) Handler(ctx context.Context, url string) ([]string, error) {
 		fmt.Sprint(result.Links.Inaccessible),
 
 		fmt.Sprint(result.HasLoginForm),
+
+		fmt.Sprint(result.FindingsSummary.Errors),
+		fmt.Sprint(result.FindingsSummary.Warnings),
+		fmt.Sprint(result.FindingsSummary.Info),
+
+		fmt.Sprint(result.Score.Overall),
+	}
+
+	for _, category := range constants.ScoredCategories {
+		details = append(details, fmt.Sprint(result.Score.Categories[category]))
+	}
+
+	if r := result.Readability; r != nil {
+		details = append(details,
+			fmt.Sprint(r.FleschReadingEase),
+			fmt.Sprint(r.FleschKincaidGrade),
+			fmt.Sprint(r.AverageSentenceLength),
+			fmt.Sprint(len(r.LongSentences)),
+			fmt.Sprint(len(r.LongParagraphs)),
+		)
+	}
+
+	if v := result.Verdict; v != nil {
+		details = append(details,
+			fmt.Sprint(v.Passed),
+			strings.Join(v.Failures, " "),
+		)
+	}
+
+	if h.fetchMetadata {
+		details = append(details, formatFetchMetadata(result.Fetch)...)
+	}
+
+	if h.robots {
+		details = append(details, fmt.Sprint(result.Links.Disallowed))
+	}
+
+	for _, r := range h.rules {
+		details = append(details, formatExtracted(result.Extracted[r.Name]))
 	}
 
-	return details, nil
+	return details
 }
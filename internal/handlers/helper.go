@@ -1,25 +1,38 @@
 package handlers
 
 import (
-	"errors"
-	"net/http"
+	"fmt"
 	"strings"
+
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
 )
 
-func getResponse(url string) (*http.Response, error) {
-	if !strings.HasPrefix(url, "http") {
-		url = "https://" + url
+// formatExtracted flattens an extracted value for a csv cell, list values are joined with " | ".
+func formatExtracted(v any) string {
+	if values, ok := v.([]string); ok {
+		return strings.Join(values, " | ")
 	}
 
-	// call the url
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, errors.New("unable to reach URL")
-	}
+	return fmt.Sprint(v)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("received non-200 status: " + resp.Status)
+// formatFetchMetadata the cells of the fetch metadata columns, empty without metadata.
+func formatFetchMetadata(m *entities.FetchMetadata) []string {
+	if m == nil {
+		return make([]string, len(constants.FetchCsvHeader))
 	}
 
-	return resp, nil
+	return []string{
+		m.FinalURL,
+		m.Protocol,
+		m.RemoteIP,
+		fmt.Sprint(m.CompressedSize),
+		fmt.Sprint(m.UncompressedSize),
+		fmt.Sprint(m.Timing.DNS),
+		fmt.Sprint(m.Timing.Connect),
+		fmt.Sprint(m.Timing.TLSHandshake),
+		fmt.Sprint(m.Timing.TimeToFirstByte),
+		fmt.Sprint(m.Timing.Total),
+	}
 }
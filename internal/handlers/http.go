@@ -3,22 +3,26 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"io"
+	"errors"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/erainogo/html-analyzer/internal/core/adapters"
+	"github.com/erainogo/html-analyzer/pkg/constants"
 	"github.com/erainogo/html-analyzer/pkg/entities"
 )
 
 type HttpServer struct {
 	mux     *http.ServeMux
 	service adapters.AnalyzeService
+	fetcher adapters.Fetcher
 	ctx     context.Context
 	logger  *zap.SugaredLogger
+	crawls  adapters.CrawlJobs
 }
 
 type HttpServerOption func(*HttpServer)
@@ -29,14 +33,23 @@ func WithLogger(logger *zap.SugaredLogger) HttpServerOption {
 	}
 }
 
+// WithCrawlJobs serves the /crawl routes, which run site crawls in the background.
+func WithCrawlJobs(crawls adapters.CrawlJobs) HttpServerOption {
+	return func(s *HttpServer) {
+		s.crawls = crawls
+	}
+}
+
 func NewHTTPServer(
 	ctx context.Context,
 	service adapters.AnalyzeService,
+	fetcher adapters.Fetcher,
 	opts ...HttpServerOption) http.Handler {
 	h := &HttpServer{
 		ctx:     ctx,
 		mux:     http.NewServeMux(),
 		service: service,
+		fetcher: fetcher,
 	}
 
 	for _, opt := range opts {
@@ -52,6 +65,11 @@ func NewHTTPServer(
 func (h *HttpServer) registerRoutes(ctx context.Context) {
 	h.mux.HandleFunc("/analyze", h.analyzeHandler(ctx))
 	h.mux.HandleFunc("/health", h.HealthHandler())
+
+	if h.crawls != nil {
+		h.mux.HandleFunc("/crawl", h.crawlHandler())
+		h.mux.HandleFunc("/crawl/", h.crawlJobHandler())
+	}
 }
 
 // analyzeHandler handler for the /analyze route.
@@ -83,49 +101,77 @@ func (h *HttpServer) analyzeHandler(ctx context.Context) http.HandlerFunc {
 
 		parsedURL, err := url.ParseRequestURI(body.URL)
 		if err != nil {
-			h.logger.Errorw("invalid URL", "url", body.URL, "error", err)
+			h.logger.Errorw("invalid URL", "url", entities.RedactURL(body.URL), "error", err)
 
 			http.Error(w, "Invalid URL format", http.StatusBadRequest)
 
 			return
 		}
 
-		// Safe HTTP request with timeout
-		reqCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-		defer cancel()
+		if body.Credentials != nil {
+			h.logger.Debugw("fetching with credentials", "url", entities.RedactURL(body.URL), "credentials", body.Credentials)
+		}
 
-		req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, parsedURL.String(), nil)
-		if err != nil {
-			h.logger.Errorw("failed to create request", "error", err)
+		if err := h.service.ValidateExtractionRules(body.Extract...); err != nil {
+			h.logger.Warnw("invalid extraction rules", "url", entities.RedactURL(body.URL), "error", err)
 
-			http.Error(w, "Failed to prepare request", http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 
 			return
 		}
 
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			h.logger.Errorw("failed to fetch URL", "url", parsedURL.String(), "error", err)
+		// the credentials go with the page fetch and the link checks to the same host
+		host := parsedURL.Hostname()
 
-			http.Error(w, "Failed to fetch the provided URL", http.StatusBadGateway)
+		// the fetcher applies the configured timeout and redirect policy
+		resp, err := h.fetcher.Fetch(
+			entities.ContextWithCredentials(r.Context(), host, body.Credentials), parsedURL.String())
+		if errors.Is(err, entities.ErrDestinationBlocked) {
+			h.logger.Warnw("blocked destination", "url", entities.RedactURL(body.URL), "error", err)
+
+			http.Error(w, err.Error(), http.StatusForbidden)
+
+			return
+		}
+
+		if errors.Is(err, entities.ErrBodyTooLarge) {
+			h.logger.Warnw("page too large", "url", entities.RedactURL(body.URL), "error", err)
+
+			// the page is what can't be processed, not the request
+			h.writeJSON(w, http.StatusUnprocessableEntity,
+				entities.ErrorResponse{Code: constants.ErrCodePageTooLarge, Error: err.Error()})
+
+			return
+		}
+
+		if errors.Is(err, entities.ErrUnsupportedContentType) {
+			h.logger.Warnw("page is not html", "url", entities.RedactURL(body.URL), "error", err)
+
+			h.writeJSON(w, http.StatusUnprocessableEntity,
+				entities.ErrorResponse{Code: constants.ErrCodeUnsupportedContentType, Error: err.Error()})
 
 			return
 		}
-		defer resp.Body.Close()
 
-		contentBytes, err := io.ReadAll(resp.Body)
 		if err != nil {
-			h.logger.Errorw("failed to read response body", "error", err)
+			h.logger.Errorw("failed to fetch URL", "url", entities.RedactURL(body.URL), "error", err)
 
-			http.Error(w, "Failed to read response", http.StatusInternalServerError)
+			http.Error(w, "Failed to fetch the provided URL", http.StatusBadGateway)
 
 			return
 		}
 
 		// call with both HTML content and URL
-		result, err := h.service.Parse(ctx, contentBytes, body.URL)
+		ctx := entities.ContextWithCredentials(ctx, host, body.Credentials)
+
+		// the request can turn the robots.txt checks of the links on or off for its run
+		if body.Robots != nil {
+			ctx = entities.ContextWithRobots(ctx, *body.Robots)
+		}
+
+		result, err := h.service.Parse(ctx, resp.Body, body.URL, resp.Metadata, body.Extract...)
 		if err != nil {
-			h.logger.Errorw("parsing failed", "url", body.URL, "error", err)
+			h.logger.Errorw("parsing failed", "url", entities.RedactURL(body.URL), "error", err)
 
 			http.Error(w, "Failed to analyze content", http.StatusBadGateway)
 
@@ -144,6 +190,147 @@ func (h *HttpServer) analyzeHandler(ctx context.Context) http.HandlerFunc {
 	}
 }
 
+// crawlHandler handler for the /crawl route, starts a crawl job.
+func (h *HttpServer) crawlHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		var body entities.CrawlRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			h.logger.Errorw("failed to decode request body", "error", err)
+
+			http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+
+			return
+		}
+
+		job, err := h.crawls.Start(body)
+		if errors.Is(err, entities.ErrInvalidCrawlRequest) {
+			h.logger.Warnw("invalid crawl request", "url", entities.RedactURL(body.URL), "error", err)
+
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		if errors.Is(err, entities.ErrTooManyCrawls) {
+			h.logger.Warnw("crawl refused", "url", entities.RedactURL(body.URL), "error", err)
+
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+
+			return
+		}
+
+		if err != nil {
+			h.logger.Errorw("failed to start crawl", "url", entities.RedactURL(body.URL), "error", err)
+
+			http.Error(w, "Failed to start the crawl", http.StatusInternalServerError)
+
+			return
+		}
+
+		h.writeJSON(w, http.StatusAccepted, job)
+	}
+}
+
+// crawlJobHandler handler for the /crawl/{id} routes: GET returns the progress of a crawl job,
+// DELETE cancels it, and POST to /crawl/{id}/pause and /crawl/{id}/resume pauses and resumes it.
+func (h *HttpServer) crawlJobHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/crawl/"), "/")
+
+		if action != "" {
+			h.crawlActionHandler(w, r, id, action)
+
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodDelete:
+			h.crawls.Cancel(id)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		job, ok := h.crawls.Get(id)
+		if !ok {
+			http.Error(w, "Crawl not found", http.StatusNotFound)
+
+			return
+		}
+
+		h.writeJSON(w, http.StatusOK, job)
+	}
+}
+
+// crawlActionHandler pauses or resumes a crawl job.
+func (h *HttpServer) crawlActionHandler(w http.ResponseWriter, r *http.Request, id, action string) {
+	var do func(string) (*entities.CrawlJob, error)
+
+	switch action {
+	case "pause":
+		do = h.crawls.Pause
+	case "resume":
+		do = h.crawls.Resume
+	default:
+		http.NotFound(w, r)
+
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	job, err := do(id)
+	if errors.Is(err, entities.ErrCrawlNotFound) {
+		http.Error(w, "Crawl not found", http.StatusNotFound)
+
+		return
+	}
+
+	if errors.Is(err, entities.ErrCrawlConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+
+		return
+	}
+
+	if errors.Is(err, entities.ErrTooManyCrawls) {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+
+		return
+	}
+
+	if err != nil {
+		h.logger.Errorw("failed to "+action+" crawl", "id", id, "error", err)
+
+		http.Error(w, "Failed to "+action+" the crawl", http.StatusInternalServerError)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusAccepted, job)
+}
+
+func (h *HttpServer) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Errorw("failed to encode response", "error", err)
+	}
+}
+
 // HealthHandler handler for the /health route.
 func (h *HttpServer) HealthHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
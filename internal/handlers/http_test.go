@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/erainogo/html-analyzer/internal/app/fetchers"
+	"github.com/erainogo/html-analyzer/mocks/adapters"
+	"github.com/erainogo/html-analyzer/pkg/constants"
+	"github.com/erainogo/html-analyzer/pkg/entities"
+)
+
+func newTestServer(t *testing.T, opts ...HttpServerOption) (http.Handler, *adapters.MockFetcher, *adapters.MockAnalyzeService) {
+	t.Helper()
+
+	fetcher := adapters.NewMockFetcher(t)
+	service := adapters.NewMockAnalyzeService(t)
+
+	server := NewHTTPServer(context.Background(), service, fetcher,
+		append([]HttpServerOption{WithLogger(zap.NewNop().Sugar())}, opts...)...)
+
+	return server, fetcher, service
+}
+
+func errOf(err error) *error {
+	return &err
+}
+
+func serve(server http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+
+	return w
+}
+
+// Test the fetch and analysis errors of /analyze map to their status codes
+func TestAnalyzeHandler(t *testing.T) {
+	const page = `{"url": "https://example.com/page"}`
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		// validated, fetched and parsed the errors of the rule check, the fetch and the analysis, when
+		// they are called
+		validated *error
+		fetched   *error
+		parsed    *error
+		status    int
+		// code of the error response, for the errors told apart by it
+		code string
+	}{
+		{
+			name: "Analyzed", method: http.MethodPost, body: page,
+			validated: errOf(nil), fetched: errOf(nil), parsed: errOf(nil), status: http.StatusOK,
+		},
+		{name: "Wrong method", method: http.MethodGet, status: http.StatusMethodNotAllowed},
+		{name: "Invalid json", method: http.MethodPost, body: "{", status: http.StatusBadRequest},
+		{name: "Empty url", method: http.MethodPost, body: `{"url": ""}`, status: http.StatusBadRequest},
+		{
+			name: "Blocked destination", method: http.MethodPost, body: page, validated: errOf(nil),
+			fetched: errOf(fmt.Errorf("dial: %w", entities.ErrDestinationBlocked)), status: http.StatusForbidden,
+		},
+		{
+			name: "Page too large", method: http.MethodPost, body: page, validated: errOf(nil),
+			fetched: errOf(entities.ErrBodyTooLarge), status: http.StatusUnprocessableEntity,
+			code: constants.ErrCodePageTooLarge,
+		},
+		{
+			name: "Not html", method: http.MethodPost, body: page, validated: errOf(nil),
+			fetched: errOf(entities.ErrUnsupportedContentType), status: http.StatusUnprocessableEntity,
+			code: constants.ErrCodeUnsupportedContentType,
+		},
+		{
+			name: "Fetch failed", method: http.MethodPost, body: page, validated: errOf(nil),
+			fetched: errOf(errors.New("connection refused")), status: http.StatusBadGateway,
+		},
+		{
+			// the page isn't fetched
+			name: "Invalid extraction rule", method: http.MethodPost, body: page,
+			validated: errOf(entities.ErrInvalidExtractionRule), status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, fetcher, service := newTestServer(t)
+
+			if tt.validated != nil {
+				service.EXPECT().ValidateExtractionRules().Return(*tt.validated)
+			}
+
+			if tt.fetched != nil {
+				fetcher.EXPECT().Fetch(mock.Anything, "https://example.com/page").
+					Return(&entities.FetchResponse{Body: []byte("<html></html>")}, *tt.fetched)
+			}
+
+			if tt.parsed != nil {
+				service.EXPECT().Parse(mock.Anything, []byte("<html></html>"), "https://example.com/page", mock.Anything).
+					Return(&entities.AnalysisResult{Title: "page"}, *tt.parsed)
+			}
+
+			w := serve(server, tt.method, "/analyze", tt.body)
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+
+			if tt.status == http.StatusOK {
+				var result entities.AnalysisResult
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+				assert.Equal(t, "page", result.Title)
+			}
+
+			if tt.code != "" {
+				var resp entities.ErrorResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tt.code, resp.Code)
+			}
+		})
+	}
+}
+
+// Test the credentials of a request reach the page without a credentials file
+func TestAnalyzeHandlerCredentials(t *testing.T) {
+	var authorization string
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer upstream.Close()
+
+	// the clients of the cli and the server always add the credentials, with an empty store here
+	store, err := fetchers.LoadCredentials("")
+	assert.NoError(t, err)
+
+	hc := &http.Client{Transport: fetchers.NewCredentialTransport(upstream.Client().Transport, store)}
+
+	fetcher, err := fetchers.New(fetchers.KindHTTP, "", hc, 1<<20)
+	assert.NoError(t, err)
+
+	service := adapters.NewMockAnalyzeService(t)
+	service.EXPECT().ValidateExtractionRules().Return(nil)
+	service.EXPECT().Parse(mock.Anything, []byte("<html></html>"), upstream.URL+"/account", mock.Anything).
+		Return(&entities.AnalysisResult{}, nil)
+
+	server := NewHTTPServer(context.Background(), service, fetcher, WithLogger(zap.NewNop().Sugar()))
+
+	w := serve(server, http.MethodPost, "/analyze",
+		`{"url": "`+upstream.URL+`/account", "credentials": {"basic": {"username": "me", "password": "secret"}}}`)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "Basic bWU6c2VjcmV0", authorization)
+}
+
+// Test /crawl starts crawl jobs
+func TestCrawlHandler(t *testing.T) {
+	crawls := adapters.NewMockCrawlJobs(t)
+	server, _, _ := newTestServer(t, WithCrawlJobs(crawls))
+
+	crawls.EXPECT().Start(entities.CrawlRequest{URL: "https://example.com", MaxDepth: 2}).
+		Return(&entities.CrawlJob{ID: "job-1", Status: entities.CrawlQueued}, nil)
+	crawls.EXPECT().Start(entities.CrawlRequest{URL: "ftp://example.com"}).
+		Return(nil, entities.ErrInvalidCrawlRequest)
+	crawls.EXPECT().Start(entities.CrawlRequest{URL: "https://busy.example"}).
+		Return(nil, fmt.Errorf("%w: 4 of 4", entities.ErrTooManyCrawls))
+
+	w := serve(server, http.MethodPost, "/crawl", `{"url": "https://example.com", "maxDepth": 2}`)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var job entities.CrawlJob
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(t, "job-1", job.ID)
+
+	w = serve(server, http.MethodPost, "/crawl", `{"url": "ftp://example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(server, http.MethodPost, "/crawl", `{"url": "https://busy.example"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	w = serve(server, http.MethodPost, "/crawl", "{")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(server, http.MethodGet, "/crawl", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+// Test the /crawl/{id} routes get, cancel, pause and resume a job
+func TestCrawlJobHandler(t *testing.T) {
+	crawls := adapters.NewMockCrawlJobs(t)
+	server, _, _ := newTestServer(t, WithCrawlJobs(crawls))
+
+	running := &entities.CrawlJob{ID: "job-1", Status: entities.CrawlRunning}
+	paused := &entities.CrawlJob{ID: "job-1", Status: entities.CrawlPaused}
+
+	crawls.EXPECT().Get("job-1").Return(running, true).Once()
+	crawls.EXPECT().Get("unknown").Return(nil, false)
+	crawls.EXPECT().Cancel("job-1").Return(true)
+	crawls.EXPECT().Get("job-1").Return(&entities.CrawlJob{ID: "job-1", Status: entities.CrawlCancelled}, true).Once()
+	crawls.EXPECT().Pause("job-1").Return(paused, nil)
+	crawls.EXPECT().Pause("done").Return(nil, fmt.Errorf("%w: job is done", entities.ErrCrawlConflict))
+	crawls.EXPECT().Resume("job-1").Return(running, nil)
+	crawls.EXPECT().Resume("unknown").Return(nil, entities.ErrCrawlNotFound)
+	crawls.EXPECT().Resume("busy").Return(nil, entities.ErrTooManyCrawls)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		want   entities.CrawlStatus
+	}{
+		{name: "Get", method: http.MethodGet, path: "/crawl/job-1", status: http.StatusOK, want: entities.CrawlRunning},
+		{name: "Get unknown", method: http.MethodGet, path: "/crawl/unknown", status: http.StatusNotFound},
+		{name: "Cancel", method: http.MethodDelete, path: "/crawl/job-1", status: http.StatusOK, want: entities.CrawlCancelled},
+		{name: "Wrong method", method: http.MethodPut, path: "/crawl/job-1", status: http.StatusMethodNotAllowed},
+		{name: "Pause", method: http.MethodPost, path: "/crawl/job-1/pause", status: http.StatusAccepted, want: entities.CrawlPaused},
+		{name: "Pause conflict", method: http.MethodPost, path: "/crawl/done/pause", status: http.StatusConflict},
+		{name: "Pause with get", method: http.MethodGet, path: "/crawl/job-1/pause", status: http.StatusMethodNotAllowed},
+		{name: "Resume", method: http.MethodPost, path: "/crawl/job-1/resume", status: http.StatusAccepted, want: entities.CrawlRunning},
+		{name: "Resume unknown", method: http.MethodPost, path: "/crawl/unknown/resume", status: http.StatusNotFound},
+		{name: "Resume too many", method: http.MethodPost, path: "/crawl/busy/resume", status: http.StatusTooManyRequests},
+		{name: "Unknown action", method: http.MethodPost, path: "/crawl/job-1/restart", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		w := serve(server, tt.method, tt.path, "")
+
+		assert.Equal(t, tt.status, w.Code, tt.name)
+
+		if tt.want != "" {
+			var job entities.CrawlJob
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &job), tt.name)
+			assert.Equal(t, tt.want, job.Status, tt.name)
+		}
+	}
+}
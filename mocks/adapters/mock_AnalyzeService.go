@@ -1,4 +1,4 @@
-// Code generated by mockery v2.53.3. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package adapters
 
@@ -22,9 +22,16 @@ func (_m *MockAnalyzeService) EXPECT() *MockAnalyzeService_Expecter {
 	return &MockAnalyzeService_Expecter{mock: &_m.Mock}
 }
 
-// Parse provides a mock function with given fields: _a0, _a1, _a2
-func (_m *MockAnalyzeService) Parse(_a0 context.Context, _a1 []byte, _a2 string) (*entities.AnalysisResult, error) {
-	ret := _m.Called(_a0, _a1, _a2)
+// Parse provides a mock function with given fields: ctx, html, url, fetch, rules
+func (_m *MockAnalyzeService) Parse(ctx context.Context, html []byte, url string, fetch *entities.FetchMetadata, rules ...entities.ExtractionRule) (*entities.AnalysisResult, error) {
+	_va := make([]interface{}, len(rules))
+	for _i := range rules {
+		_va[_i] = rules[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, html, url, fetch)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Parse")
@@ -32,19 +39,19 @@ func (_m *MockAnalyzeService) Parse(_a0 context.Context, _a1 []byte, _a2 string)
 
 	var r0 *entities.AnalysisResult
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, []byte, string) (*entities.AnalysisResult, error)); ok {
-		return rf(_a0, _a1, _a2)
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, string, *entities.FetchMetadata, ...entities.ExtractionRule) (*entities.AnalysisResult, error)); ok {
+		return rf(ctx, html, url, fetch, rules...)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, []byte, string) *entities.AnalysisResult); ok {
-		r0 = rf(_a0, _a1, _a2)
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, string, *entities.FetchMetadata, ...entities.ExtractionRule) *entities.AnalysisResult); ok {
+		r0 = rf(ctx, html, url, fetch, rules...)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entities.AnalysisResult)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, []byte, string) error); ok {
-		r1 = rf(_a0, _a1, _a2)
+	if rf, ok := ret.Get(1).(func(context.Context, []byte, string, *entities.FetchMetadata, ...entities.ExtractionRule) error); ok {
+		r1 = rf(ctx, html, url, fetch, rules...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -58,16 +65,25 @@ type MockAnalyzeService_Parse_Call struct {
 }
 
 // Parse is a helper method to define mock.On call
-//   - _a0 context.Context
-//   - _a1 []byte
-//   - _a2 string
-func (_e *MockAnalyzeService_Expecter) Parse(_a0 interface{}, _a1 interface{}, _a2 interface{}) *MockAnalyzeService_Parse_Call {
-	return &MockAnalyzeService_Parse_Call{Call: _e.mock.On("Parse", _a0, _a1, _a2)}
+//   - ctx context.Context
+//   - html []byte
+//   - url string
+//   - fetch *entities.FetchMetadata
+//   - rules ...entities.ExtractionRule
+func (_e *MockAnalyzeService_Expecter) Parse(ctx interface{}, html interface{}, url interface{}, fetch interface{}, rules ...interface{}) *MockAnalyzeService_Parse_Call {
+	return &MockAnalyzeService_Parse_Call{Call: _e.mock.On("Parse",
+		append([]interface{}{ctx, html, url, fetch}, rules...)...)}
 }
 
-func (_c *MockAnalyzeService_Parse_Call) Run(run func(_a0 context.Context, _a1 []byte, _a2 string)) *MockAnalyzeService_Parse_Call {
+func (_c *MockAnalyzeService_Parse_Call) Run(run func(ctx context.Context, html []byte, url string, fetch *entities.FetchMetadata, rules ...entities.ExtractionRule)) *MockAnalyzeService_Parse_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].([]byte), args[2].(string))
+		variadicArgs := make([]entities.ExtractionRule, len(args)-4)
+		for i, a := range args[4:] {
+			if a != nil {
+				variadicArgs[i] = a.(entities.ExtractionRule)
+			}
+		}
+		run(args[0].(context.Context), args[1].([]byte), args[2].(string), args[3].(*entities.FetchMetadata), variadicArgs...)
 	})
 	return _c
 }
@@ -77,7 +93,66 @@ func (_c *MockAnalyzeService_Parse_Call) Return(_a0 *entities.AnalysisResult, _a
 	return _c
 }
 
-func (_c *MockAnalyzeService_Parse_Call) RunAndReturn(run func(context.Context, []byte, string) (*entities.AnalysisResult, error)) *MockAnalyzeService_Parse_Call {
+func (_c *MockAnalyzeService_Parse_Call) RunAndReturn(run func(context.Context, []byte, string, *entities.FetchMetadata, ...entities.ExtractionRule) (*entities.AnalysisResult, error)) *MockAnalyzeService_Parse_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ValidateExtractionRules provides a mock function with given fields: rules
+func (_m *MockAnalyzeService) ValidateExtractionRules(rules ...entities.ExtractionRule) error {
+	_va := make([]interface{}, len(rules))
+	for _i := range rules {
+		_va[_i] = rules[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateExtractionRules")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(...entities.ExtractionRule) error); ok {
+		r0 = rf(rules...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockAnalyzeService_ValidateExtractionRules_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ValidateExtractionRules'
+type MockAnalyzeService_ValidateExtractionRules_Call struct {
+	*mock.Call
+}
+
+// ValidateExtractionRules is a helper method to define mock.On call
+//   - rules ...entities.ExtractionRule
+func (_e *MockAnalyzeService_Expecter) ValidateExtractionRules(rules ...interface{}) *MockAnalyzeService_ValidateExtractionRules_Call {
+	return &MockAnalyzeService_ValidateExtractionRules_Call{Call: _e.mock.On("ValidateExtractionRules",
+		append([]interface{}{}, rules...)...)}
+}
+
+func (_c *MockAnalyzeService_ValidateExtractionRules_Call) Run(run func(rules ...entities.ExtractionRule)) *MockAnalyzeService_ValidateExtractionRules_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]entities.ExtractionRule, len(args)-0)
+		for i, a := range args[0:] {
+			if a != nil {
+				variadicArgs[i] = a.(entities.ExtractionRule)
+			}
+		}
+		run(variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockAnalyzeService_ValidateExtractionRules_Call) Return(_a0 error) *MockAnalyzeService_ValidateExtractionRules_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockAnalyzeService_ValidateExtractionRules_Call) RunAndReturn(run func(...entities.ExtractionRule) error) *MockAnalyzeService_ValidateExtractionRules_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -1,10 +1,11 @@
-// Code generated by mockery v2.53.3. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package adapters
 
 import (
 	context "context"
 
+	entities "github.com/erainogo/html-analyzer/pkg/entities"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -22,33 +23,42 @@ func (_m *MockCliServer) EXPECT() *MockCliServer_Expecter {
 }
 
 // Handler provides a mock function with given fields: ctx, url
-func (_m *MockCliServer) Handler(ctx context.Context, url string) (*[]string, error) {
+func (_m *MockCliServer) Handler(ctx context.Context, url string) ([]string, *entities.AnalysisResult, error) {
 	ret := _m.Called(ctx, url)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Handler")
 	}
 
-	var r0 *[]string
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*[]string, error)); ok {
+	var r0 []string
+	var r1 *entities.AnalysisResult
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]string, *entities.AnalysisResult, error)); ok {
 		return rf(ctx, url)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *[]string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
 		r0 = rf(ctx, url)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*[]string)
+			r0 = ret.Get(0).([]string)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, string) *entities.AnalysisResult); ok {
 		r1 = rf(ctx, url)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*entities.AnalysisResult)
+		}
 	}
 
-	return r0, r1
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, url)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // MockCliServer_Handler_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Handler'
@@ -70,12 +80,61 @@ func (_c *MockCliServer_Handler_Call) Run(run func(ctx context.Context, url stri
 	return _c
 }
 
-func (_c *MockCliServer_Handler_Call) Return(_a0 *[]string, _a1 error) *MockCliServer_Handler_Call {
-	_c.Call.Return(_a0, _a1)
+func (_c *MockCliServer_Handler_Call) Return(_a0 []string, _a1 *entities.AnalysisResult, _a2 error) *MockCliServer_Handler_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockCliServer_Handler_Call) RunAndReturn(run func(context.Context, string) ([]string, *entities.AnalysisResult, error)) *MockCliServer_Handler_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Row provides a mock function with given fields: url, result
+func (_m *MockCliServer) Row(url string, result *entities.AnalysisResult) []string {
+	ret := _m.Called(url, result)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Row")
+	}
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(string, *entities.AnalysisResult) []string); ok {
+		r0 = rf(url, result)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
+// MockCliServer_Row_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Row'
+type MockCliServer_Row_Call struct {
+	*mock.Call
+}
+
+// Row is a helper method to define mock.On call
+//   - url string
+//   - result *entities.AnalysisResult
+func (_e *MockCliServer_Expecter) Row(url interface{}, result interface{}) *MockCliServer_Row_Call {
+	return &MockCliServer_Row_Call{Call: _e.mock.On("Row", url, result)}
+}
+
+func (_c *MockCliServer_Row_Call) Run(run func(url string, result *entities.AnalysisResult)) *MockCliServer_Row_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(*entities.AnalysisResult))
+	})
+	return _c
+}
+
+func (_c *MockCliServer_Row_Call) Return(_a0 []string) *MockCliServer_Row_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockCliServer_Handler_Call) RunAndReturn(run func(context.Context, string) (*[]string, error)) *MockCliServer_Handler_Call {
+func (_c *MockCliServer_Row_Call) RunAndReturn(run func(string, *entities.AnalysisResult) []string) *MockCliServer_Row_Call {
 	_c.Call.Return(run)
 	return _c
 }
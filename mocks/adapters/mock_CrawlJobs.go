@@ -0,0 +1,313 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package adapters
+
+import (
+	entities "github.com/erainogo/html-analyzer/pkg/entities"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockCrawlJobs is an autogenerated mock type for the CrawlJobs type
+type MockCrawlJobs struct {
+	mock.Mock
+}
+
+type MockCrawlJobs_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockCrawlJobs) EXPECT() *MockCrawlJobs_Expecter {
+	return &MockCrawlJobs_Expecter{mock: &_m.Mock}
+}
+
+// Cancel provides a mock function with given fields: id
+func (_m *MockCrawlJobs) Cancel(id string) bool {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Cancel")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// MockCrawlJobs_Cancel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Cancel'
+type MockCrawlJobs_Cancel_Call struct {
+	*mock.Call
+}
+
+// Cancel is a helper method to define mock.On call
+//   - id string
+func (_e *MockCrawlJobs_Expecter) Cancel(id interface{}) *MockCrawlJobs_Cancel_Call {
+	return &MockCrawlJobs_Cancel_Call{Call: _e.mock.On("Cancel", id)}
+}
+
+func (_c *MockCrawlJobs_Cancel_Call) Run(run func(id string)) *MockCrawlJobs_Cancel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockCrawlJobs_Cancel_Call) Return(_a0 bool) *MockCrawlJobs_Cancel_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockCrawlJobs_Cancel_Call) RunAndReturn(run func(string) bool) *MockCrawlJobs_Cancel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Get provides a mock function with given fields: id
+func (_m *MockCrawlJobs) Get(id string) (*entities.CrawlJob, bool) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 *entities.CrawlJob
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(string) (*entities.CrawlJob, bool)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *entities.CrawlJob); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.CrawlJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) bool); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// MockCrawlJobs_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type MockCrawlJobs_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - id string
+func (_e *MockCrawlJobs_Expecter) Get(id interface{}) *MockCrawlJobs_Get_Call {
+	return &MockCrawlJobs_Get_Call{Call: _e.mock.On("Get", id)}
+}
+
+func (_c *MockCrawlJobs_Get_Call) Run(run func(id string)) *MockCrawlJobs_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockCrawlJobs_Get_Call) Return(_a0 *entities.CrawlJob, _a1 bool) *MockCrawlJobs_Get_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCrawlJobs_Get_Call) RunAndReturn(run func(string) (*entities.CrawlJob, bool)) *MockCrawlJobs_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Pause provides a mock function with given fields: id
+func (_m *MockCrawlJobs) Pause(id string) (*entities.CrawlJob, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Pause")
+	}
+
+	var r0 *entities.CrawlJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*entities.CrawlJob, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *entities.CrawlJob); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.CrawlJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCrawlJobs_Pause_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Pause'
+type MockCrawlJobs_Pause_Call struct {
+	*mock.Call
+}
+
+// Pause is a helper method to define mock.On call
+//   - id string
+func (_e *MockCrawlJobs_Expecter) Pause(id interface{}) *MockCrawlJobs_Pause_Call {
+	return &MockCrawlJobs_Pause_Call{Call: _e.mock.On("Pause", id)}
+}
+
+func (_c *MockCrawlJobs_Pause_Call) Run(run func(id string)) *MockCrawlJobs_Pause_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockCrawlJobs_Pause_Call) Return(_a0 *entities.CrawlJob, _a1 error) *MockCrawlJobs_Pause_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCrawlJobs_Pause_Call) RunAndReturn(run func(string) (*entities.CrawlJob, error)) *MockCrawlJobs_Pause_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Resume provides a mock function with given fields: id
+func (_m *MockCrawlJobs) Resume(id string) (*entities.CrawlJob, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Resume")
+	}
+
+	var r0 *entities.CrawlJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*entities.CrawlJob, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *entities.CrawlJob); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.CrawlJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCrawlJobs_Resume_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Resume'
+type MockCrawlJobs_Resume_Call struct {
+	*mock.Call
+}
+
+// Resume is a helper method to define mock.On call
+//   - id string
+func (_e *MockCrawlJobs_Expecter) Resume(id interface{}) *MockCrawlJobs_Resume_Call {
+	return &MockCrawlJobs_Resume_Call{Call: _e.mock.On("Resume", id)}
+}
+
+func (_c *MockCrawlJobs_Resume_Call) Run(run func(id string)) *MockCrawlJobs_Resume_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockCrawlJobs_Resume_Call) Return(_a0 *entities.CrawlJob, _a1 error) *MockCrawlJobs_Resume_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCrawlJobs_Resume_Call) RunAndReturn(run func(string) (*entities.CrawlJob, error)) *MockCrawlJobs_Resume_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Start provides a mock function with given fields: req
+func (_m *MockCrawlJobs) Start(req entities.CrawlRequest) (*entities.CrawlJob, error) {
+	ret := _m.Called(req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Start")
+	}
+
+	var r0 *entities.CrawlJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(entities.CrawlRequest) (*entities.CrawlJob, error)); ok {
+		return rf(req)
+	}
+	if rf, ok := ret.Get(0).(func(entities.CrawlRequest) *entities.CrawlJob); ok {
+		r0 = rf(req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.CrawlJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(entities.CrawlRequest) error); ok {
+		r1 = rf(req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCrawlJobs_Start_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Start'
+type MockCrawlJobs_Start_Call struct {
+	*mock.Call
+}
+
+// Start is a helper method to define mock.On call
+//   - req entities.CrawlRequest
+func (_e *MockCrawlJobs_Expecter) Start(req interface{}) *MockCrawlJobs_Start_Call {
+	return &MockCrawlJobs_Start_Call{Call: _e.mock.On("Start", req)}
+}
+
+func (_c *MockCrawlJobs_Start_Call) Run(run func(req entities.CrawlRequest)) *MockCrawlJobs_Start_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(entities.CrawlRequest))
+	})
+	return _c
+}
+
+func (_c *MockCrawlJobs_Start_Call) Return(_a0 *entities.CrawlJob, _a1 error) *MockCrawlJobs_Start_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCrawlJobs_Start_Call) RunAndReturn(run func(entities.CrawlRequest) (*entities.CrawlJob, error)) *MockCrawlJobs_Start_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockCrawlJobs creates a new instance of MockCrawlJobs. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockCrawlJobs(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockCrawlJobs {
+	mock := &MockCrawlJobs{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -1,10 +1,28 @@
 package constants
 
+import "time"
+
 const (
 	WorkerCount    = 10
 	HeaderCount    = 6
 	CLIWorkerCount = 100
-	ARGS           = 3
+)
+
+// crawl defaults, the API never goes past the configured limits
+const (
+	CrawlWorkerCount = 5
+	CrawlMaxDepth    = 3
+	CrawlMaxPages    = 100
+	// finished crawl jobs kept for the API, the oldest are dropped first
+	CrawlJobsKept = 100
+	// crawl jobs of the API running at once
+	CrawlMaxJobs = 4
+)
+
+// robots.txt limits, as in RFC 9309
+const (
+	RobotsMaxAge  = 24 * time.Hour
+	RobotsMaxSize = 500 << 10
 )
 
 const (
@@ -21,6 +39,142 @@ const (
 	USERAGENT        = "Mozilla/5.0 (compatible; LinkChecker/1.0)"
 )
 
+// readability thresholds, in words
+const (
+	LongSentenceWords  = 25
+	LongParagraphWords = 150
+)
+
+// near duplicate detection
+const (
+	ShingleSize          = 3
+	DuplicateMaxDistance = 3
+)
+
+// sitemap protocol limits
+const (
+	SitemapMaxEntries   = 50000
+	SitemapMaxSize      = 50 << 20
+	SitemapMaxURLLength = 2048
+	SitemapWorkerCount  = 10
+	// most of a listed page read for its robots meta tag and canonical link
+	SitemapMaxPageSize = 10 << 20
+)
+
+// codes of the api error responses
+const (
+	ErrCodePageTooLarge           = "page_too_large"
+	ErrCodeUnsupportedContentType = "unsupported_content_type"
+)
+
+// sitemap issues
+const (
+	SitemapUnreachable   = "unreachable-sitemap"
+	SitemapInvalid       = "invalid-sitemap"
+	SitemapTooLarge      = "too-large"
+	SitemapTooManyURLs   = "too-many-urls"
+	SitemapNestedIndex   = "nested-index"
+	SitemapInvalidURL    = "invalid-url"
+	SitemapOffSiteURL    = "off-site-url"
+	SitemapDuplicateURL  = "duplicate-url"
+	SitemapInvalidDate   = "invalid-lastmod"
+	SitemapBadStatus     = "bad-status"
+	SitemapRedirect      = "redirect"
+	SitemapNoindex       = "noindex"
+	SitemapCanonicalized = "canonicalized"
+	SitemapDisallowed    = "disallowed"
+)
+
+// static site check issues
+const (
+	SiteBrokenLink    = "broken-link"
+	SiteMissingAnchor = "missing-anchor"
+	SiteOrphanPage    = "orphan-page"
+)
+
+// finding categories
+const (
+	CategoryMarkup   = "markup"
+	CategoryLinks    = "links"
+	CategoryHeadings = "headings"
+	CategorySEO      = "seo"
+	CategorySecurity = "security"
+	CategoryContent  = "content"
+
+	CategoryAccessibility = "accessibility"
+)
+
+// html lint rules
+const (
+	LintDuplicateID       = "duplicate-id"
+	LintUnclosedElement   = "unclosed-element"
+	LintMisnestedElement  = "misnested-element"
+	LintNestedForm        = "nested-form"
+	LintInvalidAttribute  = "invalid-attribute"
+	LintObsoleteElement   = "obsolete-element"
+	LintObsoleteAttribute = "obsolete-attribute"
+)
+
+// analyzer rules
+const (
+	RuleBrokenLink        = "broken-link"
+	RuleMissingTitle      = "missing-title"
+	RuleMissingH1         = "missing-h1"
+	RuleMultipleH1        = "multiple-h1"
+	RuleSkippedHeading    = "skipped-heading-level"
+	RuleEmptyHeading      = "empty-heading"
+	RuleInsecureLoginForm = "insecure-login-form"
+	RuleLongSentence      = "long-sentence"
+	RuleLongParagraph     = "long-paragraph"
+)
+
+// accessibility rules
+const (
+	RuleImageMissingAlt   = "img-missing-alt"
+	RuleHTMLMissingLang   = "html-missing-lang"
+	RuleControlMissingLbl = "form-control-missing-label"
+	RuleLinkMissingText   = "link-missing-text"
+)
+
+// tls rules, for the page host and the hosts of its links
+const (
+	RuleTLSExpired          = "tls-certificate-expired"
+	RuleTLSExpiring         = "tls-certificate-expiring"
+	RuleTLSSelfSigned       = "tls-self-signed"
+	RuleTLSUntrusted        = "tls-untrusted-issuer"
+	RuleTLSHostnameMismatch = "tls-hostname-mismatch"
+	RuleTLSWeakProtocol     = "tls-weak-protocol"
+	RuleTLSWeakCipher       = "tls-weak-cipher"
+	RuleTLSWeakKey          = "tls-weak-key"
+	RuleTLSWeakSignature    = "tls-weak-signature"
+)
+
+// CertificateExpiryWarningDays certificates expiring sooner are reported
+const CertificateExpiryWarningDays = 30
+
+// policy threshold rules
+const (
+	RuleMaxInaccessibleLinks = "max-inaccessible-links"
+	RuleH1Count              = "h1-count"
+	RuleTitleLength          = "title-length"
+)
+
+// score penalty per finding by severity, a category never goes below zero
+const (
+	ScorePenaltyError   = 10
+	ScorePenaltyWarning = 5
+	ScorePenaltyInfo    = 1
+)
+
+// DefaultScoreWeights weight of each scored category in the overall score
+var DefaultScoreWeights = map[string]float64{
+	CategoryLinks:         0.25,
+	CategoryHeadings:      0.15,
+	CategorySEO:           0.2,
+	CategoryAccessibility: 0.25,
+	CategorySecurity:      0.15,
+}
+
 var CsvHeader = []string{
 	"URL",
 	"HTML Version",
@@ -35,4 +189,71 @@ var CsvHeader = []string{
 	"External Links",
 	"Inaccessible Links",
 	"Has Login Form",
+	"Errors",
+	"Warnings",
+	"Info",
+	"Score",
+	"Links Score",
+	"Headings Score",
+	"SEO Score",
+	"Accessibility Score",
+	"Security Score",
+}
+
+// ScoredCategories in the order of the score columns
+var ScoredCategories = []string{
+	CategoryLinks,
+	CategoryHeadings,
+	CategorySEO,
+	CategoryAccessibility,
+	CategorySecurity,
+}
+
+var ReadabilityCsvHeader = []string{
+	"Reading Ease",
+	"Grade Level",
+	"Avg Sentence Length",
+	"Long Sentences",
+	"Long Paragraphs",
+}
+
+var DuplicatesCsvHeader = []string{
+	"Kind",
+	"Cluster",
+	"URL",
+}
+
+var SiteCsvHeader = []string{
+	"Kind",
+	"Page",
+	"Target",
+}
+
+var PolicyCsvHeader = []string{
+	"Passed",
+	"Failures",
+}
+
+var FetchCsvHeader = []string{
+	"Final URL",
+	"Protocol",
+	"Remote IP",
+	"Compressed Size",
+	"Uncompressed Size",
+	"DNS ms",
+	"Connect ms",
+	"TLS ms",
+	"TTFB ms",
+	"Total ms",
+}
+
+var RobotsCsvHeader = []string{
+	"Disallowed Links",
+}
+
+var SitemapCsvHeader = []string{
+	"Kind",
+	"Sitemap",
+	"URL",
+	"Detail",
 }
@@ -4,12 +4,57 @@ type AnalysisResult struct {
 	HTMLVersion  string         `json:"htmlVersion"`
 	Title        string         `json:"title"`
 	Headings     map[string]int `json:"headings"` // h1-h6
+	Outline      []Heading      `json:"outline"`
 	Links        LinkAnalysis   `json:"links"`
 	HasLoginForm bool           `json:"hasLoginForm"`
+	Forms        []Form         `json:"forms"`
+	Fingerprint  Fingerprint    `json:"fingerprint"`
+
+	Findings        []Finding       `json:"findings"`
+	FindingsSummary FindingsSummary `json:"findingsSummary"`
+	Verdict         *Verdict        `json:"verdict,omitempty"` // only when a policy is set
+	Score           Score           `json:"score"`
+
+	// Extracted values of the extraction rules by rule name, a string or a list of strings
+	Extracted map[string]any `json:"extracted,omitempty"`
+
+	Readability *ReadabilityAnalysis `json:"readability,omitempty"`
+
+	// Fetch how the page was fetched, when it was fetched over http
+	Fetch *FetchMetadata `json:"fetch,omitempty"`
 }
 
 type LinkAnalysis struct {
 	Internal     int `json:"internal"`
 	External     int `json:"external"`
 	Inaccessible int `json:"inaccessible"`
+	// Disallowed links robots.txt doesn't allow to check, not counted as inaccessible
+	Disallowed int `json:"disallowed"`
+	// Unchecked relative links of a page without a base url to resolve them against
+	Unchecked int    `json:"unchecked"`
+	Items     []Link `json:"items"`
+	// TLS the https hosts of the checked links other than the page's own
+	TLS []TLSInfo `json:"tls,omitempty"`
+}
+
+type Link struct {
+	Href       string `json:"href"`
+	Internal   bool   `json:"internal"`
+	Accessible bool   `json:"accessible"`
+	Disallowed bool   `json:"disallowed,omitempty"` // by robots.txt, so not checked
+	Unchecked  bool   `json:"unchecked,omitempty"`  // relative, without a base url
+	Location
+}
+
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	Location
+}
+
+type Form struct {
+	Action  string `json:"action"`
+	Method  string `json:"method"`
+	IsLogin bool   `json:"isLogin"`
+	Location
 }
@@ -0,0 +1,103 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// CrawlRequest where a crawl starts and how far it goes, the limits fall back to the configured ones.
+type CrawlRequest struct {
+	URL      string `json:"url"`
+	MaxDepth int    `json:"maxDepth,omitempty"`
+	MaxPages int    `json:"maxPages,omitempty"`
+	// Include only urls matching one of the regular expressions are crawled, the seed always is
+	Include []string `json:"include,omitempty"`
+	// Exclude urls matching one of the regular expressions aren't crawled
+	Exclude []string `json:"exclude,omitempty"`
+	// Robots honors robots.txt, the configured default when not set
+	Robots *bool `json:"robots,omitempty"`
+}
+
+// CrawlPage one page of a crawl, the result or why it couldn't be analyzed.
+type CrawlPage struct {
+	URL    string          `json:"url"`
+	Depth  int             `json:"depth"` // links followed from the seed
+	Result *AnalysisResult `json:"result,omitempty"`
+	// Summary the score and finding counts of the result, the pages of crawl jobs only keep this
+	Summary *CrawlPageSummary `json:"summary,omitempty"`
+	Error   string            `json:"error,omitempty"`
+	// Disallowed robots.txt doesn't allow the page to be crawled
+	Disallowed bool `json:"disallowed,omitempty"`
+}
+
+// CrawlPageSummary what a crawl job keeps of the analysis of a page.
+type CrawlPageSummary struct {
+	Score             int             `json:"score"`
+	InaccessibleLinks int             `json:"inaccessibleLinks"`
+	Findings          FindingsSummary `json:"findings"`
+}
+
+// CrawlSummary the site level view of the crawled pages.
+type CrawlSummary struct {
+	Seed   string `json:"seed"`
+	Pages  int    `json:"pages"`  // analyzed
+	Failed int    `json:"failed"` // couldn't be fetched or analyzed
+	// Skipped linked urls that aren't html pages, e.g. images or pdfs
+	Skipped int `json:"skipped"`
+	// Disallowed linked urls robots.txt doesn't allow to crawl
+	Disallowed int `json:"disallowed"`
+	Depth      int `json:"depth"` // deepest level reached
+	// Truncated the page limit stopped the crawl before every in scope link was followed
+	Truncated         bool            `json:"truncated"`
+	AverageScore      int             `json:"averageScore"`
+	InaccessibleLinks int             `json:"inaccessibleLinks"`
+	Findings          FindingsSummary `json:"findings"`
+	// Rules the number of findings per rule id, over all pages
+	Rules map[string]int `json:"rules"`
+}
+
+type CrawlStatus string
+
+const (
+	CrawlQueued    CrawlStatus = "queued"
+	CrawlRunning   CrawlStatus = "running"
+	CrawlPaused    CrawlStatus = "paused" // stopped, resumable from its state file
+	CrawlDone      CrawlStatus = "done"
+	CrawlFailed    CrawlStatus = "failed"
+	CrawlCancelled CrawlStatus = "cancelled"
+)
+
+// CrawlJob a crawl run in the background by the API.
+type CrawlJob struct {
+	ID         string        `json:"id"`
+	Status     CrawlStatus   `json:"status"`
+	Request    CrawlRequest  `json:"request"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	FinishedAt *time.Time    `json:"finishedAt,omitempty"`
+	Pages      []CrawlPage   `json:"pages"`
+	Summary    *CrawlSummary `json:"summary,omitempty"` // once finished
+	Error      string        `json:"error,omitempty"`
+}
+
+// CrawlProgress how far the crawl of a state file got.
+type CrawlProgress struct {
+	Request    CrawlRequest `json:"request"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	Status     CrawlStatus  `json:"status"`
+	FinishedAt *time.Time   `json:"finishedAt,omitempty"`
+	Visited    int          `json:"visited"` // urls analyzed, failed or skipped
+	Queued     int          `json:"queued"`  // urls waiting to be visited
+	Summary    CrawlSummary `json:"summary"`
+	Error      string       `json:"error,omitempty"`
+}
+
+var (
+	// ErrInvalidCrawlRequest the seed url or a scope pattern of a crawl is invalid.
+	ErrInvalidCrawlRequest = errors.New("invalid crawl request")
+	// ErrCrawlNotFound no crawl job has the id.
+	ErrCrawlNotFound = errors.New("crawl not found")
+	// ErrCrawlConflict the crawl job can't be paused or resumed in its status, or without a state dir.
+	ErrCrawlConflict = errors.New("crawl can't be paused or resumed")
+	// ErrTooManyCrawls as many crawl jobs as configured are running already.
+	ErrTooManyCrawls = errors.New("too many crawls running")
+)
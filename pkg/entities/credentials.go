@@ -0,0 +1,130 @@
+package entities
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Credentials sent with the requests to a host, the page fetch and the link checks alike.
+type Credentials struct {
+	Headers   map[string]string `json:"headers,omitempty" yaml:"headers"`
+	Cookies   map[string]string `json:"cookies,omitempty" yaml:"cookies"`
+	Basic     *BasicAuth        `json:"basic,omitempty" yaml:"basic"`
+	Bearer    string            `json:"bearer,omitempty" yaml:"bearer"` // wins over basic when both are set
+	UserAgent string            `json:"userAgent,omitempty" yaml:"userAgent"`
+}
+
+type BasicAuth struct {
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+}
+
+// String names what is set without the secrets, so credentials are safe to log.
+func (c *Credentials) String() string {
+	if c == nil {
+		return "<none>"
+	}
+
+	var parts []string
+
+	for _, kind := range []struct {
+		name   string
+		values map[string]string
+	}{{"headers", c.Headers}, {"cookies", c.Cookies}} {
+		if len(kind.values) == 0 {
+			continue
+		}
+
+		names := make([]string, 0, len(kind.values))
+		for name := range kind.values {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		parts = append(parts, fmt.Sprintf("%s=[%s]", kind.name, strings.Join(names, ",")))
+	}
+
+	if c.Basic != nil {
+		parts = append(parts, "basic="+c.Basic.Username+":***")
+	}
+
+	if c.Bearer != "" {
+		parts = append(parts, "bearer=***")
+	}
+
+	if c.UserAgent != "" {
+		parts = append(parts, "userAgent="+c.UserAgent)
+	}
+
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+type credentialsKey struct{}
+
+type hostCredentials struct {
+	host        string
+	credentials *Credentials
+}
+
+// ContextWithCredentials the requests made with the context to host carry the credentials.
+func ContextWithCredentials(ctx context.Context, host string, c *Credentials) context.Context {
+	if c == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, credentialsKey{}, hostCredentials{host: strings.ToLower(host), credentials: c})
+}
+
+// CredentialsFromContext the credentials of the context for host, nil for other hosts.
+func CredentialsFromContext(ctx context.Context, host string) *Credentials {
+	hc, ok := ctx.Value(credentialsKey{}).(hostCredentials)
+	if !ok || hc.host != strings.ToLower(host) {
+		return nil
+	}
+
+	return hc.credentials
+}
+
+type credentialsSentKey struct{}
+
+// ContextWithCredentialsSent marks a request the credential transport added credentials to, whatever
+// their kind, so the layers below it, a cache say, can tell it's personal.
+func ContextWithCredentialsSent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, credentialsSentKey{}, true)
+}
+
+// CredentialsSent whether the request of the context carries credentials.
+func CredentialsSent(ctx context.Context) bool {
+	sent, _ := ctx.Value(credentialsSentKey{}).(bool)
+
+	return sent
+}
+
+// RedactURL the url for the logs, without the password of its userinfo and the values of its query,
+// which often carry tokens and signatures. Unparsable urls are hidden entirely.
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "<invalid url>"
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+
+		for name, values := range query {
+			for i := range values {
+				values[i] = "xxxxx"
+			}
+
+			query[name] = values
+		}
+
+		u.RawQuery = query.Encode()
+	}
+
+	return u.Redacted()
+}
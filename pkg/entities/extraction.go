@@ -0,0 +1,19 @@
+package entities
+
+import "errors"
+
+var ErrInvalidExtractionRule = errors.New("invalid extraction rule")
+
+// ExtractionRule a named value to pull from the page, selected with either a css selector or xpath.
+type ExtractionRule struct {
+	Name  string `json:"name" yaml:"name"`
+	CSS   string `json:"css,omitempty" yaml:"css"`
+	XPath string `json:"xpath,omitempty" yaml:"xpath"`
+	// Attribute to read from the matched elements, their text when empty
+	Attribute string `json:"attribute,omitempty" yaml:"attribute"`
+	// List returns every match instead of the first one
+	List bool `json:"list,omitempty" yaml:"list"`
+	// Regex keeps the first capture group, or the whole match without groups.
+	// values it doesn't match are dropped
+	Regex string `json:"regex,omitempty" yaml:"regex"`
+}
@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+)
+
+type FetchResponse struct {
+	URL        string      `json:"url"` // final url, after redirects
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"-"`
+	// Metadata how the page was fetched, only for pages fetched over http
+	Metadata *FetchMetadata `json:"metadata,omitempty"`
+}
+
+// FetchMetadata the protocol, sizes and timing of fetching a page.
+type FetchMetadata struct {
+	FinalURL string `json:"finalUrl"`
+	Protocol string `json:"protocol"` // HTTP/1.1 or HTTP/2.0
+	RemoteIP string `json:"remoteIp,omitempty"`
+	// CompressedSize the body as transferred, UncompressedSize once decoded
+	CompressedSize   int64       `json:"compressedSize"`
+	UncompressedSize int64       `json:"uncompressedSize"`
+	Timing           FetchTiming `json:"timing"`
+
+	// TLS the summary of ConnectionState, in the metadata of the analysis result
+	TLS             *TLSInfo             `json:"tls,omitempty"`
+	ConnectionState *tls.ConnectionState `json:"-"`
+}
+
+// FetchTiming the phases of the request of the final url in milliseconds. DNS, connect and TLS are
+// zero when a connection is reused, Total covers the whole fetch, redirects and download included.
+type FetchTiming struct {
+	DNS              float64 `json:"dnsMs"`
+	Connect          float64 `json:"connectMs"`
+	TLSHandshake     float64 `json:"tlsHandshakeMs"`
+	TimeToFirstByte  float64 `json:"timeToFirstByteMs"`
+	Total            float64 `json:"totalMs"`
+	ReusedConnection bool    `json:"reusedConnection"`
+}
+
+var (
+	// ErrBodyTooLarge the page is bigger than the configured max body size, after decompression.
+	ErrBodyTooLarge = errors.New("response body too large")
+	// ErrUnsupportedContentType the page isn't html, e.g. a pdf or an image.
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+)
+
+// ErrDestinationBlocked the url's scheme, port or resolved address isn't allowed by the network guard.
+var ErrDestinationBlocked = errors.New("destination not allowed")
+
+// ErrNotCached offline and the url isn't in the cache.
+var ErrNotCached = errors.New("not in cache")
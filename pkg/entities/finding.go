@@ -0,0 +1,26 @@
+package entities
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Finding a single problem reported by any of the analyzers.
+type Finding struct {
+	RuleID   string   `json:"ruleId"`
+	Severity Severity `json:"severity"`
+	Category string   `json:"category"`
+	Message  string   `json:"message"`
+	HelpURL  string   `json:"helpUrl,omitempty"`
+	Location
+}
+
+type FindingsSummary struct {
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+	Info     int `json:"info"`
+	Total    int `json:"total"`
+}
@@ -0,0 +1,18 @@
+package entities
+
+// Fingerprint 64 bit simhashes as hex strings, pages with a small
+// hamming distance between their hashes are near duplicates.
+type Fingerprint struct {
+	Content   string `json:"content"`   // normalized main text
+	Structure string `json:"structure"` // dom tag paths
+}
+
+type PageFingerprint struct {
+	URL         string
+	Fingerprint Fingerprint
+}
+
+type DuplicateCluster struct {
+	Kind string   `json:"kind"` // content or structure
+	URLs []string `json:"urls"`
+}
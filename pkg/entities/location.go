@@ -0,0 +1,9 @@
+package entities
+
+// Location where an element is in the original html, line and column are 1 based
+// and left empty for elements the parser added while repairing the markup.
+type Location struct {
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Selector string `json:"selector,omitempty"`
+}
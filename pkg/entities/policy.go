@@ -0,0 +1,30 @@
+package entities
+
+// Policy what passing means for a page, loaded from a yaml or json file.
+type Policy struct {
+	// FailOn the lowest severity that fails the page, error by default
+	FailOn     Severity              `json:"failOn" yaml:"failOn"`
+	Rules      map[string]RulePolicy `json:"rules" yaml:"rules"` // keyed by rule id
+	Thresholds Thresholds            `json:"thresholds" yaml:"thresholds"`
+	// Weights of the score categories, the ones left out keep their default weight
+	Weights map[string]float64 `json:"weights" yaml:"weights"`
+}
+
+type RulePolicy struct {
+	Enabled  *bool    `json:"enabled" yaml:"enabled"`
+	Severity Severity `json:"severity" yaml:"severity"`
+}
+
+// Thresholds unset values are not checked.
+type Thresholds struct {
+	MaxInaccessibleLinks *int `json:"maxInaccessibleLinks" yaml:"maxInaccessibleLinks"`
+	H1Count              *int `json:"h1Count" yaml:"h1Count"`
+	TitleMinLength       *int `json:"titleMinLength" yaml:"titleMinLength"`
+	TitleMaxLength       *int `json:"titleMaxLength" yaml:"titleMaxLength"`
+}
+
+type Verdict struct {
+	Passed bool `json:"passed"`
+	// Failures rule ids of the findings that failed the page
+	Failures []string `json:"failures"`
+}
@@ -0,0 +1,19 @@
+package entities
+
+type ReadabilityAnalysis struct {
+	MainContent           string        `json:"mainContent"` // selector of the extracted main content
+	Words                 int           `json:"words"`
+	Sentences             int           `json:"sentences"`
+	Syllables             int           `json:"syllables"`
+	Paragraphs            int           `json:"paragraphs"`
+	FleschReadingEase     float64       `json:"fleschReadingEase"`
+	FleschKincaidGrade    float64       `json:"fleschKincaidGrade"`
+	AverageSentenceLength float64       `json:"averageSentenceLength"`
+	LongSentences         []TextFinding `json:"longSentences"`
+	LongParagraphs        []TextFinding `json:"longParagraphs"`
+}
+
+type TextFinding struct {
+	Text  string `json:"text"`
+	Words int    `json:"words"`
+}
@@ -3,4 +3,16 @@ package entities
 type RequestBody struct {
 	URL         string `json:"url"`
 	HTMLContent string `json:"htmlContent,omitempty"`
+
+	Extract []ExtractionRule `json:"extract,omitempty"`
+	// Credentials for fetching the page, reused by the link checks to the same host
+	Credentials *Credentials `json:"credentials,omitempty"`
+	// Robots honors robots.txt in the link checks, the configured default when not set
+	Robots *bool `json:"robots,omitempty"`
+}
+
+// ErrorResponse the body of the errors that need telling apart, Code names the kind of error.
+type ErrorResponse struct {
+	Code  string `json:"code"`
+	Error string `json:"error"`
 }
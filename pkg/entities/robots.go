@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrDisallowedByRobots the robots.txt of the host doesn't allow the url to be fetched.
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+type robotsKey struct{}
+
+// ContextWithRobots turns honoring robots.txt on or off for the analysis or crawl run with the
+// context, whatever the configured default.
+func ContextWithRobots(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, robotsKey{}, enabled)
+}
+
+// RobotsFromContext whether robots.txt is honored, ok is false when the context doesn't say.
+func RobotsFromContext(ctx context.Context) (enabled, ok bool) {
+	enabled, ok = ctx.Value(robotsKey{}).(bool)
+
+	return enabled, ok
+}
@@ -0,0 +1,7 @@
+package entities
+
+// Score 0-100, the overall score is the weighted average of the categories.
+type Score struct {
+	Overall    int            `json:"overall"`
+	Categories map[string]int `json:"categories"`
+}
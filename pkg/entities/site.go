@@ -0,0 +1,13 @@
+package entities
+
+// SiteReport the problems between the pages of a static site build.
+type SiteReport struct {
+	Pages  int         `json:"pages"`
+	Issues []SiteIssue `json:"issues"`
+}
+
+type SiteIssue struct {
+	Kind   string `json:"kind"`   // broken-link, missing-anchor or orphan-page
+	Page   string `json:"page"`   // file the issue is found in, relative to the site root
+	Target string `json:"target"` // link href, empty for orphan pages
+}
@@ -0,0 +1,39 @@
+package entities
+
+import "errors"
+
+var (
+	// ErrInvalidSitemap the url isn't an http url a sitemap can be read from.
+	ErrInvalidSitemap = errors.New("invalid sitemap")
+	// ErrNoSitemap the site has no sitemap that could be read.
+	ErrNoSitemap = errors.New("no sitemap found")
+)
+
+// SitemapReport the sitemaps of a site, the urls they list and the problems found in them.
+type SitemapReport struct {
+	Sitemaps []SitemapFile  `json:"sitemaps"`
+	URLs     []SitemapURL   `json:"urls"`
+	Issues   []SitemapIssue `json:"issues"`
+}
+
+// SitemapFile a sitemap read, a urlset or a sitemap index.
+type SitemapFile struct {
+	URL        string `json:"url"`
+	Index      bool   `json:"index"`
+	Entries    int    `json:"entries"`
+	Size       int64  `json:"size"` // uncompressed
+	Compressed bool   `json:"compressed"`
+}
+
+type SitemapURL struct {
+	Loc     string `json:"loc"`
+	LastMod string `json:"lastmod,omitempty"`
+	Sitemap string `json:"sitemap"` // sitemap the url is listed in
+}
+
+type SitemapIssue struct {
+	Kind    string `json:"kind"`
+	Sitemap string `json:"sitemap"`
+	URL     string `json:"url,omitempty"`    // listed url, empty for issues of the sitemap itself
+	Detail  string `json:"detail,omitempty"` // status, redirect or canonical target, or the error
+}
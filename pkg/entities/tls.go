@@ -0,0 +1,27 @@
+package entities
+
+import "time"
+
+// TLSInfo the tls connection to a host and the certificate chain it presented.
+type TLSInfo struct {
+	Host        string               `json:"host"`
+	Version     string               `json:"version,omitempty"` // e.g. TLS 1.3
+	CipherSuite string               `json:"cipherSuite,omitempty"`
+	Chain       []CertificateSummary `json:"chain"`
+	// Error why the handshake failed, the chain then only has the certificate that failed it
+	Error string `json:"error,omitempty"`
+}
+
+// CertificateSummary one certificate of a chain, the leaf first.
+type CertificateSummary struct {
+	Subject            string    `json:"subject"`
+	SANs               []string  `json:"sans,omitempty"`
+	Issuer             string    `json:"issuer"`
+	NotBefore          time.Time `json:"notBefore"`
+	NotAfter           time.Time `json:"notAfter"`
+	DaysRemaining      int       `json:"daysRemaining"` // negative once expired
+	KeyType            string    `json:"keyType"`       // RSA, ECDSA or Ed25519
+	KeySize            int       `json:"keySize"`       // bits
+	SignatureAlgorithm string    `json:"signatureAlgorithm"`
+	SelfSigned         bool      `json:"selfSigned"`
+}